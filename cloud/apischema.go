@@ -3,6 +3,9 @@ package cloud
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/osbuild/weldr-client/v2/internal/common"
 )
@@ -30,10 +33,108 @@ type ComposeResponseV1 struct {
 }
 
 // ComposeInfoV1 holds the information returned by /composes/UUID request
+// The times are zero when the server does not report them.
 type ComposeInfoV1 struct {
-	ID     string `json:"id"`
-	Kind   string `json:"kind"`
-	Status string `json:"status"`
+	ID            string          `json:"id"`
+	Kind          string          `json:"kind"`
+	Status        string          `json:"status"`
+	ImageStatus   ImageStatusV1   `json:"image_status"`
+	ImageStatuses []ImageStatusV1 `json:"image_statuses,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	StartedAt     time.Time       `json:"started_at"`
+	FinishedAt    time.Time       `json:"finished_at"`
+}
+
+// Images returns the status of each of the compose's images
+// Older servers only report the status of the first image.
+func (i ComposeInfoV1) Images() []ImageStatusV1 {
+	if len(i.ImageStatuses) > 0 {
+		return i.ImageStatuses
+	}
+	return []ImageStatusV1{i.ImageStatus}
+}
+
+// Errors returns the reasons the compose's images failed
+func (i ComposeInfoV1) Errors() []string {
+	var errors []string
+	for _, image := range i.Images() {
+		if image.Error != nil {
+			errors = append(errors, image.Error.String())
+		}
+	}
+	return errors
+}
+
+// Uploads returns the status of the uploads of all of the compose's images
+func (i ComposeInfoV1) Uploads() []UploadStatusV1 {
+	var uploads []UploadStatusV1
+	for _, image := range i.Images() {
+		if len(image.UploadStatuses) > 0 {
+			uploads = append(uploads, image.UploadStatuses...)
+		} else if image.UploadStatus != nil {
+			uploads = append(uploads, *image.UploadStatus)
+		}
+	}
+	return uploads
+}
+
+// ImageStatusV1 is the status of one of the compose's images
+// The status is one of pending, building, uploading, registering, success, or failure.
+type ImageStatusV1 struct {
+	Status         string           `json:"status"`
+	UploadStatus   *UploadStatusV1  `json:"upload_status,omitempty"` // Replaced by upload_statuses
+	UploadStatuses []UploadStatusV1 `json:"upload_statuses,omitempty"`
+	Error          *ComposeErrorV1  `json:"error,omitempty"`
+}
+
+// UploadStatusV1 is the status of an image's upload, eg. to aws or local
+type UploadStatusV1 struct {
+	Status  string          `json:"status"`
+	Type    string          `json:"type"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// ComposeErrorV1 is the reason an image build failed
+// The details depend on the error, they are often the errors of the jobs it depends on.
+type ComposeErrorV1 struct {
+	ID      int             `json:"id"`
+	Reason  string          `json:"reason"`
+	Details json.RawMessage `json:"details,omitempty"`
+}
+
+// String returns the reason and the details as compact JSON
+func (e ComposeErrorV1) String() string {
+	var details bytes.Buffer
+	if err := json.Compact(&details, e.Details); err != nil || details.String() == "null" || details.Len() == 0 {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s: %s", e.Reason, details.String())
+}
+
+// ComposeLogsV1 holds the logs returned by the /composes/UUID/logs request
+// The cloud API returns the osbuild results of each image build as a JSON object, they
+// are only available once the build is done.
+type ComposeLogsV1 struct {
+	ID          string            `json:"id"`
+	Kind        string            `json:"kind"`
+	ImageBuilds []json.RawMessage `json:"image_builds"`
+	Koji        json.RawMessage   `json:"koji,omitempty"`
+}
+
+// String returns the logs of each image build as indented JSON
+func (l ComposeLogsV1) String() string {
+	var b strings.Builder
+	for i, build := range l.ImageBuilds {
+		fmt.Fprintf(&b, "Image build %d:\n", i+1)
+		var out bytes.Buffer
+		if err := json.Indent(&out, build, "", "    "); err != nil {
+			b.Write(build)
+		} else {
+			b.Write(out.Bytes())
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
 }
 
 // ComposeDeleteV0 is returned when deleting a compose
@@ -42,6 +143,12 @@ type ComposeDeleteV0 struct {
 	ID   string `json:"id"`
 }
 
+// ComposeCancelV1 is returned when cancelling a compose
+type ComposeCancelV1 struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
 // PackageDetailsV1 contains the detailed information about a package
 // including the basic NEVRA details and the summary, description, etc.
 type PackageDetailsV1 struct {
@@ -73,34 +180,62 @@ func (pkg *PackageDetailsV1) UnmarshalJSON(data []byte) error {
 
 // ComposeRequestV1 is used to start a compose and as part of the metadata response
 type ComposeRequestV1 struct {
-	Distribution  string         `json:"distribution"`
-	Blueprint     interface{}    `json:"blueprint"`
-	ImageRequests []imageRequest `json:"image_requests"`
+	Distribution   string                   `json:"distribution"`
+	Blueprint      interface{}              `json:"blueprint"`
+	Customizations *ComposeCustomizationsV1 `json:"customizations,omitempty"`
+	ImageRequests  []ImageRequestV1         `json:"image_requests"`
 }
 
-type imageRequest struct {
-	Architecture  string      `json:"architecture"`
-	ImageType     string      `json:"image_type"`
-	Size          uint64      `json:"size,omitempty"`
-	Repositories  interface{} `json:"repositories"`
-	UploadOptions interface{} `json:"upload_options,omitempty"`
-	UploadTargets interface{} `json:"upload_targets,omitempty"`
+// ComposeCustomizationsV1 are the request customizations that are not part of the blueprint
+// PayloadRepositories are used in addition to the distribution's default repositories
+// when installing the blueprint's packages.
+type ComposeCustomizationsV1 struct {
+	PayloadRepositories []RepositoryV1 `json:"payload_repositories,omitempty"`
 }
 
-type noRepos struct{} // Empty list of repositories
+// ImageRequestV1 describes one of the images to build as part of a compose
+// Size is in bytes. Repositories and UploadOptions are passed to the server as-is,
+// UploadOptions is the older untyped upload description, new code should use UploadTargets.
+type ImageRequestV1 struct {
+	Architecture  string           `json:"architecture"`
+	ImageType     string           `json:"image_type"`
+	Size          uint64           `json:"size,omitempty"`
+	Repositories  interface{}      `json:"repositories"`
+	UploadOptions interface{}      `json:"upload_options,omitempty"`
+	UploadTargets []UploadTargetV1 `json:"upload_targets,omitempty"`
+	OSTree        *OSTreeV1        `json:"ostree,omitempty"`
+}
 
-// localTarget is used to pass 'local' and an empty upload_options object to the cloud API
-type localTarget struct {
-	Type          string   `json:"type"`
-	UploadOptions struct{} `json:"upload_options"`
+// OSTreeV1 holds the ostree settings for an image request
+// URL and Parent are optional, they are used to build on top of an existing commit
+type OSTreeV1 struct {
+	URL    string `json:"url,omitempty"`
+	Ref    string `json:"ref,omitempty"`
+	Parent string `json:"parent,omitempty"`
 }
 
+// RepositoryV1 is a repository used to build an image
+// Only one of BaseURL, MirrorList, or Metalink should be set.
+type RepositoryV1 struct {
+	BaseURL        string   `json:"baseurl,omitempty"`
+	MirrorList     string   `json:"mirrorlist,omitempty"`
+	Metalink       string   `json:"metalink,omitempty"`
+	GPGKey         string   `json:"gpgkey,omitempty"`
+	CheckGPG       bool     `json:"check_gpg"`
+	IgnoreSSL      bool     `json:"ignore_ssl"`
+	RHSM           bool     `json:"rhsm"`
+	ModuleHotfixes bool     `json:"module_hotfixes,omitempty"`
+	PackageSets    []string `json:"package_sets,omitempty"`
+}
+
+type noRepos struct{} // Empty list of repositories
+
 // InfoRequestV1 is used for the info output, it contains a subset of the blueprint fields
 // and is only suitable for output. For starting a compose use ComposeRequestV1
 type InfoRequestV1 struct {
 	Distribution  string               `json:"distribution"`
 	Blueprint     common.InfoBlueprint `json:"blueprint"`
-	ImageRequests []imageRequest       `json:"image_requests"`
+	ImageRequests []ImageRequestV1     `json:"image_requests"`
 }
 
 // ComposeMetadataV1 is returned by the /composes/UUID/metadata request
@@ -111,28 +246,21 @@ type ComposeMetadataV1 struct {
 	Request  InfoRequestV1         `json:"request"`
 }
 
-// UploadTypes extracts the upload target types
-// These are stored as interfaces because they vary based on provider type
-// But they always have a 'type' field to describe them.
-func (m *ComposeMetadataV1) UploadTypes() ([]string, error) {
+// ImageTypes returns the image type of each of the compose's image requests
+func (m *ComposeMetadataV1) ImageTypes() []string {
 	types := []string{}
-
 	for i := range m.Request.ImageRequests {
-		// Encode the UploadTargets interface{} using json
-		data := new(bytes.Buffer)
-		if err := json.NewEncoder(data).Encode(m.Request.ImageRequests[i].UploadTargets); err != nil {
-			return nil, err
-		}
+		types = append(types, m.Request.ImageRequests[i].ImageType)
+	}
+	return types
+}
 
-		// Decode just the target types, ignoring anything else
-		var targets []struct {
-			Type string `json:"type"`
-		}
-		if err := json.Unmarshal(data.Bytes(), &targets); err != nil {
-			return nil, err
-		}
+// UploadTypes returns the upload target types of all of the image requests
+func (m *ComposeMetadataV1) UploadTypes() ([]string, error) {
+	types := []string{}
 
-		for _, t := range targets {
+	for i := range m.Request.ImageRequests {
+		for _, t := range m.Request.ImageRequests[i].UploadTargets {
 			types = append(types, t.Type)
 		}
 	}
@@ -0,0 +1,182 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package cloud
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/osbuild/weldr-client/v2/internal/common"
+)
+
+// Compose is the status of a compose from either API, see Client.Composes
+type Compose = common.Compose
+
+// ComposeImage is one of the images built by a compose
+type ComposeImage = common.ComposeImage
+
+// ComposeUpload is the status of one of the compose's uploads
+type ComposeUpload = common.ComposeUpload
+
+// ComposeMetadata is the blueprint, images, and packages used by a compose
+type ComposeMetadata = common.ComposeMetadata
+
+// ComposeBackend is the set of compose operations supported by the weldr and cloud API clients
+type ComposeBackend = common.ComposeBackend
+
+// ErrUnknownCompose is returned by a ComposeBackend when the API does not have the compose
+var ErrUnknownCompose = common.ErrUnknownCompose
+
+// Composes returns the client's composes as a ComposeBackend
+// This handles composes the same way as the weldr API client's Composes. The status
+// is translated to the weldr API's status using StatusMap.
+func (c Client) Composes() ComposeBackend {
+	return composeBackend{c}
+}
+
+// FindCompose returns the backend that has the compose, and its status
+// The backends are tried in order. If none of them have it the error from the last one
+// is returned.
+func FindCompose(id string, backends ...ComposeBackend) (ComposeBackend, Compose, error) {
+	return common.FindCompose(id, backends...)
+}
+
+// composeBackend implements ComposeBackend using the cloud API
+type composeBackend struct {
+	c Client
+}
+
+// errorComposeNotFound is the API's error id for an unknown compose
+const errorComposeNotFound = "15"
+
+// composeError returns ErrUnknownCompose when the server did not find the compose
+// Some requests report an unknown UUID as a bad request, with the compose not found error id.
+func composeError(err error) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.ID == errorComposeNotFound) {
+		return common.UnknownComposeError(err.Error())
+	}
+	return err
+}
+
+// details returns the compose with its status and the blueprint and images from its metadata
+// The metadata depends on how the compose was started, if it is not available the
+// compose only has its status.
+func (b composeBackend) details(status ComposeInfoV1) Compose {
+	compose := Compose{
+		ID:       status.ID,
+		API:      "cloud",
+		Status:   status.Status,
+		State:    b.c.StatusMap(status.Status),
+		Types:    []string{},
+		Created:  status.CreatedAt,
+		Started:  status.StartedAt,
+		Finished: status.FinishedAt,
+		Errors:   status.Errors(),
+	}
+	for _, u := range status.Uploads() {
+		compose.Uploads = append(compose.Uploads, ComposeUpload{Type: u.Type, Status: u.Status})
+	}
+	metadata, err := b.c.GetComposeMetadata(status.ID)
+	if err != nil {
+		return compose
+	}
+	compose.Blueprint = metadata.Request.Blueprint.Name
+	compose.Version = metadata.Request.Blueprint.Version
+	for _, ir := range metadata.Request.ImageRequests {
+		compose.Types = append(compose.Types, ir.ImageType)
+		compose.Sizes = append(compose.Sizes, ir.Size)
+	}
+	return compose
+}
+
+func (b composeBackend) API() string {
+	return "cloud"
+}
+
+func (b composeBackend) List() ([]Compose, error) {
+	composes, err := b.c.ListComposes()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Compose
+	for _, c := range composes {
+		list = append(list, b.details(c))
+	}
+	return list, nil
+}
+
+func (b composeBackend) Info(id string) (Compose, error) {
+	status, err := b.c.ComposeInfo(id)
+	if err != nil {
+		return Compose{}, composeError(err)
+	}
+	return b.details(status), nil
+}
+
+func (b composeBackend) Wait(id string, timeout, interval time.Duration) (bool, Compose, error) {
+	aborted, status, err := b.c.ComposeWait(id, timeout, interval)
+	if err != nil {
+		return aborted, Compose{}, composeError(err)
+	}
+	return aborted, b.details(status), nil
+}
+
+func (b composeBackend) Delete(id string) error {
+	_, err := b.c.DeleteCompose(id)
+	if err != nil {
+		return composeError(err)
+	}
+	return nil
+}
+
+func (b composeBackend) Cancel(id string) error {
+	_, err := b.c.CancelCompose(id)
+	if err != nil {
+		return composeError(err)
+	}
+	return nil
+}
+
+func (b composeBackend) Image(id, path string) (string, error) {
+	fn, err := b.c.ComposeImagePath(id, path)
+	if err != nil {
+		return "", composeError(err)
+	}
+	return fn, nil
+}
+
+// Logs returns the logs of each of the compose's image builds
+// They are only available after the compose is done.
+func (b composeBackend) Logs(id string) (string, error) {
+	logs, err := b.c.ComposeLogs(id)
+	if err != nil {
+		return "", composeError(err)
+	}
+	return logs.String(), nil
+}
+
+func (b composeBackend) Metadata(id string) (ComposeMetadata, error) {
+	metadata, err := b.c.GetComposeMetadata(id)
+	if err != nil {
+		return ComposeMetadata{}, composeError(err)
+	}
+
+	var images []ComposeImage
+	for _, ir := range metadata.Request.ImageRequests {
+		image := ComposeImage{Type: ir.ImageType, Arch: ir.Architecture, Size: ir.Size, Uploads: []string{}}
+		for _, t := range ir.UploadTargets {
+			image.Uploads = append(image.Uploads, t.Type)
+		}
+		images = append(images, image)
+	}
+	return ComposeMetadata{
+		Blueprint: metadata.Request.Blueprint,
+		Images:    images,
+		Packages:  metadata.Packages,
+	}, nil
+}
@@ -0,0 +1,119 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/internal/common"
+)
+
+// backendServer returns a compose's status, metadata, and logs
+// Any other compose is not found.
+func backendServer(request *http.Request) (*http.Response, error) {
+	var sc int
+	var body string
+	switch request.URL.Path {
+	case "/api/image-builder-composer/v2/composes/":
+		sc = 200
+		body = `[{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeStatus", "status": "pending"}]`
+	case "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8":
+		sc = 200
+		body = `{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeStatus", "status": "success"}`
+	case "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/metadata":
+		sc = 200
+		body = `{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeMetadata",
+  "packages": [{"arch": "x86_64", "name": "tmux", "release": "1.fc41", "version": "3.5a"}],
+  "request": {
+    "blueprint": {"name": "tmux-image", "version": "0.0.1", "packages": [{"name": "tmux"}]},
+    "distribution": "fedora-41",
+    "image_requests": [
+      {"architecture": "x86_64", "image_type": "qcow2", "size": 4294967296,
+       "upload_targets": [{"type": "local", "upload_options": {}}]},
+      {"architecture": "aarch64", "image_type": "ami",
+       "upload_targets": [{"type": "aws", "upload_options": {}}]}
+    ]
+  }
+}`
+	case "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/logs":
+		sc = 200
+		body = `{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeLogs", "image_builds": [{"log": "done"}]}`
+	default:
+		sc = 404
+		body = `{"kind": "Error", "reason": "Compose not found"}`
+	}
+	return &http.Response{
+		StatusCode: sc,
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+	}, nil
+}
+
+func TestComposesList(t *testing.T) {
+	mc := MockClient{DoFunc: backendServer}
+	tc := NewClient(context.Background(), &mc, "")
+
+	composes, err := tc.Composes().List()
+	require.Nil(t, err)
+	require.Equal(t, 1, len(composes))
+	assert.Equal(t, Compose{
+		ID:        "008fc5ad-adad-42ec-b412-7923733483a8",
+		API:       "cloud",
+		Status:    "pending",
+		State:     "RUNNING",
+		Blueprint: "tmux-image",
+		Version:   "0.0.1",
+		Types:     []string{"qcow2", "ami"},
+		Sizes:     []uint64{4294967296, 0},
+	}, composes[0])
+	assert.Equal(t, "cloud", tc.Composes().API())
+}
+
+func TestComposesInfo(t *testing.T) {
+	mc := MockClient{DoFunc: backendServer}
+	tc := NewClient(context.Background(), &mc, "")
+
+	compose, err := tc.Composes().Info("008fc5ad-adad-42ec-b412-7923733483a8")
+	require.Nil(t, err)
+	assert.Equal(t, "success", compose.Status)
+	assert.Equal(t, "FINISHED", compose.State)
+	assert.True(t, compose.Done())
+	assert.Equal(t, "tmux-image", compose.Blueprint)
+
+	_, err = tc.Composes().Info("4b668b1a-e6b8-4dce-8828-4a8e3bef2345")
+	require.NotNil(t, err)
+	assert.True(t, errors.Is(err, ErrUnknownCompose))
+	assert.Contains(t, err.Error(), "Compose not found")
+}
+
+func TestComposesMetadata(t *testing.T) {
+	mc := MockClient{DoFunc: backendServer}
+	tc := NewClient(context.Background(), &mc, "")
+
+	metadata, err := tc.Composes().Metadata("008fc5ad-adad-42ec-b412-7923733483a8")
+	require.Nil(t, err)
+	assert.Equal(t, "tmux-image", metadata.Blueprint.Name)
+	assert.Equal(t, []common.Package{{Name: "tmux"}}, metadata.Blueprint.Packages)
+	assert.Equal(t, []ComposeImage{
+		{Type: "qcow2", Arch: "x86_64", Size: 4294967296, Uploads: []string{"local"}},
+		{Type: "ami", Arch: "aarch64", Uploads: []string{"aws"}},
+	}, metadata.Images)
+	require.Equal(t, 1, len(metadata.Packages))
+	assert.Equal(t, "tmux", metadata.Packages[0].Name)
+}
+
+func TestComposesLogs(t *testing.T) {
+	mc := MockClient{DoFunc: backendServer}
+	tc := NewClient(context.Background(), &mc, "")
+
+	logs, err := tc.Composes().Logs("008fc5ad-adad-42ec-b412-7923733483a8")
+	require.Nil(t, err)
+	assert.True(t, strings.HasPrefix(logs, "Image build 1:\n"))
+	assert.Contains(t, logs, `"log": "done"`)
+}
@@ -7,12 +7,14 @@ package cloud
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"slices"
+	"time"
 
 	"github.com/osbuild/weldr-client/v2/internal/common"
 )
@@ -54,6 +56,36 @@ func InitClientUnixSocket(ctx context.Context, socketPath string) Client {
 	return NewClient(ctx, socket, socketPath)
 }
 
+// InitClientURL configures the client to connect to a remote server using http or https
+// serverURL is the protocol, host, and optional port, eg. https://builder.example.com:443
+// tlsConfig is used for https, it can be created with the CA bundle and client certificate
+// using NewTLSConfig. If it is nil the system defaults are used.
+// It must be called before using any of the cloud.Client functions.
+func InitClientURL(ctx context.Context, serverURL string, tlsConfig *tls.Config) (Client, error) {
+	socket, protocol, host, err := common.NewRemoteHTTPClient(serverURL, tlsConfig)
+	if err != nil {
+		return Client{}, err
+	}
+	client := NewClient(ctx, socket, "")
+	client.protocol = protocol
+	client.host = host
+	client.remote = true
+	return client, nil
+}
+
+// NewTLSConfig returns a tls.Config for use with InitClientURL
+// caFile is a PEM bundle used to verify the server, the system CAs are used if it is empty.
+// certFile and keyFile are the optional PEM client certificate and key.
+func NewTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	return common.NewTLSConfig(caFile, certFile, keyFile)
+}
+
+// RetryPolicy controls how requests are retried, see SetRetryPolicy
+type RetryPolicy = common.RetryPolicy
+
+// DefaultRetryPolicy retries 3 times, waiting about 0.5, 1, and 2 seconds
+var DefaultRetryPolicy = common.DefaultRetryPolicy
+
 // Client contains details about the cloud API server connection
 // as well as functions to interact with the server
 type Client struct {
@@ -63,6 +95,13 @@ type Client struct {
 	host       string // defaults to localhost
 	socketPath string
 	rawFunc    func(string, string, int, []byte) // Pass the raw json data to a user function
+	logger     *common.RequestLogger             // Optional trace log of all requests
+	timeout    time.Duration                     // Maximum time to wait for a response, 0 for no limit
+	remote     bool                              // Connected to a remote server instead of a socket
+	token      string                            // Optional bearer token sent with every request
+	progress   common.ProgressFunc               // Optional progress reporting for file downloads
+	retry      RetryPolicy                       // Retry failed connections, disabled by default
+	retryFunc  common.RetryFunc                  // Optional function called before each retry
 	test       bool                              // Used to fake the presense of the socket for testing
 }
 
@@ -72,6 +111,72 @@ func (c *Client) SetRawCallback(f func(string, string, int, []byte)) {
 	c.rawFunc = f
 }
 
+// SetTimeout sets the maximum time to wait for the server to respond to a request
+// It does not limit the time it takes to read the response body. 0 disables the timeout.
+// Cancelling the client's context aborts requests no matter what the timeout is.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// SetToken sets a bearer token that is sent with every request
+// This is used to authenticate with a remote server. Pass "" to disable it.
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// SetLogWriter enables a JSON-lines trace log of every request made by the client
+// Each line records the time, method, route, status, latency, and the start of the
+// response body. Pass nil to disable logging.
+func (c *Client) SetLogWriter(w io.Writer) {
+	c.logger = common.NewRequestLogger(w)
+}
+
+// SetProgressCallback sets a function that is called as files are downloaded
+// It is passed the name of the file, the bytes received, the total size or -1 if it is
+// unknown, and true when the download has finished or failed. Pass nil to disable it.
+func (c *Client) SetProgressCallback(f func(fileName string, received, total int64, done bool)) {
+	c.progress = f
+}
+
+// SetProgressWriter writes the progress of file downloads to w
+// If w is a terminal a single line with the size, rate, and time remaining is updated
+// while downloading, otherwise a line is written every 10 seconds. Pass nil to disable it.
+func (c *Client) SetProgressWriter(w io.Writer) {
+	if w == nil {
+		c.progress = nil
+		return
+	}
+	c.progress = common.NewProgressWriter(w, common.IsTerminal(w))
+}
+
+// SetRetryPolicy sets how requests are retried when the connection fails
+// Requests that could not connect to the server are retried, and so are idempotent requests
+// that lost the connection or got a 502, 503, or 504 response. The zero RetryPolicy
+// disables retries, which is the default.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// SetRetryCallback sets a function that is called before a request is retried
+// It is passed the method, route, the number of the retry, the time it will wait before
+// sending it, and the reason the last attempt failed. Pass nil to disable it.
+func (c *Client) SetRetryCallback(f func(method, route string, retry int, delay time.Duration, err error)) {
+	c.retryFunc = f
+}
+
+// send makes the request, retrying it according to the client's RetryPolicy
+// Each attempt is written to the request log.
+func (c Client) send(method, url, body string, headers map[string]string) (*http.Response, error) {
+	route := common.RequestURI(url)
+	headers = common.AddBearerToken(headers, c.token)
+	return c.retry.Do(c.ctx, method, route, c.retryFunc, func() (*http.Response, error) {
+		start := time.Now()
+		resp, err := common.DoRequest(c.ctx, c.socket, c.timeout, method, url, bytes.NewReader([]byte(body)), headers)
+		c.logger.Log(method, route, start, resp, err)
+		return resp, err
+	})
+}
+
 // RawURL returns the full url for a route
 func (c Client) RawURL(route string) string {
 	if route[0] == '/' {
@@ -88,17 +193,14 @@ func (c Client) RawURL(route string) string {
 // If it is successful a http.Response will be returned. If there is an error, the response will be
 // nil and error will be returned.
 func (c Client) Request(method, route, body string, headers map[string]string) (*http.Response, error) {
-	req, err := http.NewRequest(method, c.RawURL(route), bytes.NewReader([]byte(body)))
-	if err != nil {
-		return nil, common.CheckSocketError(c.socketPath, err)
-	}
-
-	for h, v := range headers {
-		req.Header.Set(h, v)
-	}
-
-	resp, err := c.socket.Do(req)
-	if err != nil {
+	url := c.RawURL(route)
+	resp, err := c.send(method, url, body, headers)
+	if err != nil && c.ctx.Err() != nil {
+		// Cancelled by the caller, not a problem with the socket
+		return nil, err
+	} else if err != nil && c.remote {
+		return nil, err
+	} else if err != nil {
 		return nil, common.CheckSocketError(c.socketPath, err)
 	}
 
@@ -129,10 +231,8 @@ func (c Client) GetJSON(path string) ([]byte, error) {
 	c.rawFunc("GET", path, resp.StatusCode, responseBody)
 
 	// Convert the API's JSON error response to an error
-	if slices.Contains([]int{400, 401, 403, 404, 500}, resp.StatusCode) {
-		return responseBody, fmt.Errorf("GET %s failed with status %d: %s", path, resp.StatusCode, ErrorToString(responseBody))
-	} else if resp.StatusCode != 200 {
-		return responseBody, fmt.Errorf("GET %s failed with status %d: %s", path, resp.StatusCode, responseBody)
+	if resp.StatusCode != 200 {
+		return responseBody, newAPIError("GET", path, resp.StatusCode, responseBody)
 	}
 
 	return responseBody, nil
@@ -155,10 +255,8 @@ func (c Client) PostRaw(path, body string, headers map[string]string) ([]byte, e
 	c.rawFunc("POST", path, resp.StatusCode, responseBody)
 
 	// Convert the API's JSON error response to an error
-	if slices.Contains([]int{400, 401, 403, 404, 500}, resp.StatusCode) {
-		return responseBody, fmt.Errorf("POST %s failed with status %d: %s", path, resp.StatusCode, ErrorToString(responseBody))
-	} else if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		return responseBody, fmt.Errorf("POST %s failed with status %d: %s", path, resp.StatusCode, responseBody)
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return responseBody, newAPIError("POST", path, resp.StatusCode, responseBody)
 	}
 
 	return responseBody, nil
@@ -188,22 +286,56 @@ func (c Client) DeleteRaw(path string) ([]byte, error) {
 	c.rawFunc("DELETE", path, resp.StatusCode, responseBody)
 
 	// Convert the API's JSON error response to an error
-	if slices.Contains([]int{400, 401, 403, 404, 500}, resp.StatusCode) {
-		return responseBody, fmt.Errorf("DELETE %s failed with status %d: %s", path, resp.StatusCode, ErrorToString(responseBody))
-	} else if resp.StatusCode != 200 {
-		return responseBody, fmt.Errorf("DELETE %s failed with status %d: %s", path, resp.StatusCode, responseBody)
+	if resp.StatusCode != 200 {
+		return responseBody, newAPIError("DELETE", path, resp.StatusCode, responseBody)
 	}
 
 	return responseBody, nil
 }
 
+// Exists returns true if the cloud API server is available
+// For a remote server it is assumed to be available.
 func (c Client) Exists() bool {
-	if c.test {
+	if c.test || c.remote {
 		return true
 	}
 	return common.CheckSocketError(c.socketPath, nil) == nil
 }
 
+// apiErrorStatus are the response status codes that have a JSON error response body
+var apiErrorStatus = []int{400, 401, 403, 404, 500}
+
+// APIError is returned when the server responds to a request with an error status
+// Use errors.As to check the status code or the API's error id, eg. "15" for an unknown compose.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	ID         string // Error id from the API's JSON error response, if there is one
+	Code       string // Error code from the API's JSON error response, eg. IMAGE-BUILDER-COMPOSER-15
+	Message    string
+}
+
+// newAPIError returns an APIError for the response
+// The status codes with a JSON error body use its reason and details as the message,
+// otherwise the message is the body.
+func newAPIError(method, path string, statusCode int, body []byte) *APIError {
+	e := &APIError{Method: method, Path: path, StatusCode: statusCode, Message: string(body)}
+	if slices.Contains(apiErrorStatus, statusCode) {
+		e.Message = ErrorToString(body)
+		var r APIResponse
+		if err := json.Unmarshal(body, &r); err == nil && r.Kind == "Error" {
+			e.ID = r.ID
+			e.Code = r.Code
+		}
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s failed with status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
 // ErrorToString parses a cloudapi json error response and returns a printable string
 func ErrorToString(body []byte) string {
 	var r APIResponse
@@ -237,6 +369,9 @@ func (c Client) StatusMap(cloudStatus string) string {
 // If the path doesn't end in a / it is assumed to be a full path + filename and the file is
 // saved to it, or skipped if it already exists.
 // If the path ends with a / and doesn't exist it returns an error
+// The file is downloaded to a .part file which is resumed if the download is interrupted,
+// and it is checked against the server's checksum if one is included in the response.
+// The progress is reported to the SetProgressCallback or SetProgressWriter hook.
 func (c Client) GetFilePath(route, path string) (string, error) {
 	resp, err := c.Request("GET", route, "", map[string]string{})
 	if err != nil {
@@ -252,15 +387,30 @@ func (c Client) GetFilePath(route, path string) (string, error) {
 			return "", err
 		}
 
-		if slices.Contains([]int{400, 401, 403, 404, 500}, resp.StatusCode) {
+		if slices.Contains(apiErrorStatus, resp.StatusCode) {
 			// Pass the body to the callback function
 			c.rawFunc("GET", path, resp.StatusCode, responseBody)
-			return "", fmt.Errorf("GET %s failed with status %d: %s", route, resp.StatusCode, ErrorToString(responseBody))
+			return "", newAPIError("GET", route, resp.StatusCode, responseBody)
 		} else {
-			return "", fmt.Errorf("GET %s failed with status %d: %s", path, resp.StatusCode, responseBody)
+			return "", newAPIError("GET", path, resp.StatusCode, responseBody)
 		}
 	}
 
-	fileName, err := common.SaveResponseBodyToFile(resp, path)
-	return fileName, err
+	// Resume the download with a Range request if it is interrupted
+	getRange := func(headers map[string]string) (*http.Response, error) {
+		resp, err := c.Request("GET", route, "", headers)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(apiErrorStatus, resp.StatusCode) {
+			defer resp.Body.Close() //nolint:errcheck
+			responseBody, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return nil, err
+			}
+			return nil, newAPIError("GET", route, resp.StatusCode, responseBody)
+		}
+		return resp, nil
+	}
+	return common.DownloadResponseBody(resp, path, getRange, c.progress)
 }
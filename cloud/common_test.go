@@ -3,11 +3,18 @@ package cloud
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -221,6 +228,52 @@ func TestGetJSONError(t *testing.T) {
 	assert.Equal(t, "application/json", mc.Req.Header.Get("Content-Type"))
 }
 
+func TestGetJSONAPIError(t *testing.T) {
+	// Test that GetJSON returns an APIError with the status and the error id
+	jsonError := `{ "kind": "Error", "id": "15", "code": "IMAGE-BUILDER-COMPOSER-15", "details": "testing error" }`
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 404,
+				Body:       io.NopCloser(bytes.NewReader([]byte(jsonError))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	_, err := tc.GetJSON("/testroute")
+	require.Error(t, err)
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "GET", apiErr.Method)
+	assert.Equal(t, "/testroute", apiErr.Path)
+	assert.Equal(t, 404, apiErr.StatusCode)
+	assert.Equal(t, "15", apiErr.ID)
+	assert.Equal(t, "IMAGE-BUILDER-COMPOSER-15", apiErr.Code)
+	assert.Equal(t, "testing error", apiErr.Message)
+}
+
+func TestGetJSONAPIErrorNotJSON(t *testing.T) {
+	// Test that a status without a JSON error body uses the body as the message
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 502,
+				Body:       io.NopCloser(bytes.NewReader([]byte("bad gateway"))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	_, err := tc.GetJSON("/testroute")
+	require.Error(t, err)
+	assert.Equal(t, "GET /testroute failed with status 502: bad gateway", err.Error())
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 502, apiErr.StatusCode)
+	assert.Equal(t, "", apiErr.ID)
+}
+
 func TestPostRaw(t *testing.T) {
 	mc := MockClient{
 		DoFunc: func(*http.Request) (*http.Response, error) {
@@ -328,6 +381,56 @@ func TestGetFilePathFilename(t *testing.T) {
 	assert.ErrorContains(t, err, "exists, skipping download")
 }
 
+func TestGetFilePathChecksum(t *testing.T) {
+	// Test resuming a download and checking it against the server's checksum
+	var ranges []string
+	var body string
+	mc := MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			resp := http.Response{
+				Request: req,
+				Header:  http.Header{},
+			}
+			resp.Header.Set("Content-Disposition", "attachment; filename=a-very-short-file.txt")
+			// sha256 of "A Very Short File."
+			resp.Header.Set("Repr-Digest", "sha-256=:r28ZZl8wXpbIj3WfZFPwDSwGa8FHb6d+dVkRjU3Q2Wo=:")
+			resp.Header.Set("ETag", `"short-file"`)
+
+			if r := req.Header.Get("Range"); len(r) > 0 {
+				ranges = append(ranges, r)
+				resp.StatusCode = 206
+				resp.Header.Set("Content-Range", "bytes 7-17/18")
+				resp.Body = io.NopCloser(bytes.NewReader([]byte(body[7:])))
+			} else {
+				resp.StatusCode = 200
+				resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader([]byte(body[:7])), iotest.ErrReader(fmt.Errorf("connection reset by peer"))))
+			}
+			return &resp, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	tdir := t.TempDir()
+	tf := filepath.Join(tdir, "a-new-file.txt")
+	body = "A Very Short File."
+	filename, err := tc.GetFilePath("/file/a-very-short-file", tf)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"bytes=7-"}, ranges)
+	data, err := os.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, []byte(body), data)
+
+	// A corrupted download is removed
+	tf = filepath.Join(tdir, "a-bad-file.txt")
+	body = "A Very Short Fail."
+	_, err = tc.GetFilePath("/file/a-very-short-file", tf)
+	assert.ErrorContains(t, err, "checksum mismatch")
+	_, err = os.Stat(tf)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(tf + ".part")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
 func TestGetFilePathError400(t *testing.T) {
 	mc := MockClient{
 		DoFunc: func(req *http.Request) (*http.Response, error) {
@@ -383,3 +486,138 @@ func TestDeleteRawError(t *testing.T) {
 	assert.Equal(t, "DELETE", mc.Req.Method)
 	assert.Equal(t, "/testroute", mc.Req.URL.Path)
 }
+
+func TestLogWriter(t *testing.T) {
+	// Test writing a trace log of the requests
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 404,
+				Body:       io.NopCloser(bytes.NewReader([]byte(`{"kind": "Error", "details": "not found"}`))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+	var log bytes.Buffer
+	tc.SetLogWriter(&log)
+
+	body, err := tc.GetJSON("/api/image-builder-composer/v2/composes/")
+	require.NotNil(t, err)
+	assert.Equal(t, []byte(`{"kind": "Error", "details": "not found"}`), body)
+
+	var entry struct {
+		Method string `json:"method"`
+		Route  string `json:"route"`
+		Status int    `json:"status"`
+		Body   string `json:"body"`
+	}
+	err = json.Unmarshal(log.Bytes(), &entry)
+	require.Nil(t, err)
+	assert.Equal(t, "GET", entry.Method)
+	assert.Equal(t, "/api/image-builder-composer/v2/composes/", entry.Route)
+	assert.Equal(t, 404, entry.Status)
+	assert.Equal(t, `{"kind": "Error", "details": "not found"}`, entry.Body)
+}
+
+func TestRetry(t *testing.T) {
+	// Test retrying a GET when the server is restarting
+	var attempts int
+	mc := MockClient{
+		DoFunc: func(request *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts < 3 {
+				return &http.Response{
+					StatusCode: 503,
+					Body:       io.NopCloser(bytes.NewReader([]byte("Service Unavailable"))),
+				}, nil
+			}
+			return &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewReader([]byte(`{"kind": "ComposeStatus"}`))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+	tc.SetRetryPolicy(RetryPolicy{Attempts: 3, Backoff: time.Millisecond})
+	var retries []int
+	tc.SetRetryCallback(func(method, route string, retry int, delay time.Duration, err error) {
+		assert.Equal(t, "GET", method)
+		assert.Equal(t, "/api/image-builder-composer/v2/composes/", route)
+		assert.ErrorContains(t, err, "status 503")
+		retries = append(retries, retry)
+	})
+	var log bytes.Buffer
+	tc.SetLogWriter(&log)
+
+	body, err := tc.GetJSON("/api/image-builder-composer/v2/composes/")
+	require.Nil(t, err)
+	assert.Equal(t, []byte(`{"kind": "ComposeStatus"}`), body)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []int{1, 2}, retries)
+	// Each attempt is logged
+	assert.Equal(t, 3, bytes.Count(log.Bytes(), []byte("\n")))
+
+	// POST is not idempotent, it is not retried
+	attempts = 0
+	_, err = tc.PostJSON("/api/image-builder-composer/v2/compose", "{}")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed with status 503")
+	assert.Equal(t, 1, attempts)
+}
+
+func TestInitClientURL(t *testing.T) {
+	// Test connecting to a remote server over https with a bearer token
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path": "%s"}`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	// Write the server's certificate to a CA bundle
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.Nil(t, os.WriteFile(caFile, ca, 0600))
+	tlsConfig, err := NewTLSConfig(caFile, "", "")
+	require.Nil(t, err)
+
+	tc, err := InitClientURL(context.Background(), server.URL, tlsConfig)
+	require.Nil(t, err)
+	tc.SetToken("secret-token")
+
+	body, err := tc.GetJSON("/api/image-builder-composer/v2/composes/")
+	require.Nil(t, err)
+	assert.Equal(t, `{"path": "/api/image-builder-composer/v2/composes/"}`, string(body))
+
+	// Without the token the server refuses the request
+	tc.SetToken("")
+	_, err = tc.GetJSON("/api/image-builder-composer/v2/composes/")
+	assert.NotNil(t, err)
+}
+
+func TestInitClientURLBadCA(t *testing.T) {
+	// Test that the server certificate is verified
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "{}")
+	}))
+	defer server.Close()
+
+	tc, err := InitClientURL(context.Background(), server.URL, nil)
+	require.Nil(t, err)
+	_, err = tc.GetJSON("/api/image-builder-composer/v2/composes/")
+	assert.ErrorContains(t, err, "certificate")
+}
+
+func TestInitClientURLError(t *testing.T) {
+	_, err := InitClientURL(context.Background(), "ftp://builder", nil)
+	assert.ErrorContains(t, err, "protocol must be http or https")
+}
+
+func TestRemoteExists(t *testing.T) {
+	tc, err := InitClientURL(context.Background(), "https://builder.example.com", nil)
+	require.Nil(t, err)
+	assert.True(t, tc.Exists())
+}
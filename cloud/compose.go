@@ -3,6 +3,8 @@ package cloud
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"slices"
 	"time"
 
 	"github.com/osbuild/weldr-client/v2/internal/common"
@@ -13,33 +15,102 @@ import (
 // enabled in the osbuild-composer.service file
 // The composeType must be one of the cloud API supported types
 func (c Client) StartCompose(blueprint interface{}, composeType string, size uint) (string, error) {
-	local := []localTarget{localTarget{Type: "local"}}
-	return c.StartComposeUpload(blueprint, composeType, "local", nil, local, size)
+	return c.StartComposeImages(blueprint, []ImageRequestV1{NewLocalImageRequest(composeType, size)})
+}
+
+// NewLocalImageRequest returns an image request that saves the image on the server
+// The size is in MiB, 0 uses the image type's default size.
+func NewLocalImageRequest(imageType string, size uint) ImageRequestV1 {
+	return NewUploadImageRequest(imageType, size, NewUploadTarget(LocalUploadOptionsV1{}))
+}
+
+// NewUploadImageRequest returns an image request that uploads the image to the target
+// The size is in MiB, 0 uses the image type's default size.
+func NewUploadImageRequest(imageType string, size uint, target UploadTargetV1) ImageRequestV1 {
+	return ImageRequestV1{
+		ImageType:     imageType,
+		Size:          uint64(size) * 1024 * 1024,
+		UploadTargets: []UploadTargetV1{target},
+	}
 }
 
 // StartComposeUpload uses a blueprint and an upload options description to start a compose
-// The composeType must be one of the cloud API supported types
+// The composeType must be one of the cloud API supported types. uploadOptions and
+// uploadTargets are passed to the server as-is, uploadTargets must be a list of targets
+// with types supported by UploadTargetV1.
+//
+// Deprecated: Use StartComposeUploadTarget, it checks the upload options before starting the compose.
 func (c Client) StartComposeUpload(blueprint interface{}, composeType string, uploadName string, uploadOptions interface{}, uploadTargets interface{}, size uint) (string, error) {
-	byteSize := uint64(size) * 1024 * 1024
-	distro, err := common.GetHostDistroName()
-	if err != nil {
+	image := ImageRequestV1{
+		ImageType:     composeType,
+		Size:          uint64(size) * 1024 * 1024,
+		UploadOptions: uploadOptions,
+	}
+	if uploadTargets != nil {
+		// Convert the targets to the typed targets by way of their JSON
+		data, err := json.Marshal(uploadTargets)
+		if err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal(data, &image.UploadTargets); err != nil {
+			return "", fmt.Errorf("upload targets - %s", err)
+		}
+	}
+	return c.StartComposeImages(blueprint, []ImageRequestV1{image})
+}
+
+// StartComposeUploadTarget uses a blueprint and an upload target to start a compose
+// The composeType must be one of the cloud API supported types. The target is validated
+// before the compose is started.
+func (c Client) StartComposeUploadTarget(blueprint interface{}, composeType string, target UploadTargetV1, size uint) (string, error) {
+	if err := target.Validate(); err != nil {
 		return "", err
 	}
+	return c.StartComposeImages(blueprint, []ImageRequestV1{NewUploadImageRequest(composeType, size, target)})
+}
+
+// StartComposeImages uses a blueprint to start a compose that builds one or more images
+// Each image request must have an ImageType that is one of the cloud API supported types.
+// If the Architecture is empty it uses the host's architecture, and if Repositories
+// is nil it uses the distribution's default repositories. Repositories, eg. a list of
+// RepositoryV1, replace the default repositories.
+func (c Client) StartComposeImages(blueprint interface{}, images []ImageRequestV1) (string, error) {
+	return c.StartComposeRequest(ComposeRequestV1{Blueprint: blueprint, ImageRequests: images})
+}
 
-	request := ComposeRequestV1{
-		Distribution: distro,
-		Blueprint:    blueprint,
-		ImageRequests: []imageRequest{
-			imageRequest{
-				Architecture:  common.HostArch(), // Build for the same arch as the host
-				ImageType:     composeType,
-				Size:          byteSize,
-				Repositories:  []noRepos{}, // Empty list of repos, use default for distro
-				UploadOptions: uploadOptions,
-				UploadTargets: uploadTargets,
-			},
-		},
+// StartComposeRequest starts a compose using a complete compose request
+// If the Distribution is empty it uses the host's distribution. The image requests use the
+// same defaults as StartComposeImages. The upload targets are validated before the request
+// is sent, returning an error that names the first missing required key.
+func (c Client) StartComposeRequest(request ComposeRequestV1) (string, error) {
+	if len(request.ImageRequests) == 0 {
+		return "", fmt.Errorf("no image requests")
 	}
+	if len(request.Distribution) == 0 {
+		distro, err := common.GetHostDistroName()
+		if err != nil {
+			return "", err
+		}
+		request.Distribution = distro
+	}
+
+	// Fill in the defaults without changing the caller's list
+	images := make([]ImageRequestV1, len(request.ImageRequests))
+	for i, image := range request.ImageRequests {
+		for _, t := range image.UploadTargets {
+			if err := t.Validate(); err != nil {
+				return "", err
+			}
+		}
+		if len(image.Architecture) == 0 {
+			image.Architecture = common.HostArch() // Build for the same arch as the host
+		}
+		if image.Repositories == nil {
+			image.Repositories = []noRepos{} // Empty list of repos, use default for distro
+		}
+		images[i] = image
+	}
+	request.ImageRequests = images
 
 	data, err := json.Marshal(request)
 	if err != nil {
@@ -48,7 +119,7 @@ func (c Client) StartComposeUpload(blueprint interface{}, composeType string, up
 
 	body, err := c.PostJSON("api/image-builder-composer/v2/compose", string(data))
 	if err != nil {
-		return "", fmt.Errorf("%s - %s", ErrorToString(body), err)
+		return "", fmt.Errorf("%s - %w", ErrorToString(body), err)
 	}
 
 	var r ComposeResponseV1
@@ -67,7 +138,7 @@ func (c Client) StartComposeUpload(blueprint interface{}, composeType string, up
 func (c Client) ComposeInfo(id string) (ComposeInfoV1, error) {
 	body, err := c.GetJSON("api/image-builder-composer/v2/composes/" + id)
 	if err != nil {
-		return ComposeInfoV1{}, fmt.Errorf("%s - %s", ErrorToString(body), err)
+		return ComposeInfoV1{}, fmt.Errorf("%s - %w", ErrorToString(body), err)
 	}
 
 	var status ComposeInfoV1
@@ -103,6 +174,9 @@ func (c Client) ComposeWait(id string, timeout, interval time.Duration) (aborted
 				return false, status, err
 			}
 			check.Reset(interval)
+		case <-c.ctx.Done():
+			// Cancelled by the caller, eg. ctrl-c
+			return false, status, c.ctx.Err()
 		case <-abort.C:
 			// Timed out, but no errors to report, status will have last status
 			return true, status, nil
@@ -110,6 +184,27 @@ func (c Client) ComposeWait(id string, timeout, interval time.Duration) (aborted
 	}
 }
 
+// ComposeEvent is a change in the status of a compose
+// It is the same type for the weldr and cloud API clients, see ComposeWatch.
+type ComposeEvent = common.ComposeEvent
+
+// ComposeWatch sends an event on the channel each time the status of the compose changes
+// The status is checked every interval, the first event has the status when the watch
+// starts, eg. pending -> success. The event's State has the status as RUNNING, FINISHED,
+// or FAILED, the same as the weldr API. The channel is closed after the compose is done,
+// or when there is an error getting the status. Errors are sent as a last event with Err
+// set. The caller must read the events until the channel is closed, or cancel the client's
+// context to stop it.
+func (c Client) ComposeWatch(id string, interval time.Duration) <-chan ComposeEvent {
+	return common.WatchCompose(c.ctx, id, interval, func() (string, string, bool, error) {
+		status, err := c.ComposeInfo(id)
+		if err != nil {
+			return "", "", false, err
+		}
+		return status.Status, c.StatusMap(status.Status), status.Status != "pending", nil
+	})
+}
+
 // ComposeTypes returns the list of supported image types
 // Requires a distribution name and an arch
 // It actually uses
@@ -117,7 +212,7 @@ func (c Client) GetComposeTypes(distro, arch string) ([]string, error) {
 	// Get the distribution/arch/image-type matrix from the server
 	body, err := c.GetJSON("api/image-builder-composer/v2/distributions")
 	if err != nil {
-		return nil, fmt.Errorf("%s - %s", ErrorToString(body), err)
+		return nil, fmt.Errorf("%s - %w", ErrorToString(body), err)
 	}
 
 	// The response is a map of: distro -> arch -> [image-type...]
@@ -139,11 +234,27 @@ func (c Client) GetComposeTypes(distro, arch string) ([]string, error) {
 	return common.SortedMapKeys(matrix[distro][arch]), nil
 }
 
+// CheckComposeTypes checks that the image types can be built for the distribution and architecture
+// It uses the server's distribution matrix, and returns an error describing the first
+// distribution, architecture, or image type that is not supported.
+func (c Client) CheckComposeTypes(distro, arch string, composeTypes []string) error {
+	supported, err := c.GetComposeTypes(distro, arch)
+	if err != nil {
+		return err
+	}
+	for _, t := range composeTypes {
+		if !slices.Contains(supported, t) {
+			return fmt.Errorf("%s is not a supported image type for %s on %s", t, distro, arch)
+		}
+	}
+	return nil
+}
+
 // ListComposes returns status of all of the cloud composes on the server
 func (c Client) ListComposes() ([]ComposeInfoV1, error) {
 	body, err := c.GetJSON("api/image-builder-composer/v2/composes/")
 	if err != nil {
-		return nil, fmt.Errorf("%s - %s", ErrorToString(body), err)
+		return nil, fmt.Errorf("%s - %w", ErrorToString(body), err)
 	}
 
 	var status []ComposeInfoV1
@@ -160,7 +271,7 @@ func (c Client) GetComposeMetadata(id string) (ComposeMetadataV1, error) {
 	route := fmt.Sprintf("api/image-builder-composer/v2/composes/%s/metadata", id)
 	body, err := c.GetJSON(route)
 	if err != nil {
-		return ComposeMetadataV1{}, fmt.Errorf("%s - %s", ErrorToString(body), err)
+		return ComposeMetadataV1{}, fmt.Errorf("%s - %w", ErrorToString(body), err)
 	}
 
 	var metadata ComposeMetadataV1
@@ -172,6 +283,52 @@ func (c Client) GetComposeMetadata(id string) (ComposeMetadataV1, error) {
 	return metadata, nil
 }
 
+// ComposeLogs returns the logs of the compose's image builds
+func (c Client) ComposeLogs(id string) (ComposeLogsV1, error) {
+	route := fmt.Sprintf("api/image-builder-composer/v2/composes/%s/logs", id)
+	body, err := c.GetJSON(route)
+	if err != nil {
+		return ComposeLogsV1{}, fmt.Errorf("%s - %w", ErrorToString(body), err)
+	}
+
+	var logs ComposeLogsV1
+	err = json.Unmarshal(body, &logs)
+	if err != nil {
+		return ComposeLogsV1{}, fmt.Errorf("Error parsing body of logs: %s", err)
+	}
+
+	return logs, nil
+}
+
+// ComposeLogFollow waits for the compose to be done and writes its logs to w
+// The cloud API only has the logs once the build is done, so this checks the status
+// every interval until it is not 'pending'. It returns the final status of the compose.
+func (c Client) ComposeLogFollow(id string, interval time.Duration, w io.Writer) (status ComposeInfoV1, err error) {
+	check := time.NewTimer(0)
+	defer check.Stop()
+	for {
+		select {
+		case <-check.C:
+			status, err = c.ComposeInfo(id)
+			if err != nil {
+				return status, err
+			}
+			if status.Status != "pending" {
+				logs, err := c.ComposeLogs(id)
+				if err != nil {
+					return status, err
+				}
+				_, err = io.WriteString(w, logs.String())
+				return status, err
+			}
+			check.Reset(interval)
+		case <-c.ctx.Done():
+			// Cancelled by the caller, eg. ctrl-c
+			return status, c.ctx.Err()
+		}
+	}
+}
+
 // ComposeImagePath saves the compose's image to a directory or file in path
 // It returns the filename, and the error.
 func (c Client) ComposeImagePath(id, path string) (string, error) {
@@ -194,3 +351,21 @@ func (c Client) DeleteCompose(id string) (ComposeDeleteV0, error) {
 
 	return response, nil
 }
+
+// CancelCompose stops a cloud compose that is still pending
+// The compose is marked as failed, it can then be deleted with DeleteCompose.
+func (c Client) CancelCompose(id string) (ComposeCancelV1, error) {
+	route := fmt.Sprintf("api/image-builder-composer/v2/composes/%s/cancel", id)
+	body, err := c.PostJSON(route, "")
+	if err != nil {
+		return ComposeCancelV1{}, err
+	}
+
+	var response ComposeCancelV1
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return ComposeCancelV1{}, fmt.Errorf("Error parsing body of cancel: %s", err)
+	}
+
+	return response, nil
+}
@@ -3,6 +3,8 @@ package cloud
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"os"
@@ -13,6 +15,8 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/internal/common"
 )
 
 func TestStartCompose(t *testing.T) {
@@ -89,6 +93,113 @@ func TestStartComposeUpload(t *testing.T) {
 	assert.Contains(t, string(body), "AWS_SECRET_ACCESS_KEY")
 }
 
+func TestStartComposeUploadTarget(t *testing.T) {
+	json := `{"href": "/api/image-builder-composer/v2/compose", "kind": "ComposeId", "id": "b9f75040-daf7-4470-b38e-e71ed74b5906"}`
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 201,
+				Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	bp := `name="bp test"
+			version="1.1.1"`
+	var blueprint interface{}
+	err := toml.Unmarshal([]byte(bp), &blueprint)
+	require.Nil(t, err)
+
+	target := NewUploadTarget(AWSEC2UploadOptionsV1{Region: "us-east-1", SnapshotName: "test-ami"})
+	id, err := tc.StartComposeUploadTarget(blueprint, "ami", target, 0)
+	require.Nil(t, err)
+	assert.Equal(t, "b9f75040-daf7-4470-b38e-e71ed74b5906", id)
+	assert.Equal(t, "POST", mc.Req.Method)
+	assert.Equal(t, "/api/image-builder-composer/v2/compose", mc.Req.URL.Path)
+	body, err := io.ReadAll(mc.Req.Body)
+	assert.Nil(t, mc.Req.Body.Close())
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), "bp test")
+	assert.Contains(t, string(body), "1.1.1")
+	assert.NotContains(t, string(body), "local")
+	assert.Contains(t, string(body), `"upload_targets":[{"type":"aws","upload_options":{"region":"us-east-1","snapshot_name":"test-ami"}}]`)
+}
+
+func TestStartComposeUploadMissingKey(t *testing.T) {
+	mc := MockClient{}
+	tc := NewClient(context.Background(), &mc, "")
+
+	// Required options are checked before sending the request
+	target := NewUploadTarget(AzureUploadOptionsV1{TenantID: "tenant", ResourceGroup: "group"})
+	_, err := tc.StartComposeUploadTarget(nil, "vhd", target, 0)
+	assert.EqualError(t, err, "azure upload is missing the required upload_options key: subscription_id")
+	assert.Equal(t, "", mc.Req.Method)
+
+	// As are the targets of every image request
+	images := []ImageRequestV1{
+		NewLocalImageRequest("qcow2", 0),
+		NewUploadImageRequest("vhd", 0, target),
+	}
+	_, err = tc.StartComposeImages(nil, images)
+	assert.EqualError(t, err, "azure upload is missing the required upload_options key: subscription_id")
+	assert.Equal(t, "", mc.Req.Method)
+}
+
+func TestStartComposeImages(t *testing.T) {
+	response := `{"href": "/api/image-builder-composer/v2/compose", "kind": "ComposeId", "id": "b9f75040-daf7-4470-b38e-e71ed74b5906"}`
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 201,
+				Body:       io.NopCloser(bytes.NewReader([]byte(response))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	bp := `name="bp test"
+			version="1.1.1"`
+	var blueprint interface{}
+	err := toml.Unmarshal([]byte(bp), &blueprint)
+	require.Nil(t, err)
+
+	images := []ImageRequestV1{
+		NewLocalImageRequest("qcow2", 0),
+		NewLocalImageRequest("vmdk", 4096),
+	}
+	images[1].Architecture = "aarch64"
+	id, err := tc.StartComposeImages(blueprint, images)
+	require.Nil(t, err)
+	assert.Equal(t, "b9f75040-daf7-4470-b38e-e71ed74b5906", id)
+	assert.Equal(t, "POST", mc.Req.Method)
+	assert.Equal(t, "/api/image-builder-composer/v2/compose", mc.Req.URL.Path)
+	body, err := io.ReadAll(mc.Req.Body)
+	assert.Nil(t, mc.Req.Body.Close())
+	require.Nil(t, err)
+
+	var request ComposeRequestV1
+	err = json.Unmarshal(body, &request)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(request.ImageRequests))
+	assert.Equal(t, "qcow2", request.ImageRequests[0].ImageType)
+	assert.Equal(t, common.HostArch(), request.ImageRequests[0].Architecture)
+	assert.Equal(t, uint64(0), request.ImageRequests[0].Size)
+	assert.Equal(t, "vmdk", request.ImageRequests[1].ImageType)
+	assert.Equal(t, "aarch64", request.ImageRequests[1].Architecture)
+	assert.Equal(t, uint64(4096*1024*1024), request.ImageRequests[1].Size)
+	assert.Contains(t, string(body), `"upload_targets":[{"type":"local"`)
+}
+
+func TestStartComposeImagesEmpty(t *testing.T) {
+	mc := MockClient{}
+	tc := NewClient(context.Background(), &mc, "")
+
+	_, err := tc.StartComposeImages(nil, []ImageRequestV1{})
+	assert.ErrorContains(t, err, "no image requests")
+	assert.Equal(t, "", mc.Req.Method)
+}
+
 func TestComposeInfo(t *testing.T) {
 	json := `{
   "href": "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8",
@@ -233,6 +344,32 @@ func TestComposeWait(t *testing.T) {
 	assert.Equal(t, "ComposeStatus", info.Kind)
 }
 
+func TestComposeWaitCancel(t *testing.T) {
+	json := `{
+  "href": "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8",
+  "id": "008fc5ad-adad-42ec-b412-7923733483a8",
+  "kind": "ComposeStatus",
+  "status": "pending"
+}`
+
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+			}, nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	tc := NewClient(ctx, &mc, "")
+
+	// Cancelling the context, eg. with ctrl-c, aborts the wait before the timeout
+	time.AfterFunc(100*time.Millisecond, cancel)
+	aborted, _, err := tc.ComposeWait("008fc5ad-adad-42ec-b412-7923733483a8", time.Minute, 10*time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, aborted)
+}
+
 func TestComposeTypes(t *testing.T) {
 	json := `{
   "distro-1": {
@@ -281,6 +418,76 @@ func TestComposeTypes(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestCheckComposeTypes(t *testing.T) {
+	json := `{
+  "distro-1": {
+    "arch-1": {
+	  "image-1-1-1": [{"name": "fedora"}, {"name": "updates"}],
+	  "image-1-1-2": [{"name": "fedora"}, {"name": "updates"}]
+	}
+  }
+}`
+
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	err := tc.CheckComposeTypes("distro-1", "arch-1", []string{"image-1-1-2", "image-1-1-1"})
+	require.Nil(t, err)
+	assert.Equal(t, "/api/image-builder-composer/v2/distributions", mc.Req.URL.Path)
+
+	err = tc.CheckComposeTypes("distro-1", "arch-1", []string{"image-1-1-1", "image-1-2-1"})
+	assert.EqualError(t, err, "image-1-2-1 is not a supported image type for distro-1 on arch-1")
+
+	err = tc.CheckComposeTypes("distro-2", "arch-1", []string{"image-1-1-1"})
+	assert.EqualError(t, err, "distro-2 is not a supported distribution")
+
+	err = tc.CheckComposeTypes("distro-1", "arch-2", []string{"image-1-1-1"})
+	assert.EqualError(t, err, "arch-2 is not a supported architecture")
+}
+
+func TestStartComposeRequest(t *testing.T) {
+	response := `{"href": "/api/image-builder-composer/v2/compose", "kind": "ComposeId", "id": "b9f75040-daf7-4470-b38e-e71ed74b5906"}`
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 201,
+				Body:       io.NopCloser(bytes.NewReader([]byte(response))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	image := NewLocalImageRequest("iot-commit", 0)
+	image.Architecture = "aarch64"
+	image.OSTree = &OSTreeV1{Ref: "fedora/aarch64/iot"}
+	id, err := tc.StartComposeRequest(ComposeRequestV1{
+		Distribution:  "rhel-9.4",
+		Blueprint:     map[string]interface{}{"name": "bp test"},
+		ImageRequests: []ImageRequestV1{image},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "b9f75040-daf7-4470-b38e-e71ed74b5906", id)
+	body, err := io.ReadAll(mc.Req.Body)
+	assert.Nil(t, mc.Req.Body.Close())
+	require.Nil(t, err)
+
+	var request ComposeRequestV1
+	err = json.Unmarshal(body, &request)
+	require.Nil(t, err)
+	assert.Equal(t, "rhel-9.4", request.Distribution)
+	require.Equal(t, 1, len(request.ImageRequests))
+	assert.Equal(t, "aarch64", request.ImageRequests[0].Architecture)
+	assert.Equal(t, &OSTreeV1{Ref: "fedora/aarch64/iot"}, request.ImageRequests[0].OSTree)
+	assert.Contains(t, string(body), `"repositories":[]`)
+}
+
 func TestListComposes(t *testing.T) {
 	json := `[{
   "href": "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8",
@@ -410,6 +617,7 @@ func TestGetComposeMetadata(t *testing.T) {
 	uploadTypes, err := metadata.UploadTypes()
 	require.NoError(t, err)
 	assert.Equal(t, []string{"local", "aws"}, uploadTypes)
+	assert.Equal(t, []string{"live-installer"}, metadata.ImageTypes())
 	assert.Equal(t, "GET", mc.Req.Method)
 	assert.Equal(t, "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/metadata", mc.Req.URL.Path)
 }
@@ -542,3 +750,155 @@ func TestDeleteComposeError(t *testing.T) {
 	assert.Equal(t, "DELETE", mc.Req.Method)
 	assert.Equal(t, "/api/image-builder-composer/v2/composes/46f6a5d0-9e42-431b-960e-f21c4ef230f4", mc.Req.URL.Path)
 }
+
+func TestCancelCompose(t *testing.T) {
+	json := `{"href": "/api/image-builder-composer/v2/composes/46f6a5d0-9e42-431b-960e-f21c4ef24f03/cancel", "kind": "ComposeCancel", "id": "46f6a5d0-9e42-431b-960e-f21c4ef24f03"}`
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	response, err := tc.CancelCompose("46f6a5d0-9e42-431b-960e-f21c4ef24f03")
+	require.Nil(t, err)
+	assert.Equal(t, "46f6a5d0-9e42-431b-960e-f21c4ef24f03", response.ID)
+	assert.Equal(t, "ComposeCancel", response.Kind)
+	assert.Equal(t, "POST", mc.Req.Method)
+	assert.Equal(t, "/api/image-builder-composer/v2/composes/46f6a5d0-9e42-431b-960e-f21c4ef24f03/cancel", mc.Req.URL.Path)
+}
+
+func TestCancelComposeError(t *testing.T) {
+	json := `{"kind": "Error", "id": "15", "code": "IMAGE-BUILDER-COMPOSER-15", "details": "job does not exist"}`
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 400,
+				Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	response, err := tc.CancelCompose("46f6a5d0-9e42-431b-960e-f21c4ef230f4")
+	require.NotNil(t, err)
+	assert.ErrorContains(t, err, "job does not exist")
+	assert.Equal(t, ComposeCancelV1{}, response)
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 400, apiErr.StatusCode)
+	assert.Equal(t, "15", apiErr.ID)
+	assert.Equal(t, "IMAGE-BUILDER-COMPOSER-15", apiErr.Code)
+
+	// The backend reports it as an unknown compose
+	err = tc.Composes().Cancel("46f6a5d0-9e42-431b-960e-f21c4ef230f4")
+	assert.True(t, errors.Is(err, ErrUnknownCompose))
+}
+
+func TestComposeInfoFailed(t *testing.T) {
+	json := `{
+  "href": "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8",
+  "id": "008fc5ad-adad-42ec-b412-7923733483a8",
+  "kind": "ComposeStatus",
+  "created_at": "2026-10-16T10:00:00Z",
+  "started_at": "2026-10-16T10:00:05Z",
+  "finished_at": "2026-10-16T10:12:30Z",
+  "image_status": {
+    "status": "failure",
+    "error": {
+      "id": 10,
+      "reason": "osbuild build failed",
+      "details": {"stage": "org.osbuild.rpm", "errors": ["package tmux not found"]}
+    }
+  },
+  "image_statuses": [
+    {
+      "status": "failure",
+      "error": {
+        "id": 10,
+        "reason": "osbuild build failed",
+        "details": {"stage": "org.osbuild.rpm", "errors": ["package tmux not found"]}
+      }
+    },
+    {
+      "status": "success",
+      "upload_statuses": [
+        {"options": {"ami": "ami-0123456789", "region": "us-east-1"}, "status": "success", "type": "aws"},
+        {"options": null, "status": "success", "type": "local"}
+      ]
+    }
+  ],
+  "status": "failure"
+}`
+
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	info, err := tc.ComposeInfo("008fc5ad-adad-42ec-b412-7923733483a8")
+	require.Nil(t, err)
+	assert.Equal(t, "failure", info.Status)
+	assert.Equal(t, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), info.CreatedAt)
+	assert.Equal(t, time.Date(2026, 10, 16, 10, 0, 5, 0, time.UTC), info.StartedAt)
+	assert.Equal(t, time.Date(2026, 10, 16, 10, 12, 30, 0, time.UTC), info.FinishedAt)
+	require.Equal(t, 2, len(info.Images()))
+	assert.Equal(t, []string{`osbuild build failed: {"stage":"org.osbuild.rpm","errors":["package tmux not found"]}`}, info.Errors())
+	uploads := info.Uploads()
+	require.Equal(t, 2, len(uploads))
+	assert.Equal(t, "aws", uploads[0].Type)
+	assert.Equal(t, "success", uploads[0].Status)
+	assert.Equal(t, "local", uploads[1].Type)
+
+	// The backend includes the times and the errors
+	compose, err := tc.Composes().Info("008fc5ad-adad-42ec-b412-7923733483a8")
+	require.Nil(t, err)
+	assert.Equal(t, "FAILED", compose.State)
+	assert.Equal(t, info.FinishedAt, compose.Finished)
+	assert.Equal(t, info.Errors(), compose.Errors)
+	assert.Equal(t, []ComposeUpload{{Type: "aws", Status: "success"}, {Type: "local", Status: "success"}}, compose.Uploads)
+}
+
+func TestComposeInfoOldServer(t *testing.T) {
+	// Older servers only report the first image and its upload_status
+	json := `{
+  "id": "008fc5ad-adad-42ec-b412-7923733483a8",
+  "kind": "ComposeStatus",
+  "image_status": {
+    "status": "success",
+    "upload_status": {"options": null, "status": "success", "type": "local"}
+  },
+  "status": "success"
+}`
+
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, "")
+
+	info, err := tc.ComposeInfo("008fc5ad-adad-42ec-b412-7923733483a8")
+	require.Nil(t, err)
+	assert.True(t, info.CreatedAt.IsZero())
+	assert.Equal(t, 1, len(info.Images()))
+	assert.Nil(t, info.Errors())
+	assert.Equal(t, []UploadStatusV1{{Status: "success", Type: "local", Options: []byte("null")}}, info.Uploads())
+}
+
+func TestComposeErrorString(t *testing.T) {
+	assert.Equal(t, "osbuild build failed", ComposeErrorV1{Reason: "osbuild build failed"}.String())
+	assert.Equal(t, "osbuild build failed", ComposeErrorV1{Reason: "osbuild build failed", Details: []byte("null")}.String())
+	assert.Equal(t, `depsolve failed: "no package tmux"`, ComposeErrorV1{Reason: "depsolve failed", Details: []byte(`"no package tmux"`)}.String())
+}
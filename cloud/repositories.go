@@ -0,0 +1,108 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package cloud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SourceV1 is a package source in the TOML format used by 'composer-cli sources add'
+// Both the newer id/gpgkeys and the older name/gpgkey_urls fields are accepted so that
+// the same file can be used with either API. PackageSets is only used by the cloud API.
+type SourceV1 struct {
+	ID             string   `toml:"id"`
+	Name           string   `toml:"name"`
+	Type           string   `toml:"type"`
+	URL            string   `toml:"url"`
+	CheckGPG       bool     `toml:"check_gpg"`
+	CheckSSL       *bool    `toml:"check_ssl"`
+	GPGKeys        []string `toml:"gpgkeys"`
+	GPGKeyURLs     []string `toml:"gpgkey_urls"`
+	Proxy          string   `toml:"proxy"`
+	RHSM           bool     `toml:"rhsm"`
+	System         bool     `toml:"system"`
+	Distros        []string `toml:"distros"`
+	ModuleHotfixes bool     `toml:"module_hotfixes"`
+	PackageSets    []string `toml:"package_sets"`
+}
+
+// ParseSourceTOML parses a package source TOML file
+func ParseSourceTOML(data []byte) (SourceV1, error) {
+	var source SourceV1
+	if err := toml.Unmarshal(data, &source); err != nil {
+		return SourceV1{}, err
+	}
+	return source, nil
+}
+
+// String returns the source's id, or the name for older sources
+func (s SourceV1) String() string {
+	if len(s.ID) > 0 {
+		return s.ID
+	}
+	return s.Name
+}
+
+// Repository converts the source into a cloud API repository
+// The cloud API only has one gpgkey field, more than one key can be used if they are all
+// armored keys, but only one key url is supported. It does not support proxies.
+func (s SourceV1) Repository() (RepositoryV1, error) {
+	if len(s.URL) == 0 {
+		return RepositoryV1{}, fmt.Errorf("source %s is missing the url", s)
+	}
+	if len(s.Proxy) > 0 {
+		return RepositoryV1{}, fmt.Errorf("source %s: proxy is not supported by the cloud API", s)
+	}
+
+	repo := RepositoryV1{
+		CheckGPG:       s.CheckGPG,
+		RHSM:           s.RHSM,
+		ModuleHotfixes: s.ModuleHotfixes,
+		PackageSets:    s.PackageSets,
+	}
+	if s.CheckSSL != nil {
+		repo.IgnoreSSL = !*s.CheckSSL
+	}
+
+	switch s.Type {
+	case "yum-baseurl":
+		repo.BaseURL = s.URL
+	case "yum-mirrorlist":
+		repo.MirrorList = s.URL
+	case "yum-metalink":
+		repo.Metalink = s.URL
+	default:
+		return RepositoryV1{}, fmt.Errorf("source %s has an unknown type: %q", s, s.Type)
+	}
+
+	keys := append(append([]string{}, s.GPGKeys...), s.GPGKeyURLs...)
+	if len(keys) > 1 {
+		for _, k := range keys {
+			if !strings.HasPrefix(strings.TrimSpace(k), "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+				return RepositoryV1{}, fmt.Errorf("source %s: only one gpg key url is supported by the cloud API", s)
+			}
+		}
+	}
+	repo.GPGKey = strings.Join(keys, "\n")
+
+	return repo, nil
+}
+
+// SupportsDistro returns true if the source can be used with the distribution
+// Sources without a list of distros are used with all of them.
+func (s SourceV1) SupportsDistro(distro string) bool {
+	if len(s.Distros) == 0 {
+		return true
+	}
+	for _, d := range s.Distros {
+		if d == distro {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package cloud
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceRepository(t *testing.T) {
+	source, err := ParseSourceTOML([]byte(`id = "custom-source-1"
+name = "Custom Package Source #1"
+type = "yum-baseurl"
+url = "https://url/path/to/repository/"
+check_gpg = true
+check_ssl = false
+gpgkeys = ["https://url/path/to/gpg-key"]
+rhsm = true
+distros = ["fedora-41"]
+package_sets = ["os"]
+`))
+	require.Nil(t, err)
+	assert.Equal(t, "custom-source-1", source.String())
+	assert.True(t, source.SupportsDistro("fedora-41"))
+	assert.False(t, source.SupportsDistro("rhel-9.4"))
+
+	repo, err := source.Repository()
+	require.Nil(t, err)
+	assert.Equal(t, RepositoryV1{
+		BaseURL:     "https://url/path/to/repository/",
+		GPGKey:      "https://url/path/to/gpg-key",
+		CheckGPG:    true,
+		IgnoreSSL:   true,
+		RHSM:        true,
+		PackageSets: []string{"os"},
+	}, repo)
+}
+
+func TestSourceRepositoryV0(t *testing.T) {
+	// Older sources use name and gpgkey_urls
+	source, err := ParseSourceTOML([]byte(`name = "custom-source-1"
+url = "https://url/path/to/metalink"
+type = "yum-metalink"
+check_ssl = true
+check_gpg = true
+gpgkey_urls = ["https://url/path/to/gpg-key"]
+`))
+	require.Nil(t, err)
+	assert.Equal(t, "custom-source-1", source.String())
+	assert.True(t, source.SupportsDistro("fedora-41"))
+
+	repo, err := source.Repository()
+	require.Nil(t, err)
+	assert.Equal(t, RepositoryV1{
+		Metalink: "https://url/path/to/metalink",
+		GPGKey:   "https://url/path/to/gpg-key",
+		CheckGPG: true,
+	}, repo)
+}
+
+func TestSourceRepositoryKeys(t *testing.T) {
+	key := "-----BEGIN PGP PUBLIC KEY BLOCK-----\nfake key\n-----END PGP PUBLIC KEY BLOCK-----"
+	source := SourceV1{
+		ID:      "keys",
+		Type:    "yum-mirrorlist",
+		URL:     "https://url/path/to/mirrorlist",
+		GPGKeys: []string{key, key},
+	}
+	repo, err := source.Repository()
+	require.Nil(t, err)
+	assert.Equal(t, "https://url/path/to/mirrorlist", repo.MirrorList)
+	assert.Equal(t, key+"\n"+key, repo.GPGKey)
+
+	// Only one url is supported
+	source.GPGKeys = []string{key, "https://url/path/to/gpg-key"}
+	_, err = source.Repository()
+	assert.EqualError(t, err, "source keys: only one gpg key url is supported by the cloud API")
+}
+
+func TestSourceRepositoryErrors(t *testing.T) {
+	_, err := SourceV1{ID: "no-url", Type: "yum-baseurl"}.Repository()
+	assert.EqualError(t, err, "source no-url is missing the url")
+
+	_, err = SourceV1{ID: "bad-type", Type: "yum-other", URL: "https://url/"}.Repository()
+	assert.EqualError(t, err, `source bad-type has an unknown type: "yum-other"`)
+
+	_, err = SourceV1{ID: "proxy", Type: "yum-baseurl", URL: "https://url/", Proxy: "https://proxy/"}.Repository()
+	assert.EqualError(t, err, "source proxy: proxy is not supported by the cloud API")
+
+	_, err = ParseSourceTOML([]byte(`id = `))
+	assert.Error(t, err)
+}
@@ -0,0 +1,315 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package cloud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ErrProviderUpload is returned when an upload TOML file uses the older provider and settings format
+var ErrProviderUpload = errors.New("upload uses the provider and settings format, not type and upload_options")
+
+// UploadOptions is implemented by the upload options for each of the cloud API upload targets
+type UploadOptions interface {
+	// UploadType returns the cloud API upload target type, eg. aws.s3
+	UploadType() string
+
+	// Validate checks the options, returning an error that names the first missing required key
+	Validate() error
+}
+
+// UploadTargetV1 is an upload target for an image request
+// Each image can have several targets, the image is uploaded to all of them.
+type UploadTargetV1 struct {
+	Type          string        `json:"type"`
+	UploadOptions UploadOptions `json:"upload_options"`
+}
+
+// AWSEC2UploadOptionsV1 uploads the image to AWS and registers it as an AMI
+type AWSEC2UploadOptionsV1 struct {
+	Region            string   `json:"region" toml:"region"`
+	SnapshotName      string   `json:"snapshot_name,omitempty" toml:"snapshot_name"`
+	ShareWithAccounts []string `json:"share_with_accounts,omitempty" toml:"share_with_accounts"`
+}
+
+// AWSS3UploadOptionsV1 uploads the image to an AWS S3 bucket
+type AWSS3UploadOptionsV1 struct {
+	Region string `json:"region" toml:"region"`
+	Public bool   `json:"public,omitempty" toml:"public"`
+}
+
+// GCPUploadOptionsV1 uploads the image to Google Cloud
+type GCPUploadOptionsV1 struct {
+	Region            string   `json:"region" toml:"region"`
+	Bucket            string   `json:"bucket,omitempty" toml:"bucket"`
+	ImageName         string   `json:"image_name,omitempty" toml:"image_name"`
+	ShareWithAccounts []string `json:"share_with_accounts,omitempty" toml:"share_with_accounts"`
+}
+
+// AzureUploadOptionsV1 uploads the image to Azure
+type AzureUploadOptionsV1 struct {
+	TenantID         string `json:"tenant_id" toml:"tenant_id"`
+	SubscriptionID   string `json:"subscription_id" toml:"subscription_id"`
+	ResourceGroup    string `json:"resource_group" toml:"resource_group"`
+	Location         string `json:"location,omitempty" toml:"location"`
+	ImageName        string `json:"image_name,omitempty" toml:"image_name"`
+	HyperVGeneration string `json:"hyper_v_generation,omitempty" toml:"hyper_v_generation"`
+}
+
+// ContainerUploadOptionsV1 pushes the image to a container registry
+// The server's configured registry and a generated name are used if they are not set.
+type ContainerUploadOptionsV1 struct {
+	Name string `json:"name,omitempty" toml:"name"`
+	Tag  string `json:"tag,omitempty" toml:"tag"`
+}
+
+// OCIUploadOptionsV1 uploads the image to Oracle Cloud object storage
+// The settings are all configured on the server.
+type OCIUploadOptionsV1 struct{}
+
+// PulpOSTreeUploadOptionsV1 uploads an ostree commit to a Pulp server
+type PulpOSTreeUploadOptionsV1 struct {
+	Basepath      string `json:"basepath" toml:"basepath"`
+	Repository    string `json:"repository,omitempty" toml:"repository"`
+	ServerAddress string `json:"server_address,omitempty" toml:"server_address"`
+}
+
+// LocalUploadOptionsV1 saves the image on the server
+type LocalUploadOptionsV1 struct{}
+
+// UploadType returns the cloud API type for AWS EC2 uploads
+func (o AWSEC2UploadOptionsV1) UploadType() string { return "aws" }
+
+// UploadType returns the cloud API type for AWS S3 uploads
+func (o AWSS3UploadOptionsV1) UploadType() string { return "aws.s3" }
+
+// UploadType returns the cloud API type for GCP uploads
+func (o GCPUploadOptionsV1) UploadType() string { return "gcp" }
+
+// UploadType returns the cloud API type for Azure uploads
+func (o AzureUploadOptionsV1) UploadType() string { return "azure" }
+
+// UploadType returns the cloud API type for container registry uploads
+func (o ContainerUploadOptionsV1) UploadType() string { return "container" }
+
+// UploadType returns the cloud API type for OCI uploads
+func (o OCIUploadOptionsV1) UploadType() string { return "oci.objectstorage" }
+
+// UploadType returns the cloud API type for Pulp ostree uploads
+func (o PulpOSTreeUploadOptionsV1) UploadType() string { return "pulp.ostree" }
+
+// UploadType returns the cloud API type for saving the image on the server
+func (o LocalUploadOptionsV1) UploadType() string { return "local" }
+
+// Validate checks that the AWS region is set
+func (o AWSEC2UploadOptionsV1) Validate() error {
+	return requireKeys(o.UploadType(), "region", o.Region)
+}
+
+// Validate checks that the AWS region is set
+func (o AWSS3UploadOptionsV1) Validate() error {
+	return requireKeys(o.UploadType(), "region", o.Region)
+}
+
+// Validate checks that the GCP region is set
+func (o GCPUploadOptionsV1) Validate() error {
+	return requireKeys(o.UploadType(), "region", o.Region)
+}
+
+// Validate checks that the Azure tenant, subscription, and resource group are set
+func (o AzureUploadOptionsV1) Validate() error {
+	return requireKeys(o.UploadType(),
+		"tenant_id", o.TenantID,
+		"subscription_id", o.SubscriptionID,
+		"resource_group", o.ResourceGroup)
+}
+
+// Validate has nothing to check, all of the container options are optional
+func (o ContainerUploadOptionsV1) Validate() error { return nil }
+
+// Validate has nothing to check, OCI has no options
+func (o OCIUploadOptionsV1) Validate() error { return nil }
+
+// Validate checks that the Pulp basepath is set
+func (o PulpOSTreeUploadOptionsV1) Validate() error {
+	return requireKeys(o.UploadType(), "basepath", o.Basepath)
+}
+
+// Validate has nothing to check, local has no options
+func (o LocalUploadOptionsV1) Validate() error { return nil }
+
+// requireKeys returns an error naming the first empty value
+// keyValues is a list of key name, value pairs
+func requireKeys(uploadType string, keyValues ...string) error {
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		if len(keyValues[i+1]) == 0 {
+			return fmt.Errorf("%s upload is missing the required upload_options key: %s", uploadType, keyValues[i])
+		}
+	}
+	return nil
+}
+
+// newUploadOptions returns a pointer to an empty set of options for the upload type
+// It returns nil if the type is not supported.
+func newUploadOptions(uploadType string) UploadOptions {
+	switch uploadType {
+	case "aws":
+		return &AWSEC2UploadOptionsV1{}
+	case "aws.s3":
+		return &AWSS3UploadOptionsV1{}
+	case "gcp":
+		return &GCPUploadOptionsV1{}
+	case "azure":
+		return &AzureUploadOptionsV1{}
+	case "container":
+		return &ContainerUploadOptionsV1{}
+	case "oci.objectstorage":
+		return &OCIUploadOptionsV1{}
+	case "pulp.ostree":
+		return &PulpOSTreeUploadOptionsV1{}
+	case "local":
+		return &LocalUploadOptionsV1{}
+	}
+	return nil
+}
+
+// UploadTypeNames returns the upload target types supported by the client
+func UploadTypeNames() []string {
+	return []string{"aws", "aws.s3", "azure", "container", "gcp", "local", "oci", "pulp.ostree"}
+}
+
+// NewUploadTarget returns an upload target using the options
+func NewUploadTarget(options UploadOptions) UploadTargetV1 {
+	return UploadTargetV1{Type: options.UploadType(), UploadOptions: options}
+}
+
+// imageNameKeys are the upload_options keys used to name the uploaded image
+var imageNameKeys = map[string]string{
+	"aws":       "snapshot_name",
+	"gcp":       "image_name",
+	"azure":     "image_name",
+	"container": "name",
+}
+
+// WithImageName returns a copy of the target that names the uploaded image
+// It returns an error if the target's type does not support naming the image.
+func (t UploadTargetV1) WithImageName(name string) (UploadTargetV1, error) {
+	key, ok := imageNameKeys[t.Type]
+	if !ok {
+		return UploadTargetV1{}, fmt.Errorf("%s upload does not support an image name", t.Type)
+	}
+
+	// Set the name by way of the JSON so that it works with any of the option types
+	options := make(map[string]interface{})
+	if t.UploadOptions != nil {
+		data, err := json.Marshal(t.UploadOptions)
+		if err != nil {
+			return UploadTargetV1{}, err
+		}
+		if err := json.Unmarshal(data, &options); err != nil {
+			return UploadTargetV1{}, err
+		}
+	}
+	options[key] = name
+	data, err := json.Marshal(options)
+	if err != nil {
+		return UploadTargetV1{}, err
+	}
+	named := newUploadOptions(t.Type)
+	if err := json.Unmarshal(data, named); err != nil {
+		return UploadTargetV1{}, err
+	}
+	return UploadTargetV1{Type: t.Type, UploadOptions: named}, nil
+}
+
+// Validate checks that the target has options matching its type, and that they are valid
+func (t UploadTargetV1) Validate() error {
+	if len(t.Type) == 0 {
+		return fmt.Errorf("upload is missing the required key: type")
+	}
+	if t.UploadOptions == nil {
+		return fmt.Errorf("%s upload is missing the upload_options", t.Type)
+	}
+	if t.UploadOptions.UploadType() != t.Type {
+		return fmt.Errorf("%s upload has %s upload_options", t.Type, t.UploadOptions.UploadType())
+	}
+	return t.UploadOptions.Validate()
+}
+
+// UnmarshalJSON decodes the upload options based on the target's type
+// The options for types that are not supported by the client are skipped, leaving
+// UploadOptions set to nil.
+func (t *UploadTargetV1) UnmarshalJSON(data []byte) error {
+	var target struct {
+		Type          string          `json:"type"`
+		UploadOptions json.RawMessage `json:"upload_options"`
+	}
+	if err := json.Unmarshal(data, &target); err != nil {
+		return err
+	}
+
+	t.Type = target.Type
+	t.UploadOptions = newUploadOptions(target.Type)
+	if t.UploadOptions != nil && len(target.UploadOptions) > 0 && string(target.UploadOptions) != "null" {
+		return json.Unmarshal(target.UploadOptions, t.UploadOptions)
+	}
+	return nil
+}
+
+// ParseUploadTargetTOML parses and validates an upload target from a TOML file
+// The file has the upload type and its upload_options, eg.
+//
+//	type = "aws.s3"
+//
+//	[upload_options]
+//	region = "us-east-1"
+//
+// 'oci' may be used as a short name for the 'oci.objectstorage' type. Unknown keys
+// are reported as errors so that typos are not silently ignored. Files in the older
+// provider and settings format return ErrProviderUpload.
+func ParseUploadTargetTOML(data []byte) (UploadTargetV1, error) {
+	var target struct {
+		Type          string         `toml:"type"`
+		Provider      string         `toml:"provider"`
+		UploadOptions toml.Primitive `toml:"upload_options"`
+	}
+	md, err := toml.Decode(string(data), &target)
+	if err != nil {
+		return UploadTargetV1{}, err
+	}
+	if len(target.Provider) > 0 && len(target.Type) == 0 {
+		return UploadTargetV1{}, ErrProviderUpload
+	}
+	if len(target.Type) == 0 {
+		return UploadTargetV1{}, fmt.Errorf("upload is missing the required key: type")
+	}
+	if target.Type == "oci" {
+		target.Type = "oci.objectstorage"
+	}
+
+	options := newUploadOptions(target.Type)
+	if options == nil {
+		return UploadTargetV1{}, fmt.Errorf("unknown upload type: %s, it must be one of: %s", target.Type, strings.Join(UploadTypeNames(), ", "))
+	}
+	if md.IsDefined("upload_options") {
+		if err := md.PrimitiveDecode(target.UploadOptions, options); err != nil {
+			return UploadTargetV1{}, err
+		}
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return UploadTargetV1{}, fmt.Errorf("%s upload has an unknown key: %s", target.Type, undecoded[0])
+	}
+
+	t := UploadTargetV1{Type: target.Type, UploadOptions: options}
+	if err := t.Validate(); err != nil {
+		return UploadTargetV1{}, err
+	}
+	return t, nil
+}
@@ -0,0 +1,153 @@
+package cloud
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUploadTargetTOML(t *testing.T) {
+	tests := []struct {
+		toml     string
+		expected UploadTargetV1
+	}{
+		{`type = "aws"
+[upload_options]
+region = "us-east-1"
+snapshot_name = "snap"
+share_with_accounts = ["123456789012"]`,
+			UploadTargetV1{"aws", &AWSEC2UploadOptionsV1{Region: "us-east-1", SnapshotName: "snap", ShareWithAccounts: []string{"123456789012"}}}},
+		{`type = "aws.s3"
+[upload_options]
+region = "us-east-1"
+public = true`,
+			UploadTargetV1{"aws.s3", &AWSS3UploadOptionsV1{Region: "us-east-1", Public: true}}},
+		{`type = "gcp"
+[upload_options]
+region = "europe-west1"
+bucket = "images"
+image_name = "tmux-image"`,
+			UploadTargetV1{"gcp", &GCPUploadOptionsV1{Region: "europe-west1", Bucket: "images", ImageName: "tmux-image"}}},
+		{`type = "azure"
+[upload_options]
+tenant_id = "tenant"
+subscription_id = "subscription"
+resource_group = "group"
+location = "eastus"
+hyper_v_generation = "V2"`,
+			UploadTargetV1{"azure", &AzureUploadOptionsV1{TenantID: "tenant", SubscriptionID: "subscription", ResourceGroup: "group", Location: "eastus", HyperVGeneration: "V2"}}},
+		{`type = "container"
+[upload_options]
+name = "tmux"
+tag = "latest"`,
+			UploadTargetV1{"container", &ContainerUploadOptionsV1{Name: "tmux", Tag: "latest"}}},
+		{`type = "container"`,
+			UploadTargetV1{"container", &ContainerUploadOptionsV1{}}},
+		{`type = "oci"`,
+			UploadTargetV1{"oci.objectstorage", &OCIUploadOptionsV1{}}},
+		{`type = "pulp.ostree"
+[upload_options]
+basepath = "edge"
+server_address = "https://pulp.example.com"`,
+			UploadTargetV1{"pulp.ostree", &PulpOSTreeUploadOptionsV1{Basepath: "edge", ServerAddress: "https://pulp.example.com"}}},
+		{`type = "local"`,
+			UploadTargetV1{"local", &LocalUploadOptionsV1{}}},
+	}
+
+	for _, tc := range tests {
+		target, err := ParseUploadTargetTOML([]byte(tc.toml))
+		require.Nil(t, err, tc.toml)
+		assert.Equal(t, tc.expected, target)
+		assert.Nil(t, target.Validate())
+	}
+}
+
+func TestParseUploadTargetTOMLErrors(t *testing.T) {
+	tests := []struct {
+		toml string
+		err  string
+	}{
+		{`type = "aws"
+[upload_options]
+snapshot_name = "snap"`, "aws upload is missing the required upload_options key: region"},
+		{`type = "aws.s3"`, "aws.s3 upload is missing the required upload_options key: region"},
+		{`type = "gcp"`, "gcp upload is missing the required upload_options key: region"},
+		{`type = "azure"
+[upload_options]
+tenant_id = "tenant"
+subscription_id = "subscription"`, "azure upload is missing the required upload_options key: resource_group"},
+		{`type = "pulp.ostree"
+[upload_options]
+repository = "edge"`, "pulp.ostree upload is missing the required upload_options key: basepath"},
+		{`type = "aws"
+[upload_options]
+region = "us-east-1"
+regoin = "us-east-2"`, "aws upload has an unknown key: upload_options.regoin"},
+		{`[upload_options]
+region = "us-east-1"`, "upload is missing the required key: type"},
+		{`type = "vsphere"`, "unknown upload type: vsphere, it must be one of: aws, aws.s3, azure, container, gcp, local, oci, pulp.ostree"},
+		{`provider = "aws"
+[settings]
+accessKeyID = "AWS_ACCESS_KEY_ID"`, "upload uses the provider and settings format, not type and upload_options"},
+	}
+
+	for _, tc := range tests {
+		_, err := ParseUploadTargetTOML([]byte(tc.toml))
+		assert.EqualError(t, err, tc.err)
+	}
+}
+
+func TestUploadTargetValidate(t *testing.T) {
+	assert.EqualError(t, UploadTargetV1{}.Validate(), "upload is missing the required key: type")
+	assert.EqualError(t, UploadTargetV1{Type: "aws"}.Validate(), "aws upload is missing the upload_options")
+	assert.EqualError(t, UploadTargetV1{Type: "aws", UploadOptions: GCPUploadOptionsV1{Region: "us"}}.Validate(),
+		"aws upload has gcp upload_options")
+	assert.Nil(t, NewUploadTarget(GCPUploadOptionsV1{Region: "us"}).Validate())
+}
+
+func TestUploadTargetJSON(t *testing.T) {
+	targets := []UploadTargetV1{
+		NewUploadTarget(LocalUploadOptionsV1{}),
+		NewUploadTarget(AWSS3UploadOptionsV1{Region: "us-east-1"}),
+	}
+	data, err := json.Marshal(targets)
+	require.Nil(t, err)
+	assert.Equal(t, `[{"type":"local","upload_options":{}},{"type":"aws.s3","upload_options":{"region":"us-east-1"}}]`, string(data))
+
+	// Unknown types keep the type without the options
+	data = []byte(`[{"type":"local","upload_options":{}},
+		{"type":"aws.s3","upload_options":{"region":"us-east-1"}},
+		{"type":"aws","upload_options":null},
+		{"type":"future","upload_options":{"key":"value"}}]`)
+	var decoded []UploadTargetV1
+	err = json.Unmarshal(data, &decoded)
+	require.Nil(t, err)
+	assert.Equal(t, []UploadTargetV1{
+		{"local", &LocalUploadOptionsV1{}},
+		{"aws.s3", &AWSS3UploadOptionsV1{Region: "us-east-1"}},
+		{"aws", &AWSEC2UploadOptionsV1{}},
+		{"future", nil},
+	}, decoded)
+}
+
+func TestUploadTargetWithImageName(t *testing.T) {
+	target := NewUploadTarget(AWSEC2UploadOptionsV1{Region: "us-east-1", ShareWithAccounts: []string{"123"}})
+	named, err := target.WithImageName("test-ami")
+	require.Nil(t, err)
+	assert.Equal(t, UploadTargetV1{"aws", &AWSEC2UploadOptionsV1{
+		Region:            "us-east-1",
+		SnapshotName:      "test-ami",
+		ShareWithAccounts: []string{"123"},
+	}}, named)
+	// The original target is not changed
+	assert.Equal(t, "", target.UploadOptions.(AWSEC2UploadOptionsV1).SnapshotName)
+
+	named, err = NewUploadTarget(&ContainerUploadOptionsV1{Tag: "latest"}).WithImageName("http-server")
+	require.Nil(t, err)
+	assert.Equal(t, UploadTargetV1{"container", &ContainerUploadOptionsV1{Name: "http-server", Tag: "latest"}}, named)
+
+	_, err = NewUploadTarget(AWSS3UploadOptionsV1{Region: "us-east-1"}).WithImageName("test-image")
+	assert.EqualError(t, err, "aws.s3 upload does not support an image name")
+}
@@ -35,9 +35,44 @@ var (
 func init() {
 	depsolveCmd.Flags().StringVarP(&distro, "distro", "", "", "Distribution")
 	depsolveCmd.Flags().StringVarP(&arch, "arch", "", "", "Architecture")
+	root.EnableOutput(depsolveCmd)
 	blueprintsCmd.AddCommand(depsolveCmd)
 }
 
+// depsolvedBlueprint is the normalized result of a depsolve from either API
+type depsolvedBlueprint struct {
+	Name     string                `json:"name"`
+	Version  string                `json:"version"`
+	Packages []common.PackageNEVRA `json:"packages"`
+}
+
+// printDepsolved prints the blueprints and their packages
+// With --output each package is a row of the csv output, with the blueprint's name and
+// version in the first columns.
+func printDepsolved(blueprints []depsolvedBlueprint) error {
+	if !root.StructuredOutput() {
+		for _, bp := range blueprints {
+			fmt.Printf("blueprint: %s v%s\n", bp.Name, bp.Version)
+			for _, d := range bp.Packages {
+				fmt.Printf("    %s\n", d)
+			}
+		}
+		return nil
+	}
+
+	var rows [][]string
+	for i, bp := range blueprints {
+		if bp.Packages == nil {
+			blueprints[i].Packages = []common.PackageNEVRA{}
+		}
+		for _, d := range bp.Packages {
+			rows = append(rows, append([]string{bp.Name, bp.Version}, root.PackageFields(d)...))
+		}
+	}
+	header := append([]string{"Blueprint", "Blueprint Version"}, root.PackageHeader...)
+	return root.PrintOutput(blueprints, header, rows)
+}
+
 func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 	// Is the blueprint a local file? If so, try to use the cloud API for the depsolve
 	f, err := os.Open(args[0])
@@ -48,16 +83,11 @@ func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 			return root.ExecutionError(cmd, "Using a local blueprint requires server support. Check to make sure that the cloudapi socket is enabled.")
 		}
 
-		if len(distro) == 0 {
-			distro, err = common.GetHostDistroName()
-			if err != nil {
-				return root.ExecutionError(cmd, "Error determining host distribution: %s", err)
-			}
-		}
-
-		if len(arch) == 0 {
-			arch = common.HostArch()
+		distro, err = root.GetDistro(distro)
+		if err != nil {
+			return root.ExecutionError(cmd, "Error determining host distribution: %s", err)
 		}
+		arch = root.GetArch(arch)
 
 		data, err := io.ReadAll(f)
 		if err != nil {
@@ -75,18 +105,14 @@ func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 		}
 
 		// Get the blueprint name and version
-		var bpNameVersion struct {
-			Name    string
-			Version string
-		}
-		err = toml.Unmarshal([]byte(data), &bpNameVersion)
+		local, err := weldr.ParseBlueprintTOML(data)
 		if err != nil {
 			return root.ExecutionError(cmd, "reading %s - %s", args[0], err)
 		}
 
-		fmt.Printf("blueprint: %s v%s\n", bpNameVersion.Name, bpNameVersion.Version)
-		for _, d := range deps {
-			fmt.Printf("    %s\n", d)
+		bp := depsolvedBlueprint{Name: local.Name, Version: local.Version, Packages: deps}
+		if err := printDepsolved([]depsolvedBlueprint{bp}); err != nil {
+			return root.ExecutionError(cmd, "%s", err)
 		}
 	} else {
 
@@ -104,11 +130,16 @@ func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 			return root.ExecutionError(cmd, "Depsolve Error: %s", err)
 		}
 
+		blueprints := []depsolvedBlueprint{}
 		for _, bp := range bps {
-			fmt.Printf("blueprint: %s v%s\n", bp.Blueprint.Name, bp.Blueprint.Version)
-			for _, d := range bp.Dependencies {
-				fmt.Printf("    %s\n", d)
-			}
+			blueprints = append(blueprints, depsolvedBlueprint{
+				Name:     bp.Blueprint.Name,
+				Version:  bp.Blueprint.Version,
+				Packages: bp.Dependencies,
+			})
+		}
+		if err := printDepsolved(blueprints); err != nil {
+			return root.ExecutionError(cmd, "%s", err)
 		}
 	}
 	// If there were any errors, even if other blueprints succeeded, it returns an error
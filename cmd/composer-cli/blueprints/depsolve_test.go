@@ -344,3 +344,63 @@ version = "3.5a"
 	assert.Equal(t, "application/json", mcc.Req.Header.Get("Content-Type"))
 	assert.Equal(t, "/api/image-builder-composer/v2/depsolve/blueprint", mcc.Req.URL.Path)
 }
+
+func TestCmdBlueprintsDepsolveOutput(t *testing.T) {
+	// Test the "blueprints depsolve --output csv" command
+	json := `{
+    "blueprints": [
+        {
+            "blueprint": {
+                "name": "cli-test-bp-1",
+                "version": "0.0.1"
+            },
+            "dependencies": [
+                {
+                    "arch": "x86_64",
+                    "epoch": 0,
+                    "name": "acl",
+                    "release": "9.fc33",
+                    "version": "2.2.53"
+                },
+                {
+                    "arch": "x86_64",
+                    "epoch": "2",
+                    "name": "vim-minimal",
+                    "release": "1.fc33",
+                    "version": "8.2.1770"
+                }
+			]
+		}],
+    "errors": []}`
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	cmd, out, err := root.ExecuteTest("blueprints", "depsolve", "--output", "csv", "cli-test-bp-1")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, depsolveCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, `Blueprint,Blueprint Version,Name,Epoch,Version,Release,Arch
+cli-test-bp-1,0.0.1,acl,0,2.2.53,9.fc33,x86_64
+cli-test-bp-1,0.0.1,vim-minimal,2,8.2.1770,1.fc33,x86_64
+`, string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+
+	cmd, out, err = root.ExecuteTest("blueprints", "depsolve", "--output", `template={{range .}}{{range .packages}}{{.name}}-{{.epoch}}{{"\n"}}{{end}}{{end}}`, "cli-test-bp-1")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err = io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "acl-0\nvim-minimal-2\n", string(stdout))
+}
@@ -5,79 +5,182 @@
 package blueprints
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/internal/common"
 	"github.com/spf13/cobra"
 )
 
 var (
 	diffCmd = &cobra.Command{
-		Use:   "diff BLUEPRINT FROM-COMMIT TO-COMMIT [-- DIFF-ARG ...]",
+		Use:   "diff BLUEPRINT FROM TO",
 		Short: "list the differences between two blueprint commits",
 		Long: `diff lists the differences between two blueprint commits:
-    FROM-COMMIT is a commit hash or NEWEST,
-    and TO-COMMIT is a commit hash, NEWEST, or WORKSPACE
-  Arguments passed after -- are passed directly to the system diff utility.`,
+    FROM is a commit hash, NEWEST, or a local blueprint file,
+    and TO is a commit hash, NEWEST, WORKSPACE, or a local blueprint file
+  Arguments ending in .toml or containing a / are read from local files.
+
+  The blueprints are compared field by field, changing the order of the packages
+  or of other lists is not a difference. Each change is printed on its own line
+  starting with + when it was added, - when it was removed, and ~ when it changed.
+  Use --output json to get the list of changes with their kind, path, name, and
+  old and new values. --json prints the server's responses for the commits.
+
+  Passing arguments to the diff utility after -- is no longer supported.`,
 		Example: `  composer-cli blueprints diff simple HASH WORKSPACE
-  composer-cli blueprints diff simple HASH NEWEST -- -c --minimal`,
+  composer-cli blueprints diff simple NEWEST ./simple.toml
+  composer-cli blueprints diff simple HASH NEWEST --output json`,
 		RunE: diff,
 		Args: cobra.MinimumNArgs(3),
 	}
 )
 
 func init() {
+	root.EnableOutput(diffCmd)
 	blueprintsCmd.AddCommand(diffCmd)
 }
 
 func diff(cmd *cobra.Command, args []string) (rcErr error) {
-	// args[0] == blueprint name, args[1] = FROM-COMMIT, args[2] = TO-COMMIT
-	// args[3] and later are passed to the diff executable
+	// args[0] == blueprint name, args[1] = FROM, args[2] = TO
+	if len(args) > 3 {
+		return root.ExecutionError(cmd, "blueprints diff no longer runs the diff utility, arguments after -- are not supported: %s", strings.Join(args[3:], " "))
+	}
 	if args[1] == "WORKSPACE" {
 		return root.ExecutionError(cmd, "FROM-COMMIT cannot be WORKSPACE")
 	}
+	if root.JSONOutput && root.IsBlueprintFile(args[1]) && root.IsBlueprintFile(args[2]) {
+		return root.ExecutionError(cmd, "--json only prints the server's responses, use --output json to compare two local files")
+	}
 
 	if root.JSONOutput {
-		err := getBlueprintJSON(args[0], args[1])
-		if err != nil {
-			return root.ExecutionError(cmd, "%s", err.Error())
-		}
-
-		err = getBlueprintJSON(args[0], args[2])
-		if err != nil {
-			return root.ExecutionError(cmd, "%s", err.Error())
+		// Only the server responses are printed, there are none for local files
+		for _, commit := range args[1:] {
+			if root.IsBlueprintFile(commit) {
+				continue
+			}
+			err := getBlueprintJSON(args[0], commit)
+			if err != nil {
+				return root.ExecutionError(cmd, "%s", err.Error())
+			}
 		}
 		return nil
 	}
 
-	fromBlueprint, err := getBlueprint(args[0], args[1])
+	fromBlueprint, err := loadBlueprint(args[0], args[1])
 	if err != nil {
 		return root.ExecutionError(cmd, "%s", err.Error())
 	}
 
-	toBlueprint, err := getBlueprint(args[0], args[2])
+	toBlueprint, err := loadBlueprint(args[0], args[2])
 	if err != nil {
 		return root.ExecutionError(cmd, "%s", err.Error())
 	}
 
-	// Everything after args[2] is passed to diff
-	// default to --color and -u if nothing passed
-	diffArgs := args[3:]
-	if len(diffArgs) == 0 {
-		diffArgs = []string{"--color", "-u"}
-	}
-
-	err = runDiff(fromBlueprint, args[1], toBlueprint, args[2], diffArgs)
+	changes, err := common.DiffBlueprints(fromBlueprint, toBlueprint)
 	if err != nil {
 		return root.ExecutionError(cmd, "%s", err)
 	}
 
+	if root.StructuredOutput() {
+		var rows [][]string
+		for _, c := range changes {
+			rows = append(rows, []string{c.Kind, c.Path, c.Name, diffValue(c.Old), diffValue(c.New)})
+		}
+		if changes == nil {
+			changes = []common.BlueprintChange{}
+		}
+		if err := root.PrintOutput(changes, []string{"Kind", "Path", "Name", "Old", "New"}, rows); err != nil {
+			return root.ExecutionError(cmd, "%s", err)
+		}
+		return nil
+	}
+
+	for _, c := range changes {
+		fmt.Println(changeString(c))
+	}
 	return nil
 }
 
+// loadBlueprint returns the blueprint from a local file, or the commit from the server
+func loadBlueprint(name, commit string) (common.Blueprint, error) {
+	if root.IsBlueprintFile(commit) {
+		data, err := os.ReadFile(commit)
+		if err != nil {
+			return common.Blueprint{}, fmt.Errorf("reading %s - %s", commit, err)
+		}
+		bp, err := common.ParseBlueprintTOML(data)
+		if err != nil {
+			return common.Blueprint{}, fmt.Errorf("%s: %s", commit, err)
+		}
+		return bp, nil
+	}
+
+	data, err := getBlueprint(name, commit)
+	if err != nil {
+		return common.Blueprint{}, err
+	}
+	bp, err := common.ParseBlueprintTOML([]byte(data))
+	if err != nil {
+		return common.Blueprint{}, fmt.Errorf("%s %s: %s", name, commit, err)
+	}
+	return bp, nil
+}
+
+// changeString returns a line describing the change
+// eg. '+ package tmux 3.5', '- customizations.user admin', or '~ version 1.0.0 -> 1.1.0'
+func changeString(c common.BlueprintChange) string {
+	var sign string
+	switch {
+	case strings.HasSuffix(c.Kind, "-added"):
+		sign = "+"
+	case strings.HasSuffix(c.Kind, "-removed"):
+		sign = "-"
+	default:
+		sign = "~"
+	}
+	// Items in the top level lists use the kind of item, everything else uses the path
+	what := c.Kind[:max(strings.LastIndex(c.Kind, "-"), 0)]
+	if what == "customization" || what == "field" || len(what) == 0 {
+		what = c.Path
+	}
+	if len(c.Name) > 0 {
+		what += " " + c.Name
+	}
+
+	// Tables in lists are identified by their name, the details are in the --output json
+	_, oldTable := c.Old.(map[string]interface{})
+	_, newTable := c.New.(map[string]interface{})
+	switch {
+	case sign == "+" && c.New != nil && !(newTable && len(c.Name) > 0):
+		return fmt.Sprintf("+ %s %s", what, diffValue(c.New))
+	case sign == "-" && c.Old != nil && !(oldTable && len(c.Name) > 0):
+		return fmt.Sprintf("- %s %s", what, diffValue(c.Old))
+	case sign == "~":
+		return fmt.Sprintf("~ %s %s -> %s", what, diffValue(c.Old), diffValue(c.New))
+	}
+	return sign + " " + what
+}
+
+// diffValue returns the value of a change as a string
+// Strings are used as they are, other values are written as JSON.
+func diffValue(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return `""`
+	case string:
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
 func getBlueprint(name, commit string) (string, error) {
 	if commit == "WORKSPACE" {
 		// If nothing has been pushed to the /blueprints/workspace this will be the latest
@@ -121,68 +224,6 @@ func getBlueprint(name, commit string) (string, error) {
 	return bp, nil
 }
 
-// runDiff runs the system diff utility from a temporary directory
-func runDiff(fromBlueprint, fromCommit, toBlueprint, toCommit string, diffArgs []string) error {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	// Make a temporary directory to save the blueprints into
-	// defer cleanup so it doesn't ever get left behind
-	tmpDir, err := os.MkdirTemp("", "bp-diff-*")
-	if err != nil {
-		return err
-	}
-	defer os.RemoveAll(tmpDir) //nolint:errcheck
-	err = os.Chdir(tmpDir)
-	if err != nil {
-		return err
-	}
-	defer os.Chdir(cwd) //nolint:errcheck
-
-	// Use saveBlueprint from save.go
-	fromFilename, err := saveBlueprint(fromBlueprint, fromCommit, "")
-	if err != nil {
-		return err
-	}
-	diffArgs = append(diffArgs, fromFilename)
-
-	toFilename, err := saveBlueprint(toBlueprint, toCommit, "")
-	if err != nil {
-		return err
-	}
-	diffArgs = append(diffArgs, toFilename)
-
-	_, err = exec.LookPath("diff")
-	if err != nil {
-		return fmt.Errorf("The diff utility is required, please install it")
-	}
-
-	cmd := exec.Command("diff", diffArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err = cmd.Start()
-	if err != nil {
-		return fmt.Errorf("diff error: %s", err)
-	}
-	err = cmd.Wait()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			// handle diff-specific return codes
-			// 0 == same file 1 == different file 2 == errors
-			if exitErr.ExitCode() > 1 {
-				return fmt.Errorf("diff error: %s", err)
-			}
-		} else {
-			// Not an exit error (IO, etc.) handle it normally
-			return err
-		}
-	}
-
-	return nil
-}
-
 // getBlueprintJSON is used for displaying the JSON
 func getBlueprintJSON(name, commit string) error {
 	if commit == "WORKSPACE" {
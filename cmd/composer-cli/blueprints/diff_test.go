@@ -6,9 +6,12 @@ package blueprints
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -17,6 +20,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/internal/common"
 )
 
 func TestCmdBlueprintsDiff(t *testing.T) {
@@ -134,14 +138,166 @@ password = "$6$CHO2$3rN8eviE2t50lmVyBYihTgVRHcaecmeCk31LeOUleVK/R/aeWVHVZDi26zAH
 	assert.Equal(t, cmd, diffCmd)
 	stdout, err := io.ReadAll(out.Stdout)
 	assert.Nil(t, err)
-	assert.Contains(t, string(stdout), "-version = \"2.1.0\"")
-	assert.Contains(t, string(stdout), "+version = \"2.1.2\"")
+	assert.Equal(t, `+ customizations.user bart
+~ package tcpdump 2.4.* -> 2.*
+~ version 2.1.0 -> 2.1.2
+`, string(stdout))
 	stderr, err := io.ReadAll(out.Stderr)
 	assert.Nil(t, err)
 	assert.Equal(t, "", string(stderr))
 	assert.Equal(t, "GET", mc.Req.Method)
 }
 
+// diffServerTOML is the blueprint returned for any commit by diffServer
+const diffServerTOML = `name = "simple"
+description = "testing blueprints"
+version = "0.1.0"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[packages]]
+name = "vim-enhanced"
+version = "*"
+`
+
+func diffServer(request *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Request:    request,
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader([]byte(diffServerTOML))),
+	}, nil
+}
+
+// writeDiffFile writes a blueprint to a temporary file and returns its path
+func writeDiffFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.Nil(t, os.WriteFile(path, []byte(data), 0600))
+	return path
+}
+
+func TestCmdBlueprintsDiffLocalFile(t *testing.T) {
+	// Test the "blueprints diff" command with a local file, reordering the packages is not a change
+	mc := root.SetupCmdTest(diffServer)
+	path := writeDiffFile(t, "simple.toml", `name = "simple"
+description = "testing blueprints"
+version = "0.2.0"
+
+[[packages]]
+name = "vim-enhanced"
+version = "*"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[groups]]
+name = "core"
+
+[customizations]
+hostname = "simple-server"
+`)
+
+	cmd, out, err := root.ExecuteTest("blueprints", "diff", "simple", "fda3a8f9e589d1c423748b0408e5b71d9b769164", path)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	assert.Equal(t, cmd, diffCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, `+ customizations.hostname simple-server
++ group core
+~ version 0.1.0 -> 0.2.0
+`, string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(stderr))
+	assert.Equal(t, "/api/v1/blueprints/change/simple/fda3a8f9e589d1c423748b0408e5b71d9b769164", mc.Req.URL.Path)
+
+	// Both sides can be local files, the server is not used
+	mc.Req = http.Request{}
+	_, out, err = root.ExecuteTest("blueprints", "diff", "simple", path, path)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err = io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(stdout))
+	assert.Equal(t, "", mc.Req.Method)
+}
+
+func TestCmdBlueprintsDiffOutputJSON(t *testing.T) {
+	// Test the "blueprints diff" command with --output json
+	root.SetupCmdTest(diffServer)
+	path := writeDiffFile(t, "simple.toml", `name = "simple"
+description = "testing blueprints"
+version = "0.1.0"
+
+[[packages]]
+name = "tmux"
+version = "3.5*"
+
+[[packages]]
+name = "strace"
+`)
+
+	_, out, err := root.ExecuteTest("blueprints", "diff", "--output", "json", "simple", "fda3a8f9e589d1c423748b0408e5b71d9b769164", path)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	var changes []common.BlueprintChange
+	require.Nil(t, json.Unmarshal(stdout, &changes))
+	assert.Equal(t, []common.BlueprintChange{
+		{Kind: "package-added", Path: "packages", Name: "strace"},
+		{Kind: "package-changed", Path: "packages", Name: "tmux", Old: "*", New: "3.5*"},
+		{Kind: "package-removed", Path: "packages", Name: "vim-enhanced", Old: "*"},
+	}, changes)
+
+	// No changes is an empty list
+	_, out, err = root.ExecuteTest("blueprints", "diff", "--output", "json", "simple", path, path)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err = io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "[]\n", string(stdout))
+}
+
+func TestCmdBlueprintsDiffFileErrors(t *testing.T) {
+	// Test the "blueprints diff" command with local files that cannot be used
+	root.SetupCmdTest(diffServer)
+	bad := writeDiffFile(t, "bad.toml", "name = \"simple\"\npackages = \"tmux\"\n")
+
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"simple", "/missing/simple.toml", "WORKSPACE"}, "ERROR: reading /missing/simple.toml - open /missing/simple.toml: no such file or directory\n"},
+		{[]string{"simple", "WORKSPACE", bad}, "ERROR: FROM-COMMIT cannot be WORKSPACE\n"},
+		{[]string{"simple", "NEWEST", "WORKSPACE", "--", "-c", "--minimal"}, "ERROR: blueprints diff no longer runs the diff utility, arguments after -- are not supported: -c --minimal\n"},
+		{[]string{"--json", "simple", bad, bad}, "ERROR: --json only prints the server's responses, use --output json to compare two local files\n"},
+	} {
+		_, out, err := root.ExecuteTest(append([]string{"blueprints", "diff"}, tc.args...)...)
+		require.NotNil(t, out)
+		assert.NotNil(t, err, tc.args)
+		stderr, rerr := io.ReadAll(out.Stderr)
+		assert.Nil(t, rerr)
+		assert.Equal(t, tc.err, string(stderr), tc.args)
+		out.Close()
+	}
+
+	_, out, err := root.ExecuteTest("blueprints", "diff", "simple", bad, "WORKSPACE")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: "+bad+": ")
+}
+
 func TestCmdBlueprintsDiffUnknownBlueprint(t *testing.T) {
 	// Test the "blueprints diff" command with an unknown blueprint
 	json := `{
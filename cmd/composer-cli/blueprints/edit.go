@@ -0,0 +1,330 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/internal/common"
+)
+
+var (
+	editCmd = &cobra.Command{
+		Use:   "edit ...",
+		Short: "Change a blueprint on the server",
+		Long: `Change a blueprint on the server without saving and pushing it
+
+The blueprint is changed in place, keeping its comments and the order of its
+fields, and pushed back to the server as a new commit. If the version is not
+changed by bump-version the server increments the .z value.
+
+Some changes cannot be made in place, eg. adding a package when the packages
+are an inline array. These are an error unless --force is used, which rewrites
+the whole blueprint without its comments.`,
+	}
+	editAddPackageCmd = &cobra.Command{
+		Use:   "add-package BLUEPRINT PACKAGE[@VERSION]...",
+		Short: "Add packages to the blueprint",
+		Long:  "Add packages to the blueprint, or change the version of packages that are already in it",
+		Example: `  composer-cli blueprints edit add-package tmux-image tmux
+  composer-cli blueprints edit add-package tmux-image tmux@3.5a vim-enhanced@9.*`,
+		RunE: editAddPackage,
+		Args: cobra.MinimumNArgs(2),
+	}
+	editRemovePackageCmd = &cobra.Command{
+		Use:     "remove-package BLUEPRINT PACKAGE...",
+		Short:   "Remove packages from the blueprint",
+		Example: "  composer-cli blueprints edit remove-package tmux-image strace",
+		RunE:    editRemovePackage,
+		Args:    cobra.MinimumNArgs(2),
+	}
+	editAddGroupCmd = &cobra.Command{
+		Use:     "add-group BLUEPRINT GROUP...",
+		Short:   "Add package groups to the blueprint",
+		Example: "  composer-cli blueprints edit add-group tmux-image development-tools",
+		RunE:    editAddGroup,
+		Args:    cobra.MinimumNArgs(2),
+	}
+	editAddUserCmd = &cobra.Command{
+		Use:   "add-user BLUEPRINT USER",
+		Short: "Add a user account to the blueprint",
+		Example: `  composer-cli blueprints edit add-user tmux-image admin --groups wheel --key "$(cat ~/.ssh/id_ed25519.pub)"
+  composer-cli blueprints edit add-user tmux-image builder --uid 1200 --shell /bin/zsh`,
+		RunE: editAddUser,
+		Args: cobra.ExactArgs(2),
+	}
+	editSetHostnameCmd = &cobra.Command{
+		Use:     "set-hostname BLUEPRINT HOSTNAME",
+		Short:   "Set the hostname of the image",
+		Example: "  composer-cli blueprints edit set-hostname tmux-image tmux-server",
+		RunE:    editSetHostname,
+		Args:    cobra.ExactArgs(2),
+	}
+	editEnableServiceCmd = &cobra.Command{
+		Use:     "enable-service BLUEPRINT SERVICE...",
+		Short:   "Enable systemd services in the image",
+		Long:    "Enable systemd services in the image, removing them from the disabled and masked services",
+		Example: "  composer-cli blueprints edit enable-service tmux-image sshd cockpit.socket",
+		RunE:    editEnableService,
+		Args:    cobra.MinimumNArgs(2),
+	}
+	editAddFileCmd = &cobra.Command{
+		Use:   "add-file BLUEPRINT PATH",
+		Short: "Add a file to the image",
+		Long:  "Add a file to the image, using --data or the contents of a local file with --from",
+		Example: `  composer-cli blueprints edit add-file tmux-image /etc/motd --data "Welcome to tmux"
+  composer-cli blueprints edit add-file tmux-image /etc/tmux.conf --from ./tmux.conf --mode 0644`,
+		RunE: editAddFile,
+		Args: cobra.ExactArgs(2),
+	}
+	editBumpVersionCmd = &cobra.Command{
+		Use:     "bump-version BLUEPRINT major|minor|patch",
+		Short:   "Increment the version of the blueprint",
+		Example: "  composer-cli blueprints edit bump-version tmux-image minor",
+		RunE:    editBumpVersion,
+		Args:    cobra.ExactArgs(2),
+		// Only the level after the blueprint name can be completed
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return bumpLevels, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	// bumpLevels are the parts of the version that bump-version can increment
+	bumpLevels = []string{"major", "minor", "patch"}
+
+	editWorkspace bool
+	editDryRun    bool
+	editForce     bool
+
+	userPassword    string
+	userKey         string
+	userDescription string
+	userHome        string
+	userShell       string
+	userGroups      []string
+	userUID         int
+	userGID         int
+
+	fileData  string
+	fileFrom  string
+	fileMode  string
+	fileUser  string
+	fileGroup string
+)
+
+func init() {
+	editCmd.PersistentFlags().BoolVarP(&editWorkspace, "workspace", "", false, "Push the changed blueprint to the workspace instead of making a new commit")
+	editCmd.PersistentFlags().BoolVarP(&editDryRun, "dry-run", "", false, "Show the changed blueprint without pushing it")
+	editCmd.PersistentFlags().BoolVarP(&editForce, "force", "", false, "Rewrite the whole blueprint, losing its comments, when a change cannot be made in place")
+
+	editAddUserCmd.Flags().StringVarP(&userPassword, "password", "", "", "Password, plain text or crypted")
+	editAddUserCmd.Flags().StringVarP(&userKey, "key", "", "", "ssh public key")
+	editAddUserCmd.Flags().StringVarP(&userDescription, "description", "", "", "Description of the account")
+	editAddUserCmd.Flags().StringVarP(&userHome, "home", "", "", "Home directory")
+	editAddUserCmd.Flags().StringVarP(&userShell, "shell", "", "", "Login shell")
+	editAddUserCmd.Flags().StringSliceVarP(&userGroups, "groups", "", nil, "Comma separated list of groups")
+	editAddUserCmd.Flags().IntVarP(&userUID, "uid", "", 0, "User id")
+	editAddUserCmd.Flags().IntVarP(&userGID, "gid", "", 0, "Group id")
+
+	editAddFileCmd.Flags().StringVarP(&fileData, "data", "", "", "Contents of the file")
+	editAddFileCmd.Flags().StringVarP(&fileFrom, "from", "", "", "Local file to read the contents from")
+	editAddFileCmd.Flags().StringVarP(&fileMode, "mode", "", "", "Octal file mode, eg. 0644")
+	editAddFileCmd.Flags().StringVarP(&fileUser, "user", "", "", "User name or id that owns the file")
+	editAddFileCmd.Flags().StringVarP(&fileGroup, "group", "", "", "Group name or id that owns the file")
+
+	editCmd.AddCommand(editAddPackageCmd)
+	editCmd.AddCommand(editRemovePackageCmd)
+	editCmd.AddCommand(editAddGroupCmd)
+	editCmd.AddCommand(editAddUserCmd)
+	editCmd.AddCommand(editSetHostnameCmd)
+	editCmd.AddCommand(editEnableServiceCmd)
+	editCmd.AddCommand(editAddFileCmd)
+	editCmd.AddCommand(editBumpVersionCmd)
+	blueprintsCmd.AddCommand(editCmd)
+}
+
+// editBlueprint gets the blueprint from the server, changes it, and pushes it back
+func editBlueprint(cmd *cobra.Command, name string, change func(e *common.BlueprintEditor) error) error {
+	blueprints, resp, err := root.Client.GetBlueprintsTOML([]string{name})
+	if err != nil {
+		return root.ExecutionError(cmd, "Edit Error: %s", err)
+	}
+	if resp != nil && !resp.Status {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	if len(blueprints) == 0 {
+		return root.ExecutionError(cmd, "Edit Error: blueprint %s not found", name)
+	}
+
+	e, err := common.NewBlueprintEditor([]byte(blueprints[0]))
+	if err != nil {
+		return root.ExecutionError(cmd, "Edit Error: %s: %s", name, err)
+	}
+	e.Rewrite = editForce
+	if err := change(e); errors.Is(err, common.ErrBlueprintRewrite) {
+		return root.ExecutionError(cmd, "Edit Error: %s: %s, use --force to rewrite it", name, err)
+	} else if err != nil {
+		return root.ExecutionError(cmd, "Edit Error: %s: %s", name, err)
+	}
+	if e.Rewritten() {
+		fmt.Fprintf(os.Stderr, "Warning: %s was rewritten, its comments and formatting were not kept\n", name)
+	}
+
+	if editDryRun {
+		fmt.Print(string(e.Bytes()))
+		return nil
+	}
+	if editWorkspace {
+		resp, err = root.Client.PushBlueprintWorkspaceTOML(string(e.Bytes()))
+	} else {
+		resp, err = root.Client.PushBlueprintTOML(string(e.Bytes()))
+	}
+	if err != nil {
+		return root.ExecutionError(cmd, "Push TOML: %s", err)
+	}
+	if resp != nil && !resp.Status {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	return nil
+}
+
+func editAddPackage(cmd *cobra.Command, args []string) error {
+	return editBlueprint(cmd, args[0], func(e *common.BlueprintEditor) error {
+		for _, p := range args[1:] {
+			name, version, _ := strings.Cut(p, "@")
+			if err := e.AddPackage(name, version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func editRemovePackage(cmd *cobra.Command, args []string) error {
+	return editBlueprint(cmd, args[0], func(e *common.BlueprintEditor) error {
+		for _, name := range args[1:] {
+			if err := e.RemovePackage(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func editAddGroup(cmd *cobra.Command, args []string) error {
+	return editBlueprint(cmd, args[0], func(e *common.BlueprintEditor) error {
+		for _, name := range args[1:] {
+			if err := e.AddGroup(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// stringFlag returns a pointer to the value if the flag was used, or nil
+func stringFlag(cmd *cobra.Command, name, value string) *string {
+	if !cmd.Flags().Changed(name) {
+		return nil
+	}
+	return &value
+}
+
+// intFlag returns a pointer to the value if the flag was used, or nil
+func intFlag(cmd *cobra.Command, name string, value int) *int {
+	if !cmd.Flags().Changed(name) {
+		return nil
+	}
+	return &value
+}
+
+func editAddUser(cmd *cobra.Command, args []string) error {
+	user := common.UserCustomization{
+		Name:        args[1],
+		Description: stringFlag(cmd, "description", userDescription),
+		Password:    stringFlag(cmd, "password", userPassword),
+		Key:         stringFlag(cmd, "key", userKey),
+		Home:        stringFlag(cmd, "home", userHome),
+		Shell:       stringFlag(cmd, "shell", userShell),
+		Groups:      userGroups,
+		UID:         intFlag(cmd, "uid", userUID),
+		GID:         intFlag(cmd, "gid", userGID),
+	}
+	return editBlueprint(cmd, args[0], func(e *common.BlueprintEditor) error {
+		return e.AddUser(user)
+	})
+}
+
+func editSetHostname(cmd *cobra.Command, args []string) error {
+	return editBlueprint(cmd, args[0], func(e *common.BlueprintEditor) error {
+		return e.SetHostname(args[1])
+	})
+}
+
+func editEnableService(cmd *cobra.Command, args []string) error {
+	return editBlueprint(cmd, args[0], func(e *common.BlueprintEditor) error {
+		for _, name := range args[1:] {
+			if err := e.EnableService(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ownerValue returns a numeric user or group id as an int, or the name as a string
+func ownerValue(owner string) interface{} {
+	if len(owner) == 0 {
+		return nil
+	}
+	if id, err := strconv.Atoi(owner); err == nil {
+		return id
+	}
+	return owner
+}
+
+func editAddFile(cmd *cobra.Command, args []string) error {
+	if len(fileData) > 0 && len(fileFrom) > 0 {
+		return root.ExecutionError(cmd, "Use either --data or --from, not both")
+	}
+	data := fileData
+	if len(fileFrom) > 0 {
+		contents, err := os.ReadFile(fileFrom)
+		if err != nil {
+			return root.ExecutionError(cmd, "reading %s - %s", fileFrom, err)
+		}
+		data = string(contents)
+	}
+	file := common.FileCustomization{
+		Path:  args[1],
+		User:  ownerValue(fileUser),
+		Group: ownerValue(fileGroup),
+		Mode:  fileMode,
+		Data:  data,
+	}
+	return editBlueprint(cmd, args[0], func(e *common.BlueprintEditor) error {
+		return e.AddFile(file)
+	})
+}
+
+func editBumpVersion(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(bumpLevels, args[1]) {
+		return root.ExecutionError(cmd, "unknown version level %q, it should be major, minor, or patch", args[1])
+	}
+	return editBlueprint(cmd, args[0], func(e *common.BlueprintEditor) error {
+		return e.BumpVersion(args[1])
+	})
+}
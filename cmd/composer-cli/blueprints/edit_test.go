@@ -0,0 +1,310 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+// resetEditFlags resets the edit flags to their defaults between tests
+func resetEditFlags() {
+	editWorkspace = false
+	editDryRun = false
+	editForce = false
+	userPassword = ""
+	userKey = ""
+	userDescription = ""
+	userHome = ""
+	userShell = ""
+	userGroups = nil
+	userUID = 0
+	userGID = 0
+	fileData = ""
+	fileFrom = ""
+	fileMode = ""
+	fileUser = ""
+	fileGroup = ""
+	for _, c := range editCmd.Commands() {
+		c.Flags().VisitAll(func(f *pflag.Flag) {
+			f.Changed = false
+		})
+	}
+	editCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		f.Changed = false
+	})
+}
+
+const editServerTOML = `name = "tmux-image"
+description = "tmux image"
+version = "0.1.0"
+modules = []
+groups = []
+
+[[packages]]
+name = "tmux"
+version = "*"
+`
+
+// editServer returns the blueprint for GET requests and saves the body of the push
+// It returns editServerTOML unless toml is set.
+type editServer struct {
+	toml   string
+	pushed []byte
+	route  string
+}
+
+func (s *editServer) mock(request *http.Request) (*http.Response, error) {
+	if request.Method == "GET" {
+		if request.URL.Path != "/api/v1/blueprints/info/tmux-image" {
+			json := `{"status": false, "errors": [{"id": "UnknownBlueprint", "msg": "unknown-image: "}]}`
+			return &http.Response{
+				Request:    request,
+				StatusCode: 400,
+				Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+			}, nil
+		}
+		body := editServerTOML
+		if len(s.toml) > 0 {
+			body = s.toml
+		}
+		return &http.Response{
+			Request:    request,
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+
+	s.route = request.URL.Path
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	s.pushed = body
+	json := `{"status": true}`
+	return &http.Response{
+		Request:    request,
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+	}, nil
+}
+
+func TestCmdBlueprintsEditAddPackage(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetEditFlags()
+
+	cmd, out, err := root.ExecuteTest("blueprints", "edit", "add-package", "tmux-image", "vim-enhanced@9.*", "strace")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	assert.Equal(t, editAddPackageCmd, cmd)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(stderr))
+	assert.Equal(t, "/api/v1/blueprints/new", s.route)
+	assert.Equal(t, editServerTOML+`
+[[packages]]
+name = "vim-enhanced"
+version = "9.*"
+
+[[packages]]
+name = "strace"
+`, string(s.pushed))
+}
+
+func TestCmdBlueprintsEditWorkspace(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetEditFlags()
+
+	_, out, err := root.ExecuteTest("blueprints", "edit", "--workspace", "remove-package", "tmux-image", "tmux")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	assert.Equal(t, "/api/v1/blueprints/workspace", s.route)
+	assert.Equal(t, `name = "tmux-image"
+description = "tmux image"
+version = "0.1.0"
+modules = []
+groups = []
+`, string(s.pushed))
+}
+
+func TestCmdBlueprintsEditDryRun(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetEditFlags()
+
+	_, out, err := root.ExecuteTest("blueprints", "edit", "bump-version", "tmux-image", "minor", "--dry-run")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "version = \"0.2.0\"\n")
+	assert.Nil(t, s.pushed)
+}
+
+func TestCmdBlueprintsEditCustomizations(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetEditFlags()
+
+	_, out, err := root.ExecuteTest("blueprints", "edit", "add-user", "tmux-image", "admin", "--groups", "wheel,users", "--uid", "1200", "--key", "ssh-ed25519 AAAA")
+	require.NotNil(t, out)
+	out.Close()
+	require.Nil(t, err)
+	assert.Contains(t, string(s.pushed), `
+[[customizations.user]]
+name = "admin"
+key = "ssh-ed25519 AAAA"
+groups = ["wheel", "users"]
+uid = 1200
+`)
+
+	resetEditFlags()
+	_, out, err = root.ExecuteTest("blueprints", "edit", "set-hostname", "tmux-image", "tmux-server")
+	require.NotNil(t, out)
+	out.Close()
+	require.Nil(t, err)
+	assert.Contains(t, string(s.pushed), "\n[customizations]\nhostname = \"tmux-server\"\n")
+
+	resetEditFlags()
+	_, out, err = root.ExecuteTest("blueprints", "edit", "enable-service", "tmux-image", "sshd", "cockpit.socket")
+	require.NotNil(t, out)
+	out.Close()
+	require.Nil(t, err)
+	assert.Contains(t, string(s.pushed), "\n[customizations.services]\nenabled = [\"sshd\", \"cockpit.socket\"]\n")
+
+	resetEditFlags()
+	_, out, err = root.ExecuteTest("blueprints", "edit", "add-group", "tmux-image", "core")
+	require.NotNil(t, out)
+	out.Close()
+	require.Nil(t, err)
+	assert.NotContains(t, string(s.pushed), "groups = []")
+	assert.Contains(t, string(s.pushed), "\n[[groups]]\nname = \"core\"\n")
+}
+
+func TestCmdBlueprintsEditAddFile(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetEditFlags()
+
+	tmpdir := t.TempDir()
+	conf := filepath.Join(tmpdir, "tmux.conf")
+	require.Nil(t, os.WriteFile(conf, []byte("set -g mouse on\n"), 0600))
+
+	_, out, err := root.ExecuteTest("blueprints", "edit", "add-file", "tmux-image", "/etc/tmux.conf", "--from", conf, "--mode", "0644", "--user", "0")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	assert.Contains(t, string(s.pushed), `
+[[customizations.files]]
+path = "/etc/tmux.conf"
+user = 0
+mode = "0644"
+data = "set -g mouse on\n"
+`)
+}
+
+const editInlineTOML = `# Packages are inline
+name = "tmux-image"
+packages = [{name = "tmux"}]
+`
+
+func TestCmdBlueprintsEditRewrite(t *testing.T) {
+	// A change that cannot be made in place is an error without --force
+	s := editServer{toml: editInlineTOML}
+	root.SetupCmdTest(s.mock)
+	resetEditFlags()
+
+	_, out, err := root.ExecuteTest("blueprints", "edit", "add-package", "tmux-image", "vim")
+	require.NotNil(t, out)
+	assert.NotNil(t, err)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: Edit Error: tmux-image: the change cannot be made without rewriting the blueprint")
+	assert.Contains(t, string(stderr), "packages is an inline array, use --force to rewrite it\n")
+	assert.Nil(t, s.pushed)
+	out.Close()
+
+	resetEditFlags()
+	_, out, err = root.ExecuteTest("blueprints", "edit", "--force", "add-package", "tmux-image", "vim")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stderr, err = io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "Warning: tmux-image was rewritten, its comments and formatting were not kept\n", string(stderr))
+	assert.Equal(t, `name = "tmux-image"
+
+[[packages]]
+  name = "tmux"
+
+[[packages]]
+  name = "vim"
+`, string(s.pushed))
+}
+
+func TestCmdBlueprintsEditErrors(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"add-package", "tmux-image", "tmux@*"}, "ERROR: Edit Error: tmux-image: package tmux is already in the blueprint\n"},
+		{[]string{"remove-package", "tmux-image", "vim"}, "ERROR: Edit Error: tmux-image: package vim is not in the blueprint\n"},
+		{[]string{"bump-version", "tmux-image", "huge"}, "ERROR: unknown version level \"huge\", it should be major, minor, or patch\n"},
+		{[]string{"add-file", "tmux-image", "/etc/motd", "--data", "hello", "--from", "motd"}, "ERROR: Use either --data or --from, not both\n"},
+		{[]string{"set-hostname", "unknown-image", "server"}, "ERROR: UnknownBlueprint: unknown-image: \n"},
+	} {
+		resetEditFlags()
+		_, out, err := root.ExecuteTest(append([]string{"blueprints", "edit"}, tc.args...)...)
+		require.NotNil(t, out)
+		assert.NotNil(t, err, tc.args)
+		stderr, rerr := io.ReadAll(out.Stderr)
+		assert.Nil(t, rerr)
+		assert.Equal(t, tc.err, string(stderr), tc.args)
+		out.Close()
+	}
+	assert.Nil(t, s.pushed)
+}
+
+func TestCmdBlueprintsEditBumpVersionComplete(t *testing.T) {
+	// Only the second argument is completed with the version levels
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetEditFlags()
+
+	_, out, err := root.ExecuteTest("__complete", "blueprints", "edit", "bump-version", "tmux-image", "")
+	require.NotNil(t, out)
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "major\nminor\npatch\n:4\n", string(stdout))
+	out.Close()
+
+	_, out, err = root.ExecuteTest("__complete", "blueprints", "edit", "bump-version", "")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err = io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, ":4\n", string(stdout))
+	assert.Nil(t, s.pushed)
+}
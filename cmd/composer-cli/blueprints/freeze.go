@@ -5,7 +5,6 @@
 package blueprints
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -17,6 +16,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 var (
@@ -54,20 +54,6 @@ func init() {
 	freezeCmd.AddCommand(freezeSaveCmd)
 }
 
-// blueprintParts is Used to decode the parts of the blueprint to display
-type blueprintParts struct {
-	Name    string
-	Version string
-	Modules []struct {
-		Name    string
-		Version string
-	}
-	Packages []struct {
-		Name    string
-		Version string
-	}
-}
-
 func freeze(cmd *cobra.Command, args []string) (rcErr error) {
 	names := root.GetCommaArgs(args)
 	bps, errors, err := root.Client.GetFrozenBlueprintsJSON(names)
@@ -79,16 +65,9 @@ func freeze(cmd *cobra.Command, args []string) (rcErr error) {
 	}
 
 	for _, bp := range bps {
-		// Encode it using toml
-		data := new(bytes.Buffer)
-		if err := toml.NewEncoder(data).Encode(bp); err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: converting blueprint: %s\n", err)
-			rcErr = root.ExecutionError(cmd, "")
-			continue
-		}
-		// Decode the parts we care about into blueprintParts
-		var parts blueprintParts
-		if _, err := toml.Decode(data.String(), &parts); err != nil {
+		// Convert it to the Blueprint type to get the parts to display
+		parts, err := weldr.BlueprintFromJSON(bp)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: decoding blueprint: %s\n", err)
 			rcErr = root.ExecutionError(cmd, "")
 			continue
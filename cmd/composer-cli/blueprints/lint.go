@@ -0,0 +1,89 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/internal/common"
+)
+
+var (
+	lintCmd = &cobra.Command{
+		Use:   "lint FILE...",
+		Short: "Check TOML blueprint files for problems",
+		Long: `Check TOML blueprint files for problems without using the server
+
+Each file is checked against the blueprint schema, reporting syntax errors,
+misspelled or unknown keys, values with the wrong type, and values that the
+server would reject. Problems are printed as FILE:LINE: SEVERITY: MESSAGE.
+It exits with an error if any of the files have errors, warnings are printed
+but do not cause it to fail.`,
+		Example: `  composer-cli blueprints lint tmux-image.toml
+  composer-cli blueprints lint --output json *.toml`,
+		RunE: lint,
+		Args: cobra.MinimumNArgs(1),
+	}
+)
+
+func init() {
+	root.EnableOutput(lintCmd)
+	blueprintsCmd.AddCommand(lintCmd)
+}
+
+// lintRecord is a problem found in a file, used for the --output formats
+type lintRecord struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Key      string `json:"key,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func lint(cmd *cobra.Command, args []string) error {
+	records := []lintRecord{}
+	var failed int
+	for _, filename := range args {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: reading %s: %s\n", filename, err)
+			failed++
+			continue
+		}
+		problems := common.LintBlueprintTOML(data)
+		if common.LintErrors(problems) {
+			failed++
+		}
+		for _, p := range problems {
+			records = append(records, lintRecord{filename, p.Line, p.Key, p.Severity, p.Message})
+			if root.StructuredOutput() {
+				continue
+			}
+			if p.Line > 0 {
+				fmt.Printf("%s:%s\n", filename, p)
+			} else {
+				fmt.Printf("%s: %s\n", filename, p)
+			}
+		}
+	}
+
+	if root.StructuredOutput() {
+		var rows [][]string
+		for _, r := range records {
+			rows = append(rows, []string{r.File, fmt.Sprintf("%d", r.Line), r.Key, r.Severity, r.Message})
+		}
+		if err := root.PrintOutput(records, []string{"File", "Line", "Key", "Severity", "Message"}, rows); err != nil {
+			return root.ExecutionError(cmd, "%s", err)
+		}
+	}
+	if failed > 0 {
+		return root.ExecutionError(cmd, "%d of %d blueprints have errors", failed, len(args))
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+// writeLintFiles writes the blueprints to a temporary directory and returns their paths
+func writeLintFiles(t *testing.T, blueprints ...string) []string {
+	dir := t.TempDir()
+	var paths []string
+	for i, bp := range blueprints {
+		path := filepath.Join(dir, fmt.Sprintf("bp-%d.toml", i))
+		require.Nil(t, os.WriteFile(path, []byte(bp), 0600))
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// noServer fails the test if the command makes any requests
+func noServer(t *testing.T) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		t.Errorf("unexpected request: %s %s", request.Method, request.URL.Path)
+		return nil, fmt.Errorf("unexpected request")
+	}
+}
+
+func TestCmdBlueprintsLint(t *testing.T) {
+	// Test the "blueprints lint" command
+	root.SetupCmdTest(noServer(t))
+	files := writeLintFiles(t, `name = "good"
+version = "0.0.1"
+
+[[packages]]
+name = "tmux"
+`, `name = "warning"
+packages = [{name = "tmux"}, {name = "tmux"}]
+`)
+
+	cmd, out, err := root.ExecuteTest(append([]string{"blueprints", "lint"}, files...)...)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, lintCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, files[1]+":2: warning: package tmux is listed more than once\n", string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(stderr))
+}
+
+func TestCmdBlueprintsLintErrors(t *testing.T) {
+	// Test the "blueprints lint" command with problems in the files
+	root.SetupCmdTest(noServer(t))
+	files := writeLintFiles(t, `name = "bad"
+
+[[packges]]
+name = "tmux"
+`, `description = "no name"`, `name = "good"`)
+
+	_, out, err := root.ExecuteTest("blueprints", "lint", files[0], files[1], files[2], "/missing/bp.toml")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, files[0]+":3: error: unknown key packges, did you mean packages?\n"+
+		files[1]+": error: name is required\n", string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: reading /missing/bp.toml")
+	assert.Contains(t, string(stderr), "ERROR: 3 of 4 blueprints have errors\n")
+}
+
+func TestCmdBlueprintsLintJSON(t *testing.T) {
+	// Test the "blueprints lint" command with --output json
+	root.SetupCmdTest(noServer(t))
+	files := writeLintFiles(t, `name = "bad"
+
+[customizations]
+hostname = 42
+`)
+
+	_, out, err := root.ExecuteTest("blueprints", "lint", "--output", "json", files[0])
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	var records []lintRecord
+	require.Nil(t, json.Unmarshal(stdout, &records))
+	assert.Equal(t, []lintRecord{{
+		File:     files[0],
+		Line:     4,
+		Key:      "customizations.hostname",
+		Severity: "error",
+		Message:  "customizations.hostname should be a string, not an integer",
+	}}, records)
+}
@@ -5,7 +5,6 @@
 package blueprints
 
 import (
-	"fmt"
 	"sort"
 
 	"github.com/spf13/cobra"
@@ -24,6 +23,7 @@ var (
 )
 
 func init() {
+	root.EnableOutput(listCmd)
 	blueprintsCmd.AddCommand(listCmd)
 }
 
@@ -37,8 +37,8 @@ func list(cmd *cobra.Command, args []string) error {
 	}
 
 	sort.Strings(blueprints)
-	for i := range blueprints {
-		fmt.Println(blueprints[i])
+	if err := root.PrintList("Name", blueprints); err != nil {
+		return root.ExecutionError(cmd, "%s", err)
 	}
 
 	return nil
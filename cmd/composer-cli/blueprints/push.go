@@ -7,10 +7,12 @@ package blueprints
 import (
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/internal/common"
 )
 
 var (
@@ -20,18 +22,50 @@ var (
 		Long: `Push the TOML blueprint file to the server, overwriting the previous version.
   If the version string in the new blueprint matches the one on the server
   the .z value is incremented. If it does not match it will be used as-is.
+
+  With --bump the file is compared with the blueprint on the server. If nothing
+  has changed it is not pushed, otherwise the server's version is incremented
+  and written to the file. A version that was changed in the file is pushed
+  as-is when nothing else has changed. --bump auto selects the part of the version to
+  increment from the kind of change, eg. removing a package is a major change.
+  The rules can be changed with --bump-rule KIND=LEVEL, the kinds are:
+  package-added, package-removed, package-changed, module-added, module-removed,
+  module-changed, group-added, group-removed, enabled-module-added,
+  enabled-module-removed, enabled-module-changed, container-added,
+  container-removed, container-changed, customization-added,
+  customization-removed, customization-changed, field-added, field-removed,
+  and field-changed. The levels are none, patch, minor, and major.
 `,
-		Example: "  composer-cli blueprints push tmux-image.toml",
-		RunE:    push,
-		Args:    cobra.MinimumNArgs(1),
+		Example: `  composer-cli blueprints push tmux-image.toml
+  composer-cli blueprints push --bump auto tmux-image.toml
+  composer-cli blueprints push --bump auto --bump-rule package-removed=minor tmux-image.toml`,
+		RunE: push,
+		Args: cobra.MinimumNArgs(1),
 	}
+
+	pushBump      string
+	pushBumpRules []string
 )
 
 func init() {
+	pushCmd.Flags().StringVarP(&pushBump, "bump", "", "", "Increment the version if the blueprint changed: auto, patch, minor, or major")
+	pushCmd.Flags().StringArrayVarP(&pushBumpRules, "bump-rule", "", nil, "Version level for a kind of change with --bump auto, eg. package-removed=minor")
 	blueprintsCmd.AddCommand(pushCmd)
 }
 
 func push(cmd *cobra.Command, args []string) (rcErr error) {
+	var rules map[string]string
+	if len(pushBump) > 0 {
+		if !slices.Contains([]string{"auto", "patch", "minor", "major"}, pushBump) {
+			return root.ExecutionError(cmd, "--bump must be auto, patch, minor, or major")
+		}
+		var err error
+		rules, err = common.ParseBumpRules(common.DefaultBumpRules, append(root.BumpRules(), pushBumpRules...))
+		if err != nil {
+			return root.ExecutionError(cmd, "%s", err)
+		}
+	}
+
 	files := root.GetCommaArgs(args)
 	for _, filename := range files {
 		data, err := os.ReadFile(filename)
@@ -39,6 +73,22 @@ func push(cmd *cobra.Command, args []string) (rcErr error) {
 			rcErr = root.ExecutionError(cmd, "Missing blueprint file: %s\n", filename)
 			continue
 		}
+		var bump *versionBump
+		if len(pushBump) > 0 {
+			bump, err = bumpVersion(data, rules)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %s: %s\n", filename, err)
+				rcErr = root.ExecutionError(cmd, "")
+				continue
+			}
+			if bump != nil && bump.level == "none" {
+				fmt.Printf("%s has not changed, skipping it\n", bump.name)
+				continue
+			}
+			if bump != nil {
+				data = bump.data
+			}
+		}
 		resp, err := root.Client.PushBlueprintTOML(string(data))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: Push TOML: %s\n", err)
@@ -47,9 +97,98 @@ func push(cmd *cobra.Command, args []string) (rcErr error) {
 		}
 		if resp != nil && !resp.Status {
 			rcErr = root.ExecutionErrors(cmd, resp.Errors)
+			continue
+		}
+		if bump != nil && bump.level == "file" {
+			fmt.Printf("%s: %s -> %s (version from the file)\n", bump.name, bump.from, bump.to)
+		} else if bump != nil {
+			if err := writeBlueprintFile(filename, data); err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: writing %s: %s\n", filename, err)
+				rcErr = root.ExecutionError(cmd, "")
+				continue
+			}
+			fmt.Printf("%s: %s -> %s (%s)\n", bump.name, bump.from, bump.to, bump.level)
 		}
 	}
 
 	// If there were any errors, even if other blueprints succeeded, it returns an error
 	return rcErr
 }
+
+// versionBump is the new version selected by --bump
+type versionBump struct {
+	name  string
+	from  string // Version on the server
+	to    string // New version
+	level string // Part of the version that was incremented, none if nothing changed, file if it was set in the file
+	data  []byte // Blueprint with the new version
+}
+
+// bumpVersion compares the blueprint with the one on the server and selects the new version
+// It returns nil if the blueprint is not on the server yet, it is pushed with its own version.
+func bumpVersion(data []byte, rules map[string]string) (*versionBump, error) {
+	e, err := common.NewBlueprintEditor(data)
+	if err != nil {
+		return nil, err
+	}
+	local := e.Blueprint()
+	if len(local.Name) == 0 {
+		return nil, fmt.Errorf("blueprint is missing the name")
+	}
+
+	blueprints, resp, err := root.Client.GetBlueprintsTOML([]string{local.Name})
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil && !resp.Status {
+		if resp.HasErrorID("UnknownBlueprint") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s", resp.String())
+	}
+	if len(blueprints) == 0 {
+		return nil, nil
+	}
+	server, err := common.ParseBlueprintTOML([]byte(blueprints[0]))
+	if err != nil {
+		return nil, fmt.Errorf("server blueprint: %s", err)
+	}
+
+	changes, err := common.DiffBlueprints(server, local)
+	if err != nil {
+		return nil, err
+	}
+	bump := versionBump{name: local.Name, from: server.Version, level: common.BumpLevel(changes, rules)}
+	if bump.level == "none" && len(local.Version) > 0 && local.Version != server.Version {
+		// The version was changed by hand, it is pushed as-is
+		bump.level = "file"
+		bump.to = local.Version
+		bump.data = data
+		return &bump, nil
+	}
+	if bump.level == "none" {
+		// Changes that the rules ignore do not need a push
+		return &bump, nil
+	}
+	if pushBump != "auto" {
+		bump.level = pushBump
+	}
+	bump.to, err = common.BumpVersion(server.Version, bump.level)
+	if err != nil {
+		return nil, fmt.Errorf("server blueprint: %s", err)
+	}
+	if err := e.SetVersion(bump.to); err != nil {
+		return nil, err
+	}
+	bump.data = e.Bytes()
+	return &bump, nil
+}
+
+// writeBlueprintFile replaces the file with the new data, keeping its permissions
+func writeBlueprintFile(filename string, data []byte) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, info.Mode().Perm())
+}
@@ -9,8 +9,11 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -206,3 +209,231 @@ version = "*"`))
 	assert.Equal(t, "POST", mc.Req.Method)
 	assert.Equal(t, "/api/v1/blueprints/new", mc.Req.URL.Path)
 }
+
+// resetPushFlags resets the push flags to their defaults
+func resetPushFlags() {
+	pushBump = ""
+	pushBumpRules = nil
+	pushCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Changed = false
+	})
+}
+
+// writeBumpFile writes a local copy of the editServer blueprint with the changes
+func writeBumpFile(t *testing.T, data string) string {
+	filename := filepath.Join(t.TempDir(), "tmux-image.toml")
+	require.Nil(t, os.WriteFile(filename, []byte(data), 0640))
+	return filename
+}
+
+func TestCmdBlueprintsPushBump(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetPushFlags()
+	defer resetPushFlags()
+
+	filename := writeBumpFile(t, `# Local copy of the tmux image
+name = "tmux-image"
+description = "tmux image"
+version = "0.1.0"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[packages]]
+name = "vim-enhanced"
+`)
+	_, out, err := root.ExecuteTest("blueprints", "push", "--bump", "auto", filename)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "tmux-image: 0.1.0 -> 0.2.0 (minor)\n", string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(stderr))
+
+	// The new version is pushed and written to the file
+	expected := `# Local copy of the tmux image
+name = "tmux-image"
+description = "tmux image"
+version = "0.2.0"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[packages]]
+name = "vim-enhanced"
+`
+	assert.Equal(t, "/api/v1/blueprints/new", s.route)
+	assert.Equal(t, expected, string(s.pushed))
+	data, err := os.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, expected, string(data))
+	info, err := os.Stat(filename)
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
+
+func TestCmdBlueprintsPushBumpNested(t *testing.T) {
+	// A change to only an unknown field in a package is still a change
+	s := editServer{toml: editServerTOML + "arch = \"x86_64\"\n"}
+	root.SetupCmdTest(s.mock)
+	resetPushFlags()
+	defer resetPushFlags()
+
+	local := `# Local copy of the tmux image
+name = "tmux-image"
+description = "tmux image"
+version = "0.1.0"
+modules = []
+groups = []
+
+# Build it for arm
+[[packages]]
+name = "tmux"
+version = "*"
+arch = "aarch64"
+`
+	filename := writeBumpFile(t, local)
+	_, out, err := root.ExecuteTest("blueprints", "push", "--bump", "auto", filename)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "tmux-image: 0.1.0 -> 0.1.1 (patch)\n", string(stdout))
+
+	// Only the version is changed, the comments and the unknown field are kept
+	expected := strings.Replace(local, "version = \"0.1.0\"", "version = \"0.1.1\"", 1)
+	assert.Equal(t, expected, string(s.pushed))
+	data, err := os.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, expected, string(data))
+}
+
+func TestCmdBlueprintsPushBumpRules(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetPushFlags()
+	defer resetPushFlags()
+
+	// Removing the package is a major change unless the rule is changed
+	filename := writeBumpFile(t, "name = \"tmux-image\"\ndescription = \"tmux image\"\nversion = \"0.1.0\"\n")
+	_, out, err := root.ExecuteTest("blueprints", "push", "--bump", "auto", "--bump-rule", "package-removed=patch", filename)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "tmux-image: 0.1.0 -> 0.1.1 (patch)\n", string(stdout))
+	assert.Contains(t, string(s.pushed), "version = \"0.1.1\"\n")
+}
+
+func TestCmdBlueprintsPushBumpLevel(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetPushFlags()
+	defer resetPushFlags()
+
+	filename := writeBumpFile(t, "name = \"tmux-image\"\ndescription = \"A new description\"\nversion = \"0.1.0\"\npackages = [{name = \"tmux\", version = \"*\"}]\n")
+	_, out, err := root.ExecuteTest("blueprints", "push", "--bump", "major", filename)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "tmux-image: 0.1.0 -> 1.0.0 (major)\n", string(stdout))
+	assert.Contains(t, string(s.pushed), "version = \"1.0.0\"\n")
+}
+
+func TestCmdBlueprintsPushBumpUnchanged(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetPushFlags()
+	defer resetPushFlags()
+
+	// Only the formatting is different
+	local := "name = \"tmux-image\"\nversion = \"0.1.0\"\ndescription = \"tmux image\"\npackages = [{name = \"tmux\", version = \"*\"}]\n"
+	filename := writeBumpFile(t, local)
+	_, out, err := root.ExecuteTest("blueprints", "push", "--bump", "auto", filename)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "tmux-image has not changed, skipping it\n", string(stdout))
+	assert.Nil(t, s.pushed)
+	data, err := os.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, local, string(data))
+}
+
+func TestCmdBlueprintsPushBumpFileVersion(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetPushFlags()
+	defer resetPushFlags()
+
+	// Only the version was changed by hand, it is pushed as-is
+	local := "# Release it\nname = \"tmux-image\"\nversion = \"1.0.0\"\ndescription = \"tmux image\"\npackages = [{name = \"tmux\", version = \"*\"}]\n"
+	filename := writeBumpFile(t, local)
+	_, out, err := root.ExecuteTest("blueprints", "push", "--bump", "auto", filename)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "tmux-image: 0.1.0 -> 1.0.0 (version from the file)\n", string(stdout))
+	assert.Equal(t, local, string(s.pushed))
+	data, err := os.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, local, string(data))
+}
+
+func TestCmdBlueprintsPushBumpNew(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	resetPushFlags()
+	defer resetPushFlags()
+
+	// A blueprint that is not on the server is pushed as-is
+	local := "name = \"new-image\"\nversion = \"0.0.1\"\n"
+	filename := writeBumpFile(t, local)
+	_, out, err := root.ExecuteTest("blueprints", "push", "--bump", "auto", filename)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(stdout))
+	assert.Equal(t, local, string(s.pushed))
+}
+
+func TestCmdBlueprintsPushBumpErrors(t *testing.T) {
+	var s editServer
+	root.SetupCmdTest(s.mock)
+	defer resetPushFlags()
+
+	filename := writeBumpFile(t, "name = \"tmux-image\"\n")
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--bump", "huge"}, "ERROR: --bump must be auto, patch, minor, or major\n"},
+		{[]string{"--bump", "auto", "--bump-rule", "package-renamed=major"}, "ERROR: unknown kind of change in bump rule: package-renamed\n"},
+	} {
+		resetPushFlags()
+		_, out, err := root.ExecuteTest(append(append([]string{"blueprints", "push"}, tc.args...), filename)...)
+		require.NotNil(t, out)
+		assert.NotNil(t, err, tc.args)
+		stderr, rerr := io.ReadAll(out.Stderr)
+		assert.Nil(t, rerr)
+		assert.Equal(t, tc.err, string(stderr), tc.args)
+		out.Close()
+	}
+	assert.Nil(t, s.pushed)
+}
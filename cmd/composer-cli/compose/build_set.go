@@ -0,0 +1,428 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cloud"
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	buildSetCmd = &cobra.Command{
+		Use:   "build-set FILE.toml",
+		Short: "Start the composes listed in a build set file",
+		Long: `Start all of the composes listed in a build set file, optionally wait for them
+to finish and download the images, and print a summary of the results
+
+The file lists the builds as [[build]] tables:
+
+  directory = "images"   # Where --download saves the images, default is .
+  wait = true            # Wait for the composes to finish
+  download = true        # Download the images, implies wait
+  timeout = "1h"         # Maximum time to wait
+  poll = "30s"           # Polling interval
+
+  [[build]]
+  name = "web"           # Name of the build, defaults to the blueprint name
+  blueprint = "web.toml" # Local blueprint file, or the name of a blueprint on the server
+  types = ["qcow2", "ami"]
+  size = 4096            # Size of the images in MiB
+  distro = "rhel-9.4"    # Distribution, architecture, and extra sources for a local blueprint
+  arch = "x86_64"
+  repos = ["custom.toml"]
+  upload = "aws.toml"    # Upload profile
+  image-name = "web-ami" # Image name for uploads of a blueprint on the server
+  ref = "rhel/9/x86_64/edge"  # OSTree ref, parent, and url
+  parent = ""
+  url = ""
+
+A local blueprint builds all of its types in one cloud API compose, a blueprint on the
+server starts one compose for each type. Paths in the file are relative to its directory.
+The images are downloaded to a directory for each build under the directory, images that
+are uploaded are not downloaded. The cloud API can only download the first image of a
+compose, the other types of a local blueprint are listed as not downloaded. Build names
+are used as directory names, they cannot include a path separator or '..'. The flags
+override the settings in the file.`,
+		Example: `  composer-cli compose build-set release.toml
+  composer-cli compose build-set --wait --timeout 2h release.toml
+  composer-cli compose build-set --download --dir /var/tmp/release/ release.toml`,
+		RunE: buildSet,
+		Args: cobra.ExactArgs(1),
+	}
+	buildSetWait        bool
+	buildSetDownload    bool
+	buildSetDir         string
+	buildSetTimeoutStr  string
+	buildSetPollStr     string
+	buildSetMaxRequests int
+)
+
+func init() {
+	buildSetCmd.Flags().BoolVarP(&buildSetWait, "wait", "", false, "Wait for the composes to finish")
+	buildSetCmd.Flags().BoolVarP(&buildSetDownload, "download", "", false, "Download the images when the composes have finished, implies --wait")
+	buildSetCmd.Flags().StringVarP(&buildSetDir, "dir", "", "", "Directory to download the images into")
+	buildSetCmd.Flags().StringVarP(&buildSetTimeoutStr, "timeout", "", "5m", "Maximum time to wait")
+	buildSetCmd.Flags().StringVarP(&buildSetPollStr, "poll", "", "10s", "Polling interval")
+	buildSetCmd.Flags().IntVarP(&buildSetMaxRequests, "max-requests", "", 4, "Maximum number of status requests to make at the same time")
+	composeCmd.AddCommand(buildSetCmd)
+}
+
+// buildSetFile is the TOML file listing the builds to start
+type buildSetFile struct {
+	Directory string          `toml:"directory"`
+	Wait      bool            `toml:"wait"`
+	Download  bool            `toml:"download"`
+	Timeout   string          `toml:"timeout"`
+	Poll      string          `toml:"poll"`
+	Builds    []buildSetEntry `toml:"build"`
+}
+
+// buildSetEntry is one of the builds in a build set file
+type buildSetEntry struct {
+	Name      string   `toml:"name"`
+	Blueprint string   `toml:"blueprint"`
+	Types     []string `toml:"types"`
+	Size      uint     `toml:"size"`
+	Distro    string   `toml:"distro"`
+	Arch      string   `toml:"arch"`
+	Repos     []string `toml:"repos"`
+	Upload    string   `toml:"upload"`
+	ImageName string   `toml:"image-name"`
+	Ref       string   `toml:"ref"`
+	Parent    string   `toml:"parent"`
+	URL       string   `toml:"url"`
+}
+
+// buildSetCompose is the state of one of the composes started for a build
+type buildSetCompose struct {
+	build  *buildSetEntry
+	types  []string
+	id     string
+	cloud  bool   // Started with the cloud API
+	status string // Status as reported by the API
+	image  string // Path of the downloaded image
+	err    string // Why it failed to start, finish, or download
+
+	notDownloaded []string // Types of a cloud API compose that were not downloaded
+}
+
+// readBuildSet reads and checks a build set file
+// Relative paths in the file are converted to paths relative to the file's directory,
+// except for blueprints that do not exist as a file, they are the name of a blueprint
+// on the server.
+func readBuildSet(path string) (buildSetFile, error) {
+	var set buildSetFile
+	md, err := toml.DecodeFile(path, &set)
+	if err != nil {
+		return set, fmt.Errorf("reading %s - %s", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return set, fmt.Errorf("%s has unknown settings: %v", path, undecoded)
+	}
+	if len(set.Builds) == 0 {
+		return set, fmt.Errorf("%s does not have any builds", path)
+	}
+
+	base := filepath.Dir(path)
+	relative := func(p string) string {
+		if len(p) == 0 || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(base, p)
+	}
+	if len(set.Directory) > 0 {
+		set.Directory = relative(set.Directory)
+	}
+
+	names := make(map[string]bool)
+	for i := range set.Builds {
+		b := &set.Builds[i]
+		if len(b.Blueprint) == 0 {
+			return set, fmt.Errorf("build %d is missing the blueprint", i+1)
+		}
+		if len(b.Name) == 0 {
+			b.Name = strings.TrimSuffix(filepath.Base(b.Blueprint), ".toml")
+		}
+		if strings.ContainsAny(b.Name, "/"+string(os.PathSeparator)) || strings.Contains(b.Name, "..") || b.Name == "." {
+			return set, fmt.Errorf("build name %s cannot include a path separator or '..'", b.Name)
+		}
+		if names[b.Name] {
+			return set, fmt.Errorf("build name %s is used more than once", b.Name)
+		}
+		names[b.Name] = true
+		if len(b.Types) == 0 {
+			return set, fmt.Errorf("build %s is missing the image types", b.Name)
+		}
+
+		if _, err := os.Stat(relative(b.Blueprint)); err == nil {
+			b.Blueprint = relative(b.Blueprint)
+		}
+		b.Upload = relative(b.Upload)
+		for j := range b.Repos {
+			b.Repos[j] = relative(b.Repos[j])
+		}
+	}
+	return set, nil
+}
+
+func buildSet(cmd *cobra.Command, args []string) error {
+	set, err := readBuildSet(args[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "%s", err)
+	}
+
+	// The flags override the file's settings
+	flags := cmd.Flags()
+	if flags.Changed("wait") {
+		set.Wait = buildSetWait
+	}
+	if flags.Changed("download") {
+		set.Download = buildSetDownload
+	}
+	if flags.Changed("dir") || len(set.Directory) == 0 {
+		set.Directory = buildSetDir
+	}
+	if flags.Changed("timeout") || len(set.Timeout) == 0 {
+		set.Timeout = buildSetTimeoutStr
+	}
+	if flags.Changed("poll") || len(set.Poll) == 0 {
+		set.Poll = buildSetPollStr
+	}
+	if len(set.Directory) == 0 {
+		set.Directory = "."
+	}
+	timeout, err := time.ParseDuration(set.Timeout)
+	if err != nil {
+		return root.ExecutionError(cmd, "timeout - %s", err)
+	}
+	interval, err := time.ParseDuration(set.Poll)
+	if err != nil {
+		return root.ExecutionError(cmd, "poll - %s", err)
+	}
+	if interval >= timeout {
+		return root.ExecutionError(cmd, "Cannot wait, check interval (%v) must be < timeout (%v)", interval, timeout)
+	}
+	if buildSetMaxRequests < 1 {
+		return root.ExecutionError(cmd, "--max-requests must be at least 1")
+	}
+
+	var composes []*buildSetCompose
+	for i := range set.Builds {
+		composes = append(composes, startBuild(&set.Builds[i])...)
+	}
+
+	if set.Wait || set.Download {
+		waitForBuilds(composes, timeout, interval)
+	}
+	if set.Download {
+		downloadBuilds(composes, set.Directory)
+	}
+
+	// Summary of all of the composes, with the reason for any problems
+	w := tabwriter.NewWriter(os.Stdout, 5, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Build\tTypes\tID\tStatus\tImage")
+	var problems int
+	for _, c := range composes {
+		result := c.image
+		if len(c.err) > 0 {
+			problems++
+			result = c.err
+		}
+		types := c.types
+		if len(c.notDownloaded) > 0 {
+			types = c.types[:1]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.build.Name, strings.Join(types, ","), c.id, c.status, result)
+		for _, t := range c.notDownloaded {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.build.Name, t, c.id, c.status, "not downloaded, the cloud API only downloads the first image")
+		}
+	}
+	w.Flush() //nolint:errcheck
+
+	if problems > 0 {
+		return root.ExecutionError(cmd, "%d of %d composes had problems", problems, len(composes))
+	}
+	return nil
+}
+
+// startBuild starts the composes for a build
+// A local blueprint starts one cloud API compose with all of the types, a blueprint on
+// the server starts one weldr API compose for each type. Composes that could not be
+// started are returned with the error and no id.
+func startBuild(b *buildSetEntry) []*buildSetCompose {
+	blueprint, isLocal, err := readLocalBlueprint(b.Blueprint)
+	if err != nil {
+		return []*buildSetCompose{{build: b, types: b.Types, err: err.Error()}}
+	}
+	if isLocal {
+		c := &buildSetCompose{build: b, types: b.Types, cloud: true}
+		c.id, err = startBuildCloud(b, blueprint)
+		if err != nil {
+			c.err = err.Error()
+		} else {
+			fmt.Printf("%s: Compose %s added to the queue\n", b.Name, c.id)
+		}
+		return []*buildSetCompose{c}
+	}
+
+	var composes []*buildSetCompose
+	for _, t := range b.Types {
+		c := &buildSetCompose{build: b, types: []string{t}}
+		c.id, err = startBuildWeldr(b, t)
+		if err != nil {
+			c.err = err.Error()
+		} else {
+			fmt.Printf("%s: Compose %s added to the queue\n", b.Name, c.id)
+		}
+		composes = append(composes, c)
+	}
+	return composes
+}
+
+// startBuildCloud starts a cloud API compose of a local blueprint with all of the build's types
+func startBuildCloud(b *buildSetEntry, blueprint interface{}) (string, error) {
+	upload := cloud.NewLocalImageRequest("", b.Size)
+	if len(b.Upload) > 0 {
+		var err error
+		upload, err = readUploadImage(b.Upload, b.ImageName, b.Size)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var images []cloud.ImageRequestV1
+	for _, t := range b.Types {
+		image := upload
+		image.ImageType = t
+		if len(b.Ref) > 0 || len(b.Parent) > 0 || len(b.URL) > 0 {
+			image.OSTree = &cloud.OSTreeV1{URL: b.URL, Ref: b.Ref, Parent: b.Parent}
+		}
+		images = append(images, image)
+	}
+	request, err := newCloudComposeRequest(blueprint, images, b.Distro, b.Arch, b.Repos)
+	if err != nil {
+		return "", err
+	}
+	return root.Cloud.StartComposeRequest(request)
+}
+
+// startBuildWeldr starts a weldr API compose of a blueprint on the server
+func startBuildWeldr(b *buildSetEntry, composeType string) (string, error) {
+	if len(b.Distro) > 0 || len(b.Arch) > 0 || len(b.Repos) > 0 {
+		return "", fmt.Errorf("distro, arch, and repos are only supported with a local blueprint file")
+	}
+	if len(b.Upload) > 0 && len(b.ImageName) == 0 {
+		return "", fmt.Errorf("image-name is required to upload a blueprint on the server")
+	}
+
+	var uuid string
+	var resp *weldr.APIResponse
+	var err error
+	ostree := len(b.Ref) > 0 || len(b.Parent) > 0 || len(b.URL) > 0
+	switch {
+	case ostree && len(b.Upload) > 0:
+		uuid, resp, err = root.Client.StartOSTreeComposeUpload(b.Blueprint, composeType, b.ImageName, b.Upload, b.Ref, b.Parent, b.URL, b.Size)
+	case ostree:
+		uuid, resp, err = root.Client.StartOSTreeCompose(b.Blueprint, composeType, b.Ref, b.Parent, b.URL, b.Size)
+	case len(b.Upload) > 0:
+		uuid, resp, err = root.Client.StartComposeUpload(b.Blueprint, composeType, b.ImageName, b.Upload, b.Size)
+	default:
+		uuid, resp, err = root.Client.StartCompose(b.Blueprint, composeType, b.Size)
+	}
+	if err != nil {
+		return "", err
+	}
+	if resp != nil {
+		for _, w := range resp.Warnings {
+			fmt.Printf("Warning: %s\n", w)
+		}
+		if !resp.Status {
+			return "", fmt.Errorf("%s", resp)
+		}
+	}
+	return uuid, nil
+}
+
+// waitForBuilds waits for the composes that were started and updates their status
+func waitForBuilds(composes []*buildSetCompose, timeout, interval time.Duration) {
+	started := make(map[string]*buildSetCompose)
+	var ids []string
+	for _, c := range composes {
+		if len(c.id) > 0 {
+			started[c.id] = c
+			ids = append(ids, c.id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	fmt.Printf("Waiting %v for %d composes to finish\n", timeout, len(ids))
+	for r := range waitForComposes(ids, timeout, interval, buildSetMaxRequests) {
+		c := started[r.id]
+		c.status = r.status
+		switch {
+		case r.cancelled:
+			c.err = "wait was cancelled"
+		case r.err != nil:
+			c.err = r.err.Error()
+		case r.timeout:
+			c.err = fmt.Sprintf("timeout after %v", timeout)
+		case r.failed:
+			c.err = "compose failed"
+		default:
+			fmt.Printf("%s %s\n", r.id, r.status)
+		}
+	}
+}
+
+// downloadBuilds downloads the images of the finished composes into a directory per build
+// Composes that are uploaded do not have an image to download, and only the first image of
+// a cloud API compose can be downloaded.
+func downloadBuilds(composes []*buildSetCompose, dir string) {
+	for _, c := range composes {
+		if len(c.id) == 0 || len(c.err) > 0 || len(c.build.Upload) > 0 {
+			continue
+		}
+		path := filepath.Join(dir, c.build.Name)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			c.err = err.Error()
+			continue
+		}
+
+		if c.cloud {
+			// The cloud API only downloads the first image, the others are reported as not downloaded
+			c.image, c.err = downloadError(root.Cloud.ComposeImagePath(c.id, path))
+			if len(c.err) == 0 && len(c.types) > 1 {
+				c.notDownloaded = c.types[1:]
+			}
+			continue
+		}
+		fn, resp, err := root.Client.ComposeImagePath(c.id, path)
+		if err == nil && resp != nil && !resp.Status {
+			err = fmt.Errorf("%s", resp)
+		}
+		c.image, c.err = downloadError(fn, err)
+	}
+}
+
+// downloadError returns the image path, or the error message if the download failed
+func downloadError(fn string, err error) (string, string) {
+	if err != nil {
+		return "", fmt.Sprintf("download failed: %s", err)
+	}
+	return fn, ""
+}
@@ -0,0 +1,283 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+// resetBuildSetFlags sets the build-set flags back to their defaults
+func resetBuildSetFlags() {
+	buildSetWait = false
+	buildSetDownload = false
+	buildSetDir = ""
+	buildSetTimeoutStr = "5m"
+	buildSetPollStr = "10s"
+	buildSetMaxRequests = 4
+	buildSetCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Changed = false
+	})
+}
+
+// buildSetTest returns mock servers that start composes and report their status
+// The weldr API compose's UUID depends on the image type, the failed types fail.
+// The cloud API compose always uses the same UUID and finishes.
+type buildSetTest struct {
+	sync.Mutex
+	failed  map[string]bool
+	started []string // Image types of the weldr composes that were started
+}
+
+var weldrBuildIDs = map[string]string{
+	"qcow2": "11111111-1ffa-4de6-95ed-42749ac1f389",
+	"vmdk":  "22222222-1ffa-4de6-95ed-42749ac1f389",
+	"ami":   "33333333-1ffa-4de6-95ed-42749ac1f389",
+}
+
+// weldrBuildType returns the image type of a weldr API compose
+func weldrBuildType(id string) string {
+	for t, buildID := range weldrBuildIDs {
+		if buildID == id {
+			return t
+		}
+	}
+	return ""
+}
+
+const cloudBuildID = "008fc5ad-adad-42ec-b412-7923733483a8"
+
+func mockResponse(request *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		Request:    request,
+		StatusCode: status,
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		Header:     http.Header{},
+	}
+}
+
+func mockImage(request *http.Request, fileName string) *http.Response {
+	resp := mockResponse(request, 200, "This is a poor approximation of an image file.")
+	resp.Header.Set("Content-Disposition", "attachment; filename="+fileName)
+	resp.Header.Set("Content-Type", "application/octet-stream")
+	return resp
+}
+
+func (b *buildSetTest) weldr(request *http.Request) (*http.Response, error) {
+	b.Lock()
+	defer b.Unlock()
+	path := request.URL.Path
+	id := path[strings.LastIndex(path, "/")+1:]
+	composeType := weldrBuildType(id)
+
+	switch {
+	case path == "/api/v1/compose":
+		var body struct {
+			ComposeType string `json:"compose_type"`
+		}
+		if err := json.NewDecoder(request.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		b.started = append(b.started, body.ComposeType)
+		return mockResponse(request, 200, fmt.Sprintf(`{"build_id": "%s", "status": true}`, weldrBuildIDs[body.ComposeType])), nil
+	case strings.HasPrefix(path, "/api/v1/compose/info/"):
+		status := "FINISHED"
+		if b.failed[composeType] {
+			status = "FAILED"
+		}
+		return mockResponse(request, 200, getInfoWithStatus(status)), nil
+	case strings.HasPrefix(path, "/api/v1/compose/image/"):
+		return mockImage(request, id+"-disk."+composeType), nil
+	}
+	return mockResponse(request, 404, `{"status": false, "errors": [{"id": "HTTPError", "msg": "Not Found"}]}`), nil
+}
+
+func (b *buildSetTest) cloud(request *http.Request) (*http.Response, error) {
+	path := request.URL.Path
+	switch path {
+	case "/api/image-builder-composer/v2/compose":
+		return mockResponse(request, 201, fmt.Sprintf(`{"href": "/api/image-builder-composer/v2/compose", "id": "%s", "kind": "ComposeId"}`, cloudBuildID)), nil
+	case "/api/image-builder-composer/v2/composes/" + cloudBuildID:
+		return mockResponse(request, 200, fmt.Sprintf(`{"id": "%s", "kind": "ComposeStatus", "status": "success"}`, cloudBuildID)), nil
+	case "/api/image-builder-composer/v2/composes/" + cloudBuildID + "/download":
+		return mockImage(request, cloudBuildID+"-images.tar"), nil
+	}
+	return mockResponse(request, 404, `{"kind": "Error", "reason": "Compose not found"}`), nil
+}
+
+// writeBuildSet writes a build set file and a local blueprint to a temporary directory
+func writeBuildSet(t *testing.T, set string) string {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "local-bp.toml"), []byte(`name = "local-bp"
+version = "1.0.0"
+[[packages]]
+name = "tmux"
+`), 0644)
+	require.Nil(t, err)
+	path := filepath.Join(dir, "set.toml")
+	err = os.WriteFile(path, []byte(set), 0644)
+	require.Nil(t, err)
+	return path
+}
+
+func TestCmdComposeBuildSet(t *testing.T) {
+	b := &buildSetTest{}
+	root.SetupCmdTest(b.weldr)
+	root.SetupCloudCmdTest(b.cloud)
+	resetBuildSetFlags()
+
+	path := writeBuildSet(t, `
+directory = "images"
+download = true
+poll = "10ms"
+
+[[build]]
+blueprint = "local-bp.toml"
+types = ["qcow2", "ami"]
+
+[[build]]
+name = "server"
+blueprint = "http-server"
+types = ["qcow2", "vmdk"]
+`)
+	cmd, out, err := root.ExecuteTest("compose", "build-set", path)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, buildSetCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "local-bp: Compose 008fc5ad-adad-42ec-b412-7923733483a8 added to the queue\n")
+	assert.Contains(t, string(stdout), "server: Compose 11111111-1ffa-4de6-95ed-42749ac1f389 added to the queue\n")
+	assert.Contains(t, string(stdout), "Waiting 5m0s for 3 composes to finish\n")
+	assert.Regexp(t, `local-bp +qcow2 +008fc5ad-adad-42ec-b412-7923733483a8 +success +.*/images/local-bp/008fc5ad-adad-42ec-b412-7923733483a8-images.tar\n`, string(stdout))
+	assert.Regexp(t, `local-bp +ami +008fc5ad-adad-42ec-b412-7923733483a8 +success +not downloaded, the cloud API only downloads the first image\n`, string(stdout))
+	assert.Regexp(t, `server +vmdk +22222222-1ffa-4de6-95ed-42749ac1f389 +FINISHED +.*/images/server/22222222-1ffa-4de6-95ed-42749ac1f389-disk.vmdk\n`, string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(stderr))
+	assert.Equal(t, []string{"qcow2", "vmdk"}, b.started)
+
+	// The images are downloaded into a directory for each build, next to the build set
+	_, err = os.Stat(filepath.Join(filepath.Dir(path), "images", "local-bp", cloudBuildID+"-images.tar"))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(filepath.Dir(path), "images", "server", weldrBuildIDs["qcow2"]+"-disk.qcow2"))
+	assert.Nil(t, err)
+}
+
+func TestCmdComposeBuildSetNoWait(t *testing.T) {
+	b := &buildSetTest{}
+	root.SetupCmdTest(b.weldr)
+	root.SetupCloudCmdTest(b.cloud)
+	resetBuildSetFlags()
+
+	// The flags override the file's settings
+	path := writeBuildSet(t, `
+download = true
+
+[[build]]
+blueprint = "http-server"
+types = ["qcow2"]
+`)
+	cmd, out, err := root.ExecuteTest("compose", "build-set", "--download=false", path)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(stdout), "Waiting")
+	assert.Regexp(t, `http-server +qcow2 +11111111-1ffa-4de6-95ed-42749ac1f389 +\n`, string(stdout))
+}
+
+func TestCmdComposeBuildSetProblems(t *testing.T) {
+	b := &buildSetTest{failed: map[string]bool{"vmdk": true}}
+	root.SetupCmdTest(b.weldr)
+	root.SetupCloudCmdTest(b.cloud)
+	resetBuildSetFlags()
+
+	path := writeBuildSet(t, `
+[[build]]
+blueprint = "http-server"
+types = ["qcow2", "vmdk"]
+
+[[build]]
+name = "upload"
+blueprint = "http-server"
+types = ["ami"]
+upload = "aws.toml"
+`)
+	cmd, out, err := root.ExecuteTest("compose", "build-set", "--wait", "--poll", "10ms", path)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Regexp(t, `http-server +qcow2 +11111111-1ffa-4de6-95ed-42749ac1f389 +FINISHED +\n`, string(stdout))
+	assert.Regexp(t, `http-server +vmdk +22222222-1ffa-4de6-95ed-42749ac1f389 +FAILED +compose failed\n`, string(stdout))
+	assert.Regexp(t, `upload +ami +image-name is required to upload a blueprint on the server\n`, string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR: 2 of 3 composes had problems\n", string(stderr))
+}
+
+func TestReadBuildSet(t *testing.T) {
+	path := writeBuildSet(t, `
+directory = "/var/tmp/images"
+[[build]]
+blueprint = "local-bp.toml"
+types = ["qcow2"]
+repos = ["baseos.toml", "/etc/custom.toml"]
+[[build]]
+blueprint = "http-server"
+types = ["qcow2"]
+upload = "aws.toml"
+`)
+	set, err := readBuildSet(path)
+	require.Nil(t, err)
+	dir := filepath.Dir(path)
+	assert.Equal(t, "/var/tmp/images", set.Directory)
+	require.Equal(t, 2, len(set.Builds))
+	assert.Equal(t, "local-bp", set.Builds[0].Name)
+	assert.Equal(t, filepath.Join(dir, "local-bp.toml"), set.Builds[0].Blueprint)
+	assert.Equal(t, []string{filepath.Join(dir, "baseos.toml"), "/etc/custom.toml"}, set.Builds[0].Repos)
+	assert.Equal(t, "http-server", set.Builds[1].Name)
+	assert.Equal(t, "http-server", set.Builds[1].Blueprint)
+	assert.Equal(t, filepath.Join(dir, "aws.toml"), set.Builds[1].Upload)
+
+	for _, tc := range []struct {
+		set string
+		err string
+	}{
+		{"[[build]]\nblueprint = \"bp\"\ntype = [\"qcow2\"]\n", "unknown settings: [build.type]"},
+		{"[[build]]\nblueprint = \"bp\"\ntypes = [\"qcow2\"]\n[[build]]\nblueprint = \"bp\"\ntypes = [\"ami\"]\n", "build name bp is used more than once"},
+		{"[[build]]\ntypes = [\"qcow2\"]\n", "build 1 is missing the blueprint"},
+		{"[[build]]\nblueprint = \"bp\"\n", "build bp is missing the image types"},
+		{"wait = true\n", "does not have any builds"},
+		{"[[build]]\nname = \"../escape\"\nblueprint = \"bp\"\ntypes = [\"qcow2\"]\n", "build name ../escape cannot include a path separator or '..'"},
+		{"[[build]]\nname = \"images/web\"\nblueprint = \"bp\"\ntypes = [\"qcow2\"]\n", "build name images/web cannot include a path separator or '..'"},
+		{"[[build]]\nname = \"..\"\nblueprint = \"bp\"\ntypes = [\"qcow2\"]\n", "build name .. cannot include a path separator or '..'"},
+	} {
+		_, err := readBuildSet(writeBuildSet(t, tc.set))
+		require.NotNil(t, err, tc.set)
+		assert.Contains(t, err.Error(), tc.err)
+	}
+}
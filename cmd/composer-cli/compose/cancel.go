@@ -5,15 +5,22 @@
 package compose
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 
+	"github.com/osbuild/weldr-client/v2/cloud"
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
 )
 
 var (
 	cancelCmd = &cobra.Command{
-		Use:     "cancel UUID",
-		Short:   "Cancel one compose",
+		Use:   "cancel UUID",
+		Short: "Cancel one compose",
+		Long: `Cancel a waiting or running compose
+
+The cloud API is checked first, if it does not have the compose it is cancelled
+using the weldr API.`,
 		Example: "  composer-cli compose cancel 914bb03b-e4c8-4074-bc31-6869961ee2f3",
 		RunE:    cancelComposes,
 		Args:    cobra.ExactArgs(1),
@@ -25,12 +32,24 @@ func init() {
 }
 
 func cancelComposes(cmd *cobra.Command, args []string) error {
-	_, errors, err := root.Client.CancelCompose(args[0])
+	// Check cloudapi for the compose first
+	if root.Cloud.Exists() {
+		err := root.Cloud.Composes().Cancel(args[0])
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, cloud.ErrUnknownCompose) {
+			return root.ExecutionError(cmd, "Cancel Error: %s", err)
+		}
+	}
+
+	// Not a cloudapi compose, try the weldrapi
+	_, apiErrors, err := root.Client.CancelCompose(args[0])
 	if err != nil {
 		return root.ExecutionError(cmd, "Cancel Error: %s", err)
 	}
-	if len(errors) > 0 {
-		return root.ExecutionErrors(cmd, errors)
+	if len(apiErrors) > 0 {
+		return root.ExecutionErrors(cmd, apiErrors)
 	}
 
 	return nil
@@ -6,6 +6,7 @@ package compose
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
 	"testing"
@@ -195,3 +196,95 @@ func TestCmdComposeCancelUnknownJSON(t *testing.T) {
 	assert.Equal(t, []byte(""), sentBody)
 	assert.Equal(t, "/api/v1/compose/cancel/4b668b1a-e6b8-4dce-8828-4a8e3bef2345", mc.Req.URL.Path)
 }
+
+func TestCmdComposeCancelCloud(t *testing.T) {
+	// Test the "compose cancel" command with a cloud API compose
+	mwc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("unexpected weldr API request: %s", request.URL.Path)
+	})
+	mcc := root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"href": "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/cancel", "kind": "ComposeCancel", "id": "008fc5ad-adad-42ec-b412-7923733483a8"}`
+
+		return &http.Response{
+			Request:    request,
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	cmd, out, err := root.ExecuteTest("compose", "cancel", "008fc5ad-adad-42ec-b412-7923733483a8")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, cancelCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stdout)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+	assert.Equal(t, "POST", mcc.Req.Method)
+	assert.Equal(t, "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/cancel", mcc.Req.URL.Path)
+	assert.Equal(t, "", mwc.Req.Method)
+}
+
+func TestCmdComposeCancelCloudUnknown(t *testing.T) {
+	// Test the "compose cancel" command falling back to the weldr API
+	mwc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"uuids": [{"uuid": "ac188b76-138a-452c-82fb-5cc651986991", "status": true}], "errors": []}`
+
+		return &http.Response{
+			Request:    request,
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+	mcc := root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"kind": "Error", "reason": "Compose with given id not found"}`
+
+		return &http.Response{
+			Request:    request,
+			StatusCode: 404,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	cmd, out, err := root.ExecuteTest("compose", "cancel", "ac188b76-138a-452c-82fb-5cc651986991")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+	assert.Equal(t, "POST", mcc.Req.Method)
+	assert.Equal(t, "DELETE", mwc.Req.Method)
+	assert.Equal(t, "/api/v1/compose/cancel/ac188b76-138a-452c-82fb-5cc651986991", mwc.Req.URL.Path)
+}
+
+func TestCmdComposeCancelCloudError(t *testing.T) {
+	// Test the "compose cancel" command with a compose that cannot be cancelled
+	mwc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("unexpected weldr API request: %s", request.URL.Path)
+	})
+	root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"kind": "Error", "reason": "Compose is not pending"}`
+
+		return &http.Response{
+			Request:    request,
+			StatusCode: 400,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	cmd, out, err := root.ExecuteTest("compose", "cancel", "008fc5ad-adad-42ec-b412-7923733483a8")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: Cancel Error: POST api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/cancel failed with status 400: Compose is not pending")
+	assert.Equal(t, "", mwc.Req.Method)
+}
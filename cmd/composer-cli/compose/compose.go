@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 var (
@@ -56,3 +57,13 @@ var (
 func init() {
 	root.AddRootCommand(composeCmd)
 }
+
+// composeBackends returns the APIs used to find a compose, the cloud API is first
+// It is skipped when it is not available.
+func composeBackends() []weldr.ComposeBackend {
+	var backends []weldr.ComposeBackend
+	if root.Cloud.Exists() {
+		backends = append(backends, root.Cloud.Composes())
+	}
+	return append(backends, root.Client.Composes())
+}
@@ -6,6 +6,7 @@ package compose
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -28,44 +29,81 @@ func init() {
 
 func info(cmd *cobra.Command, args []string) error {
 	if root.Cloud.Exists() {
-		metadata, err := root.Cloud.GetComposeMetadata(args[0])
+		backend := root.Cloud.Composes()
+		metadata, err := backend.Metadata(args[0])
 		if err == nil {
-			var imageSize string
-			var imageType string
-			if len(metadata.Request.ImageRequests) > 0 {
-				if metadata.Request.ImageRequests[0].Size > 0 {
-					imageSize = fmt.Sprintf("%d", metadata.Request.ImageRequests[0].Size)
-				}
-				imageType = metadata.Request.ImageRequests[0].ImageType
-			}
+			imageType, imageSize := imageDetails(metadata.Images)
 
-			info, _ := root.Cloud.ComposeInfo(args[0])
+			compose, err := backend.Info(args[0])
+			if err != nil {
+				compose.State = "Unknown"
+			}
 			fmt.Printf("%s %-8s %-15s %s %-16s %s\n",
 				args[0],
-				root.Cloud.StatusMap(info.Status),
-				metadata.Request.Blueprint.Name,
-				metadata.Request.Blueprint.Version,
+				compose.State,
+				metadata.Blueprint.Name,
+				metadata.Blueprint.Version,
 				imageType,
 				imageSize)
 
-			uploads, err := metadata.UploadTypes()
+			// List each image when there is more than one
+			if len(metadata.Images) > 1 {
+				fmt.Println("Images:")
+				for _, image := range metadata.Images {
+					var size string
+					if image.Size > 0 {
+						size = fmt.Sprintf("%d", image.Size)
+					}
+					fmt.Printf("    %-8s %-16s %s\n", image.Arch, image.Type, size)
+				}
+			}
+
+			// The times are only included when the server reports them
+			for _, t := range []struct {
+				name string
+				time time.Time
+			}{{"Created", compose.Created}, {"Started", compose.Started}, {"Finished", compose.Finished}} {
+				if !t.time.IsZero() {
+					fmt.Printf("%-9s %s\n", t.name+":", t.time.Format("Mon Jan 2 15:04:05 2006"))
+				}
+			}
+
+			if len(compose.Errors) > 0 {
+				fmt.Println("Errors:")
+				for _, e := range compose.Errors {
+					fmt.Printf("    %s\n", e)
+				}
+			}
 
 			// Skip printing uploads if there are none, or the only one is local
-			if err == nil && len(uploads) > 0 && uploads[0] != "local" {
-				// NOTE: Cloud doesn't have the same upload info as weldr, just print types
-				fmt.Printf("Uploads:\n")
-				for _, t := range uploads {
-					fmt.Printf("    %s\n", t)
+			// Older servers do not report the upload status, the types are printed instead.
+			if len(compose.Uploads) > 0 {
+				if len(compose.Uploads) > 1 || compose.Uploads[0].Type != "local" {
+					fmt.Printf("Uploads:\n")
+					for _, u := range compose.Uploads {
+						fmt.Printf("    %-8s %s\n", u.Type, u.Status)
+					}
+				}
+			} else {
+				var uploads []string
+				for _, image := range metadata.Images {
+					uploads = append(uploads, image.Uploads...)
+				}
+				if len(uploads) > 0 && uploads[0] != "local" {
+					fmt.Printf("Uploads:\n")
+					for _, t := range uploads {
+						fmt.Printf("    %s\n", t)
+					}
 				}
 			}
 
 			fmt.Println("Packages:")
-			for _, p := range metadata.Request.Blueprint.Packages {
+			for _, p := range metadata.Blueprint.Packages {
 				fmt.Printf("    %s\n", p)
 			}
 
 			fmt.Println("Modules:")
-			for _, m := range metadata.Request.Blueprint.Modules {
+			for _, m := range metadata.Blueprint.Modules {
 				fmt.Printf("    %s\n", m)
 			}
 
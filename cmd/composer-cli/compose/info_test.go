@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -172,6 +173,20 @@ func TestCmdComposeInfoJSON(t *testing.T) {
 	assert.Equal(t, "GET", mc.Req.Method)
 }
 
+func TestCmdComposeInfoOutput(t *testing.T) {
+	// Test that "compose info" does not ignore --output
+	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return nil, nil
+	})
+	mc.Req = http.Request{}
+
+	_, out, err := root.ExecuteTest("compose", "info", "--output", "yaml", "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.NotNil(t, out)
+	defer out.Close()
+	assert.ErrorContains(t, err, "compose info does not support --output yaml")
+	assert.Equal(t, "", mc.Req.Method)
+}
+
 func TestCmdComposeInfoUnknown(t *testing.T) {
 	// Test the "compose info" command
 	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
@@ -520,3 +535,136 @@ func TestComposeInfoCloud(t *testing.T) {
 	assert.Equal(t, []byte(""), stderr)
 	assert.Equal(t, "GET", mcc.Req.Method)
 }
+
+func TestComposeInfoCloudImages(t *testing.T) {
+	// Test info for a compose with several image requests
+	root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		var json string
+		var sc int
+
+		if request.URL.Path == "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/metadata" {
+			sc = 200
+			json = `{
+  "href": "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/metadata",
+  "id": "008fc5ad-adad-42ec-b412-7923733483a8",
+  "kind": "ComposeMetadata",
+  "packages": [],
+  "request": {
+    "blueprint": {
+      "name": "tmux-image",
+      "packages": [
+        {
+          "name": "tmux"
+        }
+      ],
+      "version": "0.0.1"
+    },
+    "distribution": "fedora-41",
+    "image_requests": [
+      {
+        "architecture": "x86_64",
+        "image_type": "qcow2",
+        "repositories": [],
+        "upload_targets": [{"type": "local", "upload_options": {}}]
+      },
+      {
+        "architecture": "aarch64",
+        "image_type": "vmdk",
+        "size": 4294967296,
+        "repositories": [],
+        "upload_targets": [{"type": "local", "upload_options": {}}]
+      }
+    ]
+  }
+}`
+		} else if request.URL.Path == "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8" {
+			sc = 200
+			json = `{
+  "href": "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8",
+  "id": "008fc5ad-adad-42ec-b412-7923733483a8",
+  "kind": "ComposeStatus",
+  "status": "pending"
+}`
+		} else {
+			sc = 404
+			json = `{"kind":"ComposeError", "...":"unknown url"}`
+		}
+
+		return &http.Response{
+			StatusCode: sc,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	cmd, out, err := root.ExecuteTest("compose", "info", "008fc5ad-adad-42ec-b412-7923733483a8")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, infoCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "008fc5ad-adad-42ec-b412-7923733483a8 RUNNING  tmux-image      0.0.1 qcow2,vmdk       -,4294967296")
+	assert.Contains(t, string(stdout), "Images:\n    x86_64   qcow2            \n    aarch64  vmdk             4294967296\n")
+}
+
+func TestComposeInfoCloudFailed(t *testing.T) {
+	// Test info for a failed compose, it includes the reason, times, and upload status
+	root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		var json string
+		var sc int
+
+		if request.URL.Path == "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/metadata" {
+			sc = 200
+			json = `{
+  "id": "008fc5ad-adad-42ec-b412-7923733483a8",
+  "kind": "ComposeMetadata",
+  "request": {
+    "blueprint": {"name": "tmux-image", "version": "0.0.1"},
+    "distribution": "fedora-41",
+    "image_requests": [
+      {"architecture": "x86_64", "image_type": "ami", "upload_targets": [{"type": "aws", "upload_options": {}}]}
+    ]
+  }
+}`
+		} else if request.URL.Path == "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8" {
+			sc = 200
+			json = `{
+  "id": "008fc5ad-adad-42ec-b412-7923733483a8",
+  "kind": "ComposeStatus",
+  "created_at": "2026-10-16T10:00:00Z",
+  "finished_at": "2026-10-16T10:12:30Z",
+  "image_status": {
+    "status": "failure",
+    "error": {"id": 10, "reason": "osbuild build failed", "details": "package tmux not found"},
+    "upload_statuses": [{"options": null, "status": "failure", "type": "aws"}]
+  },
+  "status": "failure"
+}`
+		} else {
+			sc = 404
+			json = `{"kind":"ComposeError", "...":"unknown url"}`
+		}
+
+		return &http.Response{
+			StatusCode: sc,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	cmd, out, err := root.ExecuteTest("compose", "info", "008fc5ad-adad-42ec-b412-7923733483a8")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, infoCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	created := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC).Local().Format("Mon Jan 2 15:04:05 2006")
+	finished := time.Date(2026, 10, 16, 10, 12, 30, 0, time.UTC).Local().Format("Mon Jan 2 15:04:05 2006")
+	assert.Contains(t, string(stdout), "008fc5ad-adad-42ec-b412-7923733483a8 FAILED   tmux-image      0.0.1 ami")
+	assert.Contains(t, string(stdout), "Created:  "+created+"\nFinished: "+finished+"\n")
+	assert.NotContains(t, string(stdout), "Started:")
+	assert.Contains(t, string(stdout), "Errors:\n    osbuild build failed: \"package tmux not found\"\n")
+	assert.Contains(t, string(stdout), "Uploads:\n    aws      failure\n")
+}
@@ -8,12 +8,13 @@ import (
 	"fmt"
 	"os"
 	"slices"
-	"sort"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 var (
@@ -30,102 +31,138 @@ var (
 )
 
 func init() {
+	root.EnableOutput(listCmd)
 	composeCmd.AddCommand(listCmd)
 }
 
-// composeDetails returns the compose's blueprint name, version, image type, and size
-func composeDetails(id string) (string, string, string, string) {
-	metadata, err := root.Cloud.GetComposeMetadata(id)
-	if err != nil {
-		return "", "", "", ""
+// imageDetails returns the types and sizes of the compose's images
+// They are comma separated, an image using the default size is shown as '-' unless none
+// of them have a size, then the size is empty.
+func imageDetails(images []weldr.ComposeImage) (string, string) {
+	var imageTypes []string
+	var sizes []string
+	var hasSize bool
+	for _, image := range images {
+		imageTypes = append(imageTypes, image.Type)
+		if image.Size > 0 {
+			sizes = append(sizes, fmt.Sprintf("%d", image.Size))
+			hasSize = true
+		} else {
+			sizes = append(sizes, "-")
+		}
 	}
-	var size string
-	var imageType string
-	if len(metadata.Request.ImageRequests) > 0 {
-		imageType = metadata.Request.ImageRequests[0].ImageType
+	if !hasSize {
+		return strings.Join(imageTypes, ","), ""
+	}
+	return strings.Join(imageTypes, ","), strings.Join(sizes, ",")
+}
 
-		if metadata.Request.ImageRequests[0].Size > 0 {
-			size = fmt.Sprintf("%d", metadata.Request.ImageRequests[0].Size)
-		}
+// composeRecord is the normalized details of a compose from either API
+// It is used for the --output formats, the status is WAITING, RUNNING, FINISHED, or
+// FAILED for both APIs, and the sizes are in bytes.
+type composeRecord struct {
+	ID        string   `json:"id"`
+	API       string   `json:"api"`
+	Status    string   `json:"status"`
+	Time      string   `json:"time,omitempty"`
+	Blueprint string   `json:"blueprint"`
+	Version   string   `json:"version"`
+	Types     []string `json:"types"`
+	Sizes     []uint64 `json:"sizes,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// newComposeRecord returns the details of a compose from either API
+// The sizes are left out when none of the images have a size.
+func newComposeRecord(compose weldr.Compose) composeRecord {
+	r := composeRecord{
+		ID:        compose.ID,
+		API:       compose.API,
+		Status:    compose.State,
+		Blueprint: compose.Blueprint,
+		Version:   compose.Version,
+		Types:     compose.Types,
+		Errors:    compose.Errors,
+	}
+	if r.Types == nil {
+		r.Types = []string{}
+	}
+	if slices.ContainsFunc(compose.Sizes, func(size uint64) bool { return size > 0 }) {
+		r.Sizes = compose.Sizes
+	}
+	return r
+}
 
+// listComposes returns the composes from both APIs, the cloud API's are first
+// Errors from the cloud API are ignored, it may not be running.
+func listComposes() ([]weldr.Compose, error) {
+	var composes []weldr.Compose
+	if root.Cloud.Exists() {
+		cloudComposes, _ := root.Cloud.Composes().List()
+		composes = append(composes, cloudComposes...)
 	}
 
-	return metadata.Request.Blueprint.Name, metadata.Request.Blueprint.Version, imageType, size
+	weldrComposes, err := root.Client.Composes().List()
+	return append(composes, weldrComposes...), err
 }
 
-func list(cmd *cobra.Command, args []string) (rcErr error) {
-	// One output table for both APIs
-	w := tabwriter.NewWriter(os.Stdout, 5, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "ID\tStatus\tBlueprint\tVersion\tType")
+// composeFilter returns true if the compose's state is one of the list arguments
+// The cloud API does not have a waiting status, its pending composes are RUNNING and
+// are also listed as waiting.
+func composeFilter(compose weldr.Compose, args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+	for _, arg := range args {
+		state := strings.ToUpper(arg)
+		if compose.State == state || (compose.API == "cloud" && state == "WAITING" && compose.State == "RUNNING") {
+			return true
+		}
+	}
+	return false
+}
 
-	// Check cloudapi for composes first
-	if root.Cloud.Exists() {
-		composes, _ := root.Cloud.ListComposes()
-		if len(composes) > 0 {
-			var filter []string
-			for _, arg := range args {
-				switch arg {
-				case "waiting":
-					filter = append(filter, "pending")
-				case "running":
-					filter = append(filter, "pending")
-				case "finished":
-					filter = append(filter, "success")
-				case "failed":
-					filter = append(filter, "failure")
-				}
-			}
-			sort.Strings(filter)
-
-			for i := range composes {
-				if len(filter) > 0 && !slices.Contains(filter, composes[i].Status) {
-					continue
-				}
-
-				// Get as much detail as we can about the compose
-				// This depends on the type of build and how it was started so some fields may
-				// be blank.
-				bpName, bpVersion, imageType, _ := composeDetails(composes[i].ID)
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", composes[i].ID,
-					root.Cloud.StatusMap(composes[i].Status),
-					bpName, bpVersion, imageType)
-			}
+// printComposeRecords prints the composes using the --output format
+func printComposeRecords(records []composeRecord) error {
+	var rows [][]string
+	for _, r := range records {
+		var sizes []string
+		for _, s := range r.Sizes {
+			sizes = append(sizes, fmt.Sprintf("%d", s))
 		}
+		rows = append(rows, []string{r.ID, r.API, r.Status, r.Time, r.Blueprint, r.Version,
+			strings.Join(r.Types, ","), strings.Join(sizes, ","), strings.Join(r.Errors, "; ")})
 	}
+	header := []string{"ID", "API", "Status", "Time", "Blueprint", "Version", "Types", "Sizes", "Errors"}
+	return root.PrintOutput(records, header, rows)
+}
 
-	// Check weldrapi for composes
-	composes, errors, err := root.Client.ListComposes()
+func list(cmd *cobra.Command, args []string) (rcErr error) {
+	composes, err := listComposes()
 	if err != nil {
-		return root.ExecutionError(cmd, "List Error: %s", err)
-	}
-	if len(errors) > 0 {
-		rcErr = root.ExecutionErrors(cmd, errors)
+		rcErr = root.ExecutionError(cmd, "List Error: %s", err)
 	}
 
-	var filter []string
-	for _, arg := range args {
-		switch arg {
-		case "waiting":
-			filter = append(filter, "WAITING")
-		case "running":
-			filter = append(filter, "RUNNING")
-		case "finished":
-			filter = append(filter, "FINISHED")
-		case "failed":
-			filter = append(filter, "FAILED")
+	records := []composeRecord{}
+	for _, c := range composes {
+		if composeFilter(c, args) {
+			records = append(records, newComposeRecord(c))
 		}
 	}
-	sort.Strings(filter)
 
-	for i := range composes {
-		if len(filter) > 0 && !slices.Contains(filter, composes[i].Status) {
-			continue
+	if root.StructuredOutput() {
+		if err := printComposeRecords(records); err != nil {
+			return root.ExecutionError(cmd, "%s", err)
 		}
-
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", composes[i].ID, composes[i].Status,
-			composes[i].Blueprint, composes[i].Version, composes[i].Type)
+		return rcErr
 	}
 
+	// One output table for both APIs
+	w := tabwriter.NewWriter(os.Stdout, 5, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tStatus\tBlueprint\tVersion\tType")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.ID, r.Status, r.Blueprint, r.Version, strings.Join(r.Types, ","))
+	}
 	w.Flush() //nolint:errcheck
 	return rcErr
 }
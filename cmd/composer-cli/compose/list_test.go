@@ -529,3 +529,145 @@ func TestComposeListCloud(t *testing.T) {
 	assert.Equal(t, []byte(""), stderr)
 	assert.Equal(t, "GET", mcc.Req.Method)
 }
+
+// listOutputTest returns mock servers with a compose from each API
+func listOutputTest() {
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"new": [], "run": [], "finished": [], "failed": []}`
+		if request.URL.Path == "/api/v1/compose/finished" {
+			json = `{"finished": [{
+	"id": "cefd01c3-629f-493e-af72-3f12981bb77b",
+	"blueprint": "tmux-bcl",
+	"version": "1.0.0",
+	"compose_type": "qcow2",
+	"image_size": 2147483648,
+	"queue_status": "FINISHED",
+	"job_created": 1608149057.869667,
+	"job_started": 1608149057.8754315,
+	"job_finished": 1608149299.363162
+}]}`
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+	root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		var json string
+		if request.URL.Path == "/api/image-builder-composer/v2/composes/" {
+			json = `[{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeStatus", "status": "pending"}]`
+		} else if request.URL.Path == "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/metadata" {
+			json = `{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeMetadata",
+  "request": {
+    "blueprint": {"name": "tmux-image", "version": "0.0.1"},
+    "distribution": "fedora-41",
+    "image_requests": [
+      {"architecture": "x86_64", "image_type": "qcow2", "size": 4294967296},
+      {"architecture": "x86_64", "image_type": "ami"}
+    ]
+  }
+}`
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+}
+
+func TestCmdComposeListOutput(t *testing.T) {
+	listOutputTest()
+
+	// The composes from both APIs are in one list, with the same status names
+	cmd, out, err := root.ExecuteTest("compose", "list", "--output", "json")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, listCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, `[
+    {
+        "id": "008fc5ad-adad-42ec-b412-7923733483a8",
+        "api": "cloud",
+        "status": "RUNNING",
+        "blueprint": "tmux-image",
+        "version": "0.0.1",
+        "types": [
+            "qcow2",
+            "ami"
+        ],
+        "sizes": [
+            4294967296,
+            0
+        ]
+    },
+    {
+        "id": "cefd01c3-629f-493e-af72-3f12981bb77b",
+        "api": "weldr",
+        "status": "FINISHED",
+        "blueprint": "tmux-bcl",
+        "version": "1.0.0",
+        "types": [
+            "qcow2"
+        ],
+        "sizes": [
+            2147483648
+        ]
+    }
+]
+`, string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+
+	_, out, err = root.ExecuteTest("compose", "list", "--output", "csv")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err = io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, `ID,API,Status,Time,Blueprint,Version,Types,Sizes,Errors
+008fc5ad-adad-42ec-b412-7923733483a8,cloud,RUNNING,,tmux-image,0.0.1,"qcow2,ami","4294967296,0",
+cefd01c3-629f-493e-af72-3f12981bb77b,weldr,FINISHED,,tmux-bcl,1.0.0,qcow2,2147483648,
+`, string(stdout))
+
+	_, out, err = root.ExecuteTest("compose", "list", "--output", `template={{range .}}{{.id}} {{.status}}{{"\n"}}{{end}}`)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err = io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "008fc5ad-adad-42ec-b412-7923733483a8 RUNNING\ncefd01c3-629f-493e-af72-3f12981bb77b FINISHED\n", string(stdout))
+
+	// --output table is the normal output
+	_, out, err = root.ExecuteTest("compose", "list", "--output", "table")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err = io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "ID   ")
+	assert.Contains(t, string(stdout), "qcow2,ami")
+}
+
+func TestCmdComposeListOutputErrors(t *testing.T) {
+	listOutputTest()
+
+	_, out, err := root.ExecuteTest("compose", "list", "--output", "xml")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: unknown --output format \"xml\"")
+
+	_, out, err = root.ExecuteTest("compose", "list", "--json", "--output", "yaml")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stderr, err = io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: --json cannot be used with --output")
+}
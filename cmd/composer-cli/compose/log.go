@@ -7,6 +7,7 @@ package compose
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -17,18 +18,37 @@ var (
 	logCmd = &cobra.Command{
 		Use:   "log UUID [size]",
 		Short: "Get the log for a running compose",
-		Long:  "Get the log for a running compose, optional size in kB that defaults to 1k",
+		Long: `Get the log for a running compose, optional size in kB that defaults to 1k
+
+With --follow new lines from the log are printed until the compose is finished or
+has failed. --follow also supports cloud API composes, the cloud API only has the
+logs once the build is done so it waits for the compose to finish and then prints
+all of them at once.`,
 		Example: `  composer-cli compose log 914bb03b-e4c8-4074-bc31-6869961ee2f3
-  composer-cli compose log 914bb03b-e4c8-4074-bc31-6869961ee2f3 2048`,
+  composer-cli compose log 914bb03b-e4c8-4074-bc31-6869961ee2f3 2048
+  composer-cli compose log --follow 914bb03b-e4c8-4074-bc31-6869961ee2f3`,
 		RunE: getLog,
 		Args: cobra.MinimumNArgs(1),
 	}
+	follow        bool
+	followPollStr string
 )
 
 func init() {
+	logCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Print new log lines until the compose is done")
+	logCmd.Flags().StringVarP(&followPollStr, "poll", "", "5s", "Polling interval used with --follow")
 	composeCmd.AddCommand(logCmd)
 }
 
+// printWriter writes to stdout with fmt.Print
+// os.Stdout is disabled when --json is used, the output is dropped instead of failing.
+type printWriter struct{}
+
+func (printWriter) Write(p []byte) (int, error) {
+	fmt.Print(string(p))
+	return len(p), nil
+}
+
 func getLog(cmd *cobra.Command, args []string) error {
 	logSize := 1024
 	if len(args) > 1 {
@@ -38,6 +58,32 @@ func getLog(cmd *cobra.Command, args []string) error {
 		}
 		logSize = s
 	}
+	interval, err := time.ParseDuration(followPollStr)
+	if err != nil {
+		return root.ExecutionError(cmd, "poll - %s", err)
+	}
+
+	if follow {
+		if root.Cloud.Exists() {
+			// Try the UUID with the cloud API first
+			if _, err := root.Cloud.ComposeInfo(args[0]); err == nil {
+				return followCloudLog(cmd, args[0], interval)
+			}
+		}
+
+		info, resp, err := root.Client.ComposeLogFollow(args[0], logSize, interval, printWriter{})
+		if err != nil {
+			return root.ExecutionError(cmd, "Log error: %s", err)
+		}
+		if resp != nil && !resp.Status {
+			return root.ExecutionErrors(cmd, resp.Errors)
+		}
+		if info.QueueStatus == "FAILED" {
+			return root.ExecutionError(cmd, "compose %s failed", args[0])
+		}
+		return nil
+	}
+
 	log, resp, err := root.Client.ComposeLog(args[0], logSize)
 	if err != nil {
 		return root.ExecutionError(cmd, "Log error: %s", err)
@@ -50,3 +96,15 @@ func getLog(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// followCloudLog waits until a cloud API compose is done and prints its logs
+func followCloudLog(cmd *cobra.Command, id string, interval time.Duration) error {
+	status, err := root.Cloud.ComposeLogFollow(id, interval, printWriter{})
+	if err != nil {
+		return root.ExecutionError(cmd, "Log error: %s", err)
+	}
+	if status.Status == "failure" {
+		return root.ExecutionError(cmd, "compose %s failed", id)
+	}
+	return nil
+}
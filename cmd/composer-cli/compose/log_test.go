@@ -6,8 +6,10 @@ package compose
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -68,6 +70,30 @@ And should do the job.`
 	assert.Equal(t, "512", query.Get("size"))
 }
 
+func TestCmdComposeLogNoCloud(t *testing.T) {
+	// Test that "compose log" without --follow only uses the weldr API
+	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte("A weldr log line"))),
+		}, nil
+	})
+	mcc := root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("unexpected cloud API request: %s", request.URL.Path)
+	})
+	follow = false
+
+	_, out, err := root.ExecuteTest("compose", "log", "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "A weldr log line\n", string(stdout))
+	assert.Equal(t, "/api/v1/compose/log/b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7", mc.Req.URL.Path)
+	assert.Equal(t, "", mcc.Req.Method)
+}
+
 func TestCmdComposeLogUnknown(t *testing.T) {
 	// Test the "compose log" command
 	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
@@ -138,3 +164,111 @@ func TestCmdComposeLogUnknownJSON(t *testing.T) {
 	assert.Equal(t, []byte(""), stderr)
 	assert.Equal(t, "GET", mc.Req.Method)
 }
+
+// followLogTest returns a mock server for a compose that logs a line for each status
+func followLogTest(statuses []string) func(request *http.Request) (*http.Response, error) {
+	var checks int
+	var lines []string
+	return func(request *http.Request) (*http.Response, error) {
+		var body string
+		if strings.HasPrefix(request.URL.Path, "/api/v1/compose/info/") {
+			status := statuses[min(checks, len(statuses)-1)]
+			checks++
+			body = fmt.Sprintf(`{"id": "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7", "queue_status": "%s"}`, status)
+			if status != "WAITING" {
+				lines = append(lines, fmt.Sprintf("Log line %d", len(lines)+1))
+			}
+		} else {
+			body = strings.Join(lines, "\n") + "\n"
+		}
+		return &http.Response{
+			Request:    request,
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func TestCmdComposeLogFollow(t *testing.T) {
+	root.SetupCmdTest(followLogTest([]string{"WAITING", "RUNNING", "RUNNING", "FINISHED"}))
+	follow = false
+	followPollStr = "5s"
+
+	cmd, out, err := root.ExecuteTest("compose", "log", "--follow", "--poll", "10ms", "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, logCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Log line 1\nLog line 2\nLog line 3\n", string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+}
+
+func TestCmdComposeLogFollowFailed(t *testing.T) {
+	root.SetupCmdTest(followLogTest([]string{"RUNNING", "FAILED"}))
+	follow = false
+	followPollStr = "5s"
+
+	cmd, out, err := root.ExecuteTest("compose", "log", "--follow", "--poll", "10ms", "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, logCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Log line 1\nLog line 2\n", string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "compose b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7 failed")
+}
+
+func TestCmdComposeLogCloud(t *testing.T) {
+	var checks int
+	root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		var body string
+		switch request.URL.Path {
+		case "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8":
+			status := "pending"
+			if checks > 1 {
+				status = "success"
+			}
+			checks++
+			body = `{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeStatus", "status": "` + status + `"}`
+		case "/api/image-builder-composer/v2/composes/008fc5ad-adad-42ec-b412-7923733483a8/logs":
+			body = `{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeLogs",
+"image_builds": [{"osbuild_output": {"success": true}}]}`
+		default:
+			return &http.Response{
+				Request:    request,
+				StatusCode: 404,
+				Body:       io.NopCloser(bytes.NewReader([]byte(`{"kind": "Error", "reason": "not found"}`))),
+			}, nil
+		}
+		return &http.Response{
+			Request:    request,
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	})
+	follow = false
+	followPollStr = "5s"
+
+	cmd, out, err := root.ExecuteTest("compose", "log", "--follow", "--poll", "10ms", "008fc5ad-adad-42ec-b412-7923733483a8")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, logCmd)
+	assert.Equal(t, 3, checks)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Image build 1:\n{\n    \"osbuild_output\": {\n        \"success\": true\n    }\n}\n", string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+}
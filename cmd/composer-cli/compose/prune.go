@@ -0,0 +1,216 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	pruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old composes from both APIs",
+		Long: `Delete the finished and failed composes that match all of the selections
+
+--older-than selects the composes that finished before the age, eg. 14d, 2w, or 36h.
+--keep-last keeps the N most recent composes of each blueprint, and deletes the rest.
+At least one of --older-than, --keep-last, or --blueprint must be used. Composes
+that are waiting or running are never deleted. The cloud API does not report the
+time on older servers, those composes are not deleted by --older-than and are
+kept by --keep-last. Use --dry-run to see what would be deleted.`,
+		Example: `  composer-cli compose prune --older-than 14d
+  composer-cli compose prune --status failed --older-than 2d
+  composer-cli compose prune --blueprint http-server --keep-last 3 --dry-run`,
+		RunE: pruneComposes,
+		Args: cobra.NoArgs,
+	}
+	pruneOlderThan  string
+	pruneStatus     []string
+	pruneBlueprints []string
+	pruneKeepLast   int
+	pruneDryRun     bool
+)
+
+func init() {
+	pruneCmd.Flags().StringVarP(&pruneOlderThan, "older-than", "", "", "Delete composes older than this, eg. 14d, 2w, 36h")
+	pruneCmd.Flags().StringSliceVarP(&pruneStatus, "status", "", []string{"failed", "finished"}, "Status of the composes to delete, failed, finished, or both")
+	pruneCmd.Flags().StringArrayVarP(&pruneBlueprints, "blueprint", "", nil, "Only delete composes of this blueprint, can be used more than once")
+	pruneCmd.Flags().IntVarP(&pruneKeepLast, "keep-last", "", 0, "Keep the most recent N composes of each blueprint")
+	pruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "", false, "Print the composes that would be deleted without deleting them")
+	root.EnableOutput(pruneCmd)
+	composeCmd.AddCommand(pruneCmd)
+}
+
+// parseAge parses a duration that can also use days and weeks, eg. 14d or 2w
+func parseAge(age string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, found := strings.CutSuffix(age, suffix); found {
+			i, err := strconv.Atoi(n)
+			if err != nil || i < 0 {
+				return 0, fmt.Errorf("%q is not a valid age", age)
+			}
+			return time.Duration(i) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%q is not a valid age", age)
+	}
+	return d, nil
+}
+
+// composeTime returns the most recent time reported for the compose
+// It is zero when the API does not report any times.
+func composeTime(c weldr.Compose) time.Time {
+	for _, t := range []time.Time{c.Finished, c.Started, c.Created} {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// pruneSelection is what prune should delete
+type pruneSelection struct {
+	states     []string // WAITING, RUNNING, FINISHED, FAILED
+	blueprints []string
+	olderThan  time.Duration // 0 for any age
+	keepLast   int
+	now        time.Time
+}
+
+// selectPrune returns the composes that should be deleted, oldest first
+func selectPrune(composes []weldr.Compose, sel pruneSelection) []weldr.Compose {
+	var matched []weldr.Compose
+	for _, c := range composes {
+		if !c.Done() || !slices.Contains(sel.states, c.State) {
+			continue
+		}
+		if len(sel.blueprints) > 0 && !slices.Contains(sel.blueprints, c.Blueprint) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+
+	// Newest first, composes without a time are treated as the newest so they are kept
+	sort.SliceStable(matched, func(i, j int) bool {
+		ti, tj := composeTime(matched[i]), composeTime(matched[j])
+		if ti.IsZero() || tj.IsZero() {
+			return ti.IsZero() && !tj.IsZero()
+		}
+		return ti.After(tj)
+	})
+
+	var selected []weldr.Compose
+	kept := make(map[string]int)
+	for _, c := range matched {
+		if kept[c.Blueprint] < sel.keepLast {
+			kept[c.Blueprint]++
+			continue
+		}
+		if sel.olderThan > 0 {
+			t := composeTime(c)
+			if t.IsZero() || sel.now.Sub(t) < sel.olderThan {
+				continue
+			}
+		}
+		selected = append(selected, c)
+	}
+	slices.Reverse(selected)
+	return selected
+}
+
+func pruneComposes(cmd *cobra.Command, args []string) (rcErr error) {
+	sel := pruneSelection{
+		blueprints: pruneBlueprints,
+		keepLast:   pruneKeepLast,
+		now:        time.Now(),
+	}
+	if len(pruneOlderThan) > 0 {
+		age, err := parseAge(pruneOlderThan)
+		if err != nil {
+			return root.ExecutionError(cmd, "--older-than %s", err)
+		}
+		sel.olderThan = age
+	}
+	if pruneKeepLast < 0 {
+		return root.ExecutionError(cmd, "--keep-last must be 0 or more")
+	}
+	if sel.olderThan == 0 && sel.keepLast == 0 && len(sel.blueprints) == 0 {
+		return root.ExecutionError(cmd, "prune needs at least one of --older-than, --keep-last, or --blueprint")
+	}
+	for _, s := range root.GetCommaArgs(pruneStatus) {
+		state := strings.ToUpper(s)
+		if state != "FINISHED" && state != "FAILED" {
+			return root.ExecutionError(cmd, "--status %s is not supported, use failed, finished, or both", s)
+		}
+		sel.states = append(sel.states, state)
+	}
+	if len(sel.states) == 0 {
+		return root.ExecutionError(cmd, "--status needs at least one of failed or finished")
+	}
+
+	composes, err := listComposes()
+	if err != nil {
+		return root.ExecutionError(cmd, "List Error: %s", err)
+	}
+
+	backends := make(map[string]weldr.ComposeBackend)
+	for _, b := range composeBackends() {
+		backends[b.API()] = b
+	}
+
+	records := []composeRecord{}
+	var failed int
+	for _, c := range selectPrune(composes, sel) {
+		r := newComposeRecord(c)
+		t := composeTime(c)
+		var tableTime string
+		if !t.IsZero() {
+			r.Time = t.UTC().Format(time.RFC3339)
+			tableTime = t.Format("Mon Jan 2 15:04:05 2006")
+		}
+
+		if !pruneDryRun {
+			if err := backends[c.API].Delete(c.ID); err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: Delete %s: %s\n", c.ID, err)
+				failed++
+				continue
+			}
+		}
+		records = append(records, r)
+		if root.StructuredOutput() {
+			continue
+		}
+		action := "Deleted"
+		if pruneDryRun {
+			action = "Would delete"
+		}
+		fmt.Printf("%s %s %s %s %s %s %s\n", action, r.ID, r.Status, tableTime, r.Blueprint, r.Version, strings.Join(r.Types, ","))
+	}
+
+	if root.StructuredOutput() {
+		if err := printComposeRecords(records); err != nil {
+			return root.ExecutionError(cmd, "%s", err)
+		}
+	} else if len(records) == 0 && failed == 0 {
+		fmt.Println("No composes to delete")
+	}
+	if failed > 0 {
+		return root.ExecutionError(cmd, "%d of %d composes could not be deleted", failed, failed+len(records))
+	}
+	return nil
+}
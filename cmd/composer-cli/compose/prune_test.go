@@ -0,0 +1,257 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+// resetPruneFlags sets the prune flags back to their defaults
+func resetPruneFlags() {
+	pruneOlderThan = ""
+	pruneStatus = []string{"failed", "finished"}
+	pruneBlueprints = nil
+	pruneKeepLast = 0
+	pruneDryRun = false
+	pruneCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Changed = false
+	})
+}
+
+func TestParseAge(t *testing.T) {
+	for age, d := range map[string]time.Duration{
+		"14d": 14 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"90m": 90 * time.Minute,
+		"0d":  0,
+	} {
+		parsed, err := parseAge(age)
+		require.Nil(t, err, age)
+		assert.Equal(t, d, parsed, age)
+	}
+
+	for _, age := range []string{"", "d", "-1d", "1.5d", "14", "fortnight"} {
+		_, err := parseAge(age)
+		assert.ErrorContains(t, err, "is not a valid age", age)
+	}
+}
+
+func TestSelectPrune(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) time.Time { return now.Add(-time.Duration(d) * 24 * time.Hour) }
+	composes := []weldr.Compose{
+		{ID: "old-finished", State: "FINISHED", Blueprint: "http-server", Finished: daysAgo(30)},
+		{ID: "old-failed", State: "FAILED", Blueprint: "http-server", Finished: daysAgo(20)},
+		{ID: "new-finished", State: "FINISHED", Blueprint: "http-server", Finished: daysAgo(1)},
+		{ID: "old-running", State: "RUNNING", Blueprint: "http-server", Started: daysAgo(40)},
+		{ID: "no-time", State: "FINISHED", Blueprint: "http-server"},
+		{ID: "tmux-old", State: "FINISHED", Blueprint: "tmux", Created: daysAgo(50)},
+		{ID: "tmux-new", State: "FINISHED", Blueprint: "tmux", Created: daysAgo(15)},
+	}
+	ids := func(composes []weldr.Compose) (ids []string) {
+		for _, c := range composes {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+	done := []string{"FINISHED", "FAILED"}
+
+	// The oldest are first, running composes and composes without a time are skipped
+	sel := pruneSelection{states: done, olderThan: 14 * 24 * time.Hour, now: now}
+	assert.Equal(t, []string{"tmux-old", "old-finished", "old-failed", "tmux-new"}, ids(selectPrune(composes, sel)))
+
+	sel = pruneSelection{states: []string{"FAILED"}, olderThan: 14 * 24 * time.Hour, now: now}
+	assert.Equal(t, []string{"old-failed"}, ids(selectPrune(composes, sel)))
+
+	// Composes without a time are the most recent, so they are kept
+	sel = pruneSelection{states: done, keepLast: 2, now: now}
+	assert.Equal(t, []string{"old-finished", "old-failed"}, ids(selectPrune(composes, sel)))
+
+	sel = pruneSelection{states: done, blueprints: []string{"tmux"}, keepLast: 1, olderThan: 20 * 24 * time.Hour, now: now}
+	assert.Equal(t, []string{"tmux-old"}, ids(selectPrune(composes, sel)))
+
+	sel = pruneSelection{states: done, blueprints: []string{"tmux"}, now: now}
+	assert.Equal(t, []string{"tmux-old", "tmux-new"}, ids(selectPrune(composes, sel)))
+}
+
+// pruneTest has old composes on both APIs and records the ones that are deleted
+type pruneTest struct {
+	sync.Mutex
+	deleted []string
+	failIDs map[string]bool // Deleting these composes fails
+}
+
+func (p *pruneTest) weldr(request *http.Request) (*http.Response, error) {
+	old := float64(time.Now().Add(-30 * 24 * time.Hour).Unix())
+	recent := float64(time.Now().Add(-time.Hour).Unix())
+	path := request.URL.Path
+	switch {
+	case path == "/api/v1/compose/queue":
+		return mockResponse(request, 200, fmt.Sprintf(`{"new": [], "run": [{"id": "aaaaaaaa-1ffa-4de6-95ed-42749ac1f389",
+"blueprint": "http-server", "version": "0.0.1", "compose_type": "qcow2", "queue_status": "RUNNING",
+"job_created": %f, "job_started": %f}]}`, old, old)), nil
+	case path == "/api/v1/compose/finished":
+		return mockResponse(request, 200, fmt.Sprintf(`{"finished": [
+{"id": "bbbbbbbb-1ffa-4de6-95ed-42749ac1f389", "blueprint": "http-server", "version": "0.0.1",
+ "compose_type": "qcow2", "queue_status": "FINISHED", "job_created": %f, "job_finished": %f},
+{"id": "cccccccc-1ffa-4de6-95ed-42749ac1f389", "blueprint": "http-server", "version": "0.0.2",
+ "compose_type": "qcow2", "queue_status": "FINISHED", "job_created": %f, "job_finished": %f}]}`, old, old, recent, recent)), nil
+	case path == "/api/v1/compose/failed":
+		return mockResponse(request, 200, fmt.Sprintf(`{"failed": [
+{"id": "dddddddd-1ffa-4de6-95ed-42749ac1f389", "blueprint": "tmux", "version": "1.0.0",
+ "compose_type": "ami", "queue_status": "FAILED", "job_created": %f, "job_finished": %f}]}`, old, old)), nil
+	case strings.HasPrefix(path, "/api/v1/compose/delete/"):
+		id := path[strings.LastIndex(path, "/")+1:]
+		if p.failIDs[id] {
+			return mockResponse(request, 200, fmt.Sprintf(`{"uuids": [], "errors": [{"id": "ComposeError", "msg": "%s is busy"}]}`, id)), nil
+		}
+		p.Lock()
+		p.deleted = append(p.deleted, id)
+		p.Unlock()
+		return mockResponse(request, 200, fmt.Sprintf(`{"uuids": [{"uuid": "%s", "status": true}], "errors": []}`, id)), nil
+	}
+	return mockResponse(request, 404, `{"status": false, "errors": [{"id": "HTTPError", "msg": "Not Found"}]}`), nil
+}
+
+func (p *pruneTest) cloud(request *http.Request) (*http.Response, error) {
+	old := time.Now().Add(-20 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	path := request.URL.Path
+	switch {
+	case path == "/api/image-builder-composer/v2/composes/":
+		return mockResponse(request, 200, fmt.Sprintf(`[
+{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeStatus", "status": "success", "finished_at": "%s"},
+{"id": "11111111-adad-42ec-b412-7923733483a8", "kind": "ComposeStatus", "status": "success"}]`, old)), nil
+	case request.Method == "DELETE":
+		p.Lock()
+		p.deleted = append(p.deleted, path[strings.LastIndex(path, "/")+1:])
+		p.Unlock()
+		return mockResponse(request, 200, `{"kind": "ComposeDeleteStatus"}`), nil
+	}
+	return mockResponse(request, 404, `{"kind": "Error", "reason": "Compose not found"}`), nil
+}
+
+func TestCmdComposePrune(t *testing.T) {
+	p := &pruneTest{}
+	root.SetupCmdTest(p.weldr)
+	root.SetupCloudCmdTest(p.cloud)
+	resetPruneFlags()
+
+	cmd, out, err := root.ExecuteTest("compose", "prune", "--older-than", "14d")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, pruneCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Regexp(t, `Deleted bbbbbbbb-1ffa-4de6-95ed-42749ac1f389 FINISHED .* http-server 0.0.1 qcow2\n`, string(stdout))
+	assert.Regexp(t, `Deleted dddddddd-1ffa-4de6-95ed-42749ac1f389 FAILED .* tmux 1.0.0 ami\n`, string(stdout))
+	assert.Regexp(t, `Deleted 008fc5ad-adad-42ec-b412-7923733483a8 FINISHED .*\n`, string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(stderr))
+
+	// Running, recent, and cloud composes without a time are not deleted
+	assert.ElementsMatch(t, []string{
+		"bbbbbbbb-1ffa-4de6-95ed-42749ac1f389",
+		"dddddddd-1ffa-4de6-95ed-42749ac1f389",
+		"008fc5ad-adad-42ec-b412-7923733483a8",
+	}, p.deleted)
+}
+
+func TestCmdComposePruneDryRun(t *testing.T) {
+	p := &pruneTest{}
+	root.SetupCmdTest(p.weldr)
+	root.SetupCloudCmdTest(p.cloud)
+	resetPruneFlags()
+
+	cmd, out, err := root.ExecuteTest("compose", "prune", "--blueprint", "http-server", "--keep-last", "1", "--dry-run")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Regexp(t, `^Would delete bbbbbbbb-1ffa-4de6-95ed-42749ac1f389 FINISHED .* http-server 0.0.1 qcow2\n$`, string(stdout))
+	assert.Nil(t, p.deleted)
+}
+
+func TestCmdComposePruneOutput(t *testing.T) {
+	p := &pruneTest{}
+	root.SetupCmdTest(p.weldr)
+	root.SetupCloudCmdTest(p.cloud)
+	resetPruneFlags()
+
+	cmd, out, err := root.ExecuteTest("compose", "prune", "--status", "failed", "--older-than", "2w",
+		"--output", `template={{range .}}{{.id}} {{.api}}{{"\n"}}{{end}}`)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "dddddddd-1ffa-4de6-95ed-42749ac1f389 weldr\n", string(stdout))
+	assert.Equal(t, []string{"dddddddd-1ffa-4de6-95ed-42749ac1f389"}, p.deleted)
+}
+
+func TestCmdComposePruneDeleteError(t *testing.T) {
+	p := &pruneTest{failIDs: map[string]bool{"dddddddd-1ffa-4de6-95ed-42749ac1f389": true}}
+	root.SetupCmdTest(p.weldr)
+	root.SetupCloudCmdTest(p.cloud)
+	resetPruneFlags()
+
+	cmd, out, err := root.ExecuteTest("compose", "prune", "--older-than", "14d")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "Deleted bbbbbbbb-1ffa-4de6-95ed-42749ac1f389")
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: Delete dddddddd-1ffa-4de6-95ed-42749ac1f389: ComposeError: dddddddd-1ffa-4de6-95ed-42749ac1f389 is busy\n")
+	assert.Contains(t, string(stderr), "ERROR: 1 of 3 composes could not be deleted\n")
+}
+
+func TestCmdComposePruneErrors(t *testing.T) {
+	p := &pruneTest{}
+	root.SetupCmdTest(p.weldr)
+	root.SetupCloudCmdTest(p.cloud)
+
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{}, "prune needs at least one of --older-than, --keep-last, or --blueprint"},
+		{[]string{"--older-than", "soon"}, `--older-than "soon" is not a valid age`},
+		{[]string{"--keep-last", "-1"}, "--keep-last must be 0 or more"},
+		{[]string{"--older-than", "1d", "--status", "running"}, "--status running is not supported"},
+	} {
+		resetPruneFlags()
+		_, out, err := root.ExecuteTest(append([]string{"compose", "prune"}, tc.args...)...)
+		require.NotNil(t, out)
+		require.NotNil(t, err, tc.args)
+		stderr, rerr := io.ReadAll(out.Stderr)
+		assert.Nil(t, rerr)
+		assert.Contains(t, string(stderr), tc.err)
+		out.Close()
+	}
+	assert.Nil(t, p.deleted)
+}
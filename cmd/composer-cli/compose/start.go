@@ -7,7 +7,6 @@ package compose
 import (
 	"errors"
 	"fmt"
-	"io"
 	"io/fs"
 	"os"
 	"time"
@@ -15,6 +14,7 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/spf13/cobra"
 
+	"github.com/osbuild/weldr-client/v2/cloud"
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
 	"github.com/osbuild/weldr-client/v2/weldr"
 )
@@ -23,34 +23,62 @@ var (
 	startCmd = &cobra.Command{
 		Use:   "start BLUEPRINT TYPE [IMAGE-NAME PROFILE.TOML]",
 		Short: "Start a compose using the selected blueprint and output type",
-		Long:  "Start a compose using the selected blueprint and output type. Optionally start an upload. --size is supported by osbuild-composer, and is in MiB",
-		RunE:  start,
+		Long: `Start a compose using the selected blueprint and output type. Optionally start an upload. --size is supported by osbuild-composer, and is in MiB
+
+  Use --type to build several output types, the TYPE argument is then left out. A local
+  blueprint file builds all of the types in one cloud API compose, a blueprint on the server
+  starts one compose for each type, they are all started before --wait waits for them.
+
+  --distro and --arch select the distribution and architecture to build when using a local
+  blueprint file, they default to the profile's settings or the host's.
+
+  --repo adds a repository to a local blueprint compose, it uses the same TOML format as
+  'sources add' and can be used more than once. The repositories are used for the blueprint's
+  packages in addition to the distribution's default repositories.`,
+		RunE: start,
 		Example: `  composer-cli compose start tmux-image qcow2
   composer-cli compose start tmux-image qcow2 --size 4096
-  composer-cli compose start tmux-image ami ami-name aws-upload.toml`,
+  composer-cli compose start tmux-image ami ami-name aws-upload.toml
+  composer-cli compose start --type qcow2,ami,vmdk tmux-image.toml
+  composer-cli compose start --distro rhel-9.4 --arch aarch64 tmux-image.toml qcow2
+  composer-cli compose start --repo custom.toml tmux-image.toml qcow2`,
 		Args: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 2 || len(args) == 4 {
+			if len(typeList) > 0 && (len(args) == 1 || len(args) == 3) {
+				return nil
+			} else if len(typeList) == 0 && (len(args) == 2 || len(args) == 4) {
 				return nil
 			}
 			return errors.New("Invalid number of arguments")
 		},
 	}
-	size uint
+	size      uint
+	typeList  string
+	repoFiles []string // Defined here, used by start and start-ostree
 )
 
 func init() {
 	startCmd.Flags().UintVarP(&size, "size", "", 0, "Size of image in MiB")
+	startCmd.Flags().StringVarP(&typeList, "type", "", "", "Comma separated list of output types to build")
+	// distro and arch are defined in types.go
+	startCmd.Flags().StringVarP(&distro, "distro", "", "", "Distribution to build, requires a local blueprint")
+	startCmd.Flags().StringVarP(&arch, "arch", "", "", "Architecture to build, requires a local blueprint")
+	startCmd.Flags().StringArrayVarP(&repoFiles, "repo", "", nil, "Source TOML file with an extra repository to use, requires a local blueprint")
 	startCmd.Flags().BoolVarP(&wait, "wait", "", false, "Wait for compose to finish")
 	startCmd.Flags().StringVarP(&timeoutStr, "timeout", "", "5m", "Maximum time to wait")
 	startCmd.Flags().StringVarP(&pollStr, "poll", "", "10s", "Polling interval")
 	composeCmd.AddCommand(startCmd)
 }
 
-func start(cmd *cobra.Command, args []string) error {
-	var resp *weldr.APIResponse
-	var uuid string
-	var err error
+// startTypes returns the output types to build and the optional upload arguments
+// The types come from --type if it is used, otherwise from the TYPE argument
+func startTypes(args []string) ([]string, []string) {
+	if len(typeList) > 0 {
+		return root.GetCommaArgs([]string{typeList}), args[1:]
+	}
+	return []string{args[1]}, args[2:]
+}
 
+func start(cmd *cobra.Command, args []string) error {
 	timeout, err := time.ParseDuration(timeoutStr)
 	if err != nil {
 		return root.ExecutionError(cmd, "Wait Error: timeout - %s", err)
@@ -60,104 +88,303 @@ func start(cmd *cobra.Command, args []string) error {
 		return root.ExecutionError(cmd, "Wait Error: poll - %s", err)
 	}
 
+	composeTypes, uploadArgs := startTypes(args)
+	if len(composeTypes) == 0 {
+		return root.ExecutionError(cmd, "missing output type")
+	}
+
 	// Is the blueprint a local file? If so, try to use the cloud API for the compose
-	f, err := os.Open(args[0])
-	if err == nil {
-		defer f.Close() //nolint:errcheck
+	blueprint, isLocal, err := readLocalBlueprint(args[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "%s", err)
+	}
+	if isLocal {
+		// Without upload arguments the images are saved locally, otherwise they are
+		// uploaded to the target described by the profile, named IMAGE-NAME
+		upload := cloud.NewLocalImageRequest("", size)
+		if len(uploadArgs) == 2 {
+			upload, err = readUploadImage(uploadArgs[1], uploadArgs[0], size)
+			if err != nil {
+				return root.ExecutionError(cmd, "%s", err)
+			}
+		}
+
+		var images []cloud.ImageRequestV1
+		for _, t := range composeTypes {
+			image := upload
+			image.ImageType = t
+			images = append(images, image)
+		}
+
+		return startCloudCompose(cmd, blueprint, images, timeout, interval)
+	}
 
-		if !root.Cloud.Exists() {
-			return root.ExecutionError(cmd, "Using a local blueprint requires server support. Check to make sure that the cloudapi socket is enabled.")
+	if err := checkLocalOnlyFlags(); err != nil {
+		return root.ExecutionError(cmd, "%s", err)
+	}
+	// The weldr API builds one image per compose. They are all started before waiting for
+	// any of them, and an error starting one type does not stop the others.
+	var uuids []string
+	var failed bool
+	for _, t := range composeTypes {
+		prefix := ""
+		if len(composeTypes) > 1 {
+			prefix = t + ": "
 		}
+		uuid, ok := startWeldrCompose(args[0], t, uploadArgs, prefix)
+		if !ok {
+			failed = true
+			continue
+		}
+		uuids = append(uuids, uuid)
+	}
+
+	if wait && len(uuids) > 0 {
+		if !waitForWeldrComposes(uuids, timeout, interval) {
+			failed = true
+		}
+	}
+	if failed {
+		// The errors have already been printed
+		return root.ExecutionError(cmd, "")
+	}
+	return nil
+}
+
+// readLocalBlueprint reads a blueprint from a local TOML file
+// If the file does not exist it returns false, the name is then used as a blueprint on
+// the server. Using a local blueprint requires the cloud API.
+func readLocalBlueprint(path string) (interface{}, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	} else if err != nil {
+		// File exists, but there was an error reading it
+		return nil, false, fmt.Errorf("reading %s - %s", path, err)
+	}
+
+	if !root.Cloud.Exists() {
+		return nil, false, fmt.Errorf("Using a local blueprint requires server support. Check to make sure that the cloudapi socket is enabled.")
+	}
 
-		data, err := io.ReadAll(f)
+	var blueprint interface{}
+	err = toml.Unmarshal(data, &blueprint)
+	if err != nil {
+		return nil, false, fmt.Errorf("reading %s - %s", path, err)
+	}
+	return blueprint, true, nil
+}
+
+// checkLocalOnlyFlags returns an error if flags that need a local blueprint were used
+func checkLocalOnlyFlags() error {
+	if len(distro) > 0 || len(arch) > 0 {
+		return fmt.Errorf("--distro and --arch are only supported with a local blueprint file")
+	}
+	if len(repoFiles) > 0 {
+		return fmt.Errorf("--repo is only supported with a local blueprint file, use 'sources add' to add it to the server")
+	}
+	return nil
+}
+
+// readRepositories reads the source files and converts them to cloud API repositories
+// Sources that list distros must include the distribution being built.
+func readRepositories(repoFiles []string, distro string) ([]cloud.RepositoryV1, error) {
+	var repos []cloud.RepositoryV1
+	for _, path := range repoFiles {
+		data, err := os.ReadFile(path)
 		if err != nil {
-			return root.ExecutionError(cmd, "reading %s - %s", args[0], err)
+			return nil, fmt.Errorf("reading %s - %s", path, err)
 		}
-		var blueprint interface{}
-		err = toml.Unmarshal([]byte(data), &blueprint)
+		source, err := cloud.ParseSourceTOML(data)
 		if err != nil {
-			return root.ExecutionError(cmd, "reading %s - %s", args[0], err)
+			return nil, fmt.Errorf("reading %s - %s", path, err)
 		}
+		if !source.SupportsDistro(distro) {
+			return nil, fmt.Errorf("source %s does not support %s", source, distro)
+		}
+		repo, err := source.Repository()
+		if err != nil {
+			return nil, fmt.Errorf("%s - %s", path, err)
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
 
-		// Start the cloud API compose
-		// 2 args is saved locally, 4 is uploaded to the specified service
-		if len(args) == 2 {
-			uuid, err = root.Cloud.StartCompose(blueprint, args[1], size)
-		} else if len(args) == 4 {
-			// Read the upload options from the file
-			f, err = os.Open(args[3])
-			if err != nil {
-				return root.ExecutionError(cmd, "reading %s - %s", args[3], err)
-			}
-			data, err = io.ReadAll(f)
-			if err != nil {
-				return root.ExecutionError(cmd, "reading %s - %s", args[3], err)
-			}
-			var uploadOptions interface{}
-			err = toml.Unmarshal([]byte(data), &uploadOptions)
-			if err != nil {
-				return root.ExecutionError(cmd, "reading %s - %s", args[3], err)
-			}
-
-			uuid, err = root.Cloud.StartComposeUpload(blueprint, args[1], args[2], uploadOptions, nil, size)
+// readUploadImage reads the upload TOML file and returns an image request that uses it
+// The ImageType is not set, it is filled in for each of the types being built.
+// Files with a type and upload_options are checked, and an error names the first missing
+// required key. The imageName is used to name the uploaded image when the type supports
+// it. Files in the older provider and settings format are passed to the server as-is.
+func readUploadImage(path, imageName string, size uint) (cloud.ImageRequestV1, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cloud.ImageRequestV1{}, fmt.Errorf("reading %s - %s", path, err)
+	}
+	target, err := cloud.ParseUploadTargetTOML(data)
+	if errors.Is(err, cloud.ErrProviderUpload) {
+		var uploadOptions interface{}
+		if err := toml.Unmarshal(data, &uploadOptions); err != nil {
+			return cloud.ImageRequestV1{}, fmt.Errorf("reading %s - %s", path, err)
+		}
+		if len(imageName) > 0 {
+			fmt.Printf("Warning: %s uses the provider and settings format, the image name %s is not used\n", path, imageName)
 		}
+		return cloud.ImageRequestV1{Size: uint64(size) * 1024 * 1024, UploadOptions: uploadOptions}, nil
+	} else if err != nil {
+		return cloud.ImageRequestV1{}, fmt.Errorf("reading %s - %s", path, err)
+	}
+
+	if len(imageName) > 0 {
+		named, err := target.WithImageName(imageName)
 		if err != nil {
-			return root.ExecutionError(cmd, "starting cloud API compose: %s", err)
+			fmt.Printf("Warning: %s, the image name %s is not used\n", err, imageName)
+		} else {
+			target = named
 		}
-		fmt.Printf("Compose %s added to the queue\n", uuid)
+	}
+	return cloud.NewUploadImageRequest("", size, target), nil
+}
 
-		if wait {
-			fmt.Printf("Waiting %v for compose to finish\n", timeout)
-			aborted, status, err := root.Cloud.ComposeWait(uuid, timeout, interval)
-			if err != nil {
-				return root.ExecutionError(cmd, "%s", err)
-			}
-			if aborted {
-				return root.ExecutionError(cmd, "timeout after %v", timeout)
-			}
+// startCloudCompose starts a compose of a local blueprint using the cloud API
+// The images are built for the --distro and --arch, if they are not set the profile's
+// defaults are used, and then the host's. When they are selected they are checked against
+// the server's list of supported image types before starting the compose.
+// The --repo sources, if any, are added to the default repositories for all of the images.
+// If --wait was used it waits for the compose to finish.
+func startCloudCompose(cmd *cobra.Command, blueprint interface{}, images []cloud.ImageRequestV1, timeout, interval time.Duration) error {
+	request, err := newCloudComposeRequest(blueprint, images, distro, arch, repoFiles)
+	if err != nil {
+		return root.ExecutionError(cmd, "%s", err)
+	}
+	uuid, err := root.Cloud.StartComposeRequest(request)
+	if err != nil {
+		return root.ExecutionError(cmd, "starting cloud API compose: %s", err)
+	}
+	fmt.Printf("Compose %s added to the queue\n", uuid)
 
-			fmt.Printf("%s %s\n", uuid, status.Status)
+	if wait {
+		fmt.Printf("Waiting %v for compose to finish\n", timeout)
+		aborted, status, err := root.Cloud.ComposeWait(uuid, timeout, interval)
+		if err != nil {
+			return root.ExecutionError(cmd, "%s", err)
 		}
-	} else if !errors.Is(err, fs.ErrNotExist) {
-		// File exists, but there was an error opening it
-		return root.ExecutionError(cmd, "reading %s - %s", args[0], err)
-	} else {
-		// 2 args is saved locally, 4 is uploaded to the specified service
-		if len(args) == 2 {
-			uuid, resp, err = root.Client.StartCompose(args[0], args[1], size)
-		} else if len(args) == 4 {
-			uuid, resp, err = root.Client.StartComposeUpload(args[0], args[1], args[2], args[3], size)
+		if aborted {
+			return root.ExecutionError(cmd, "timeout after %v", timeout)
 		}
-		if err != nil {
-			return root.ExecutionError(cmd, "starting compose: %s", err)
+
+		fmt.Printf("%s %s\n", uuid, status.Status)
+	}
+
+	return nil
+}
+
+// newCloudComposeRequest returns a cloud API compose request for the images
+// distro and arch default to the profile's settings, and then the host's. When they are
+// selected they are checked against the server's list of supported image types. The
+// repositories from repoFiles, if any, are sent as payload repositories so that they are
+// used in addition to the distribution's default repositories.
+func newCloudComposeRequest(blueprint interface{}, images []cloud.ImageRequestV1, distro, arch string, repoFiles []string) (cloud.ComposeRequestV1, error) {
+	distroName, err := root.GetDistro(distro)
+	if err != nil {
+		return cloud.ComposeRequestV1{}, err
+	}
+	archName := root.GetArch(arch)
+
+	if len(root.DistroOrDefault(distro)) > 0 || len(root.ArchOrDefault(arch)) > 0 {
+		var composeTypes []string
+		for _, image := range images {
+			composeTypes = append(composeTypes, image.ImageType)
 		}
-		if resp != nil {
-			// Response may be just warnings, just error, or both.
-			for _, w := range resp.Warnings {
-				fmt.Printf("Warning: %s\n", w)
-			}
-			if !resp.Status {
-				return root.ExecutionErrors(cmd, resp.Errors)
-			}
+		if err := root.Cloud.CheckComposeTypes(distroName, archName, composeTypes); err != nil {
+			return cloud.ComposeRequestV1{}, err
 		}
-		fmt.Printf("Compose %s added to the queue\n", uuid)
+	}
 
-		if wait {
-			fmt.Printf("Waiting %v for compose to finish\n", timeout)
-			aborted, info, resp, err := root.Client.ComposeWait(uuid, timeout, interval)
-			if err != nil {
-				return root.ExecutionError(cmd, "Wait: %s", err)
-			}
-			if resp != nil {
-				return root.ExecutionErrors(cmd, resp.Errors)
-			}
-			if aborted {
-				return root.ExecutionError(cmd, "Wait: timeout after %v", timeout)
-			}
+	repos, err := readRepositories(repoFiles, distroName)
+	if err != nil {
+		return cloud.ComposeRequestV1{}, err
+	}
+
+	for i := range images {
+		images[i].Architecture = archName
+	}
+	request := cloud.ComposeRequestV1{
+		Distribution:  distroName,
+		Blueprint:     blueprint,
+		ImageRequests: images,
+	}
+	if len(repos) > 0 {
+		request.Customizations = &cloud.ComposeCustomizationsV1{PayloadRepositories: repos}
+	}
+	return request, nil
+}
+
+// startWeldrCompose starts a compose of a blueprint on the server using the weldr API
+// Without upload arguments the image is saved locally, with an image name and profile
+// it is uploaded. Errors are printed with the prefix, and it returns false if the
+// compose was not started.
+func startWeldrCompose(blueprint, composeType string, uploadArgs []string, prefix string) (string, bool) {
+	var resp *weldr.APIResponse
+	var uuid string
+	var err error
 
-			fmt.Printf("%s %s\n", info.ID, info.QueueStatus)
+	if len(uploadArgs) == 2 {
+		uuid, resp, err = root.Client.StartComposeUpload(blueprint, composeType, uploadArgs[0], uploadArgs[1], size)
+	} else {
+		uuid, resp, err = root.Client.StartCompose(blueprint, composeType, size)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %sstarting compose: %s\n", prefix, err)
+		return "", false
+	}
+	if resp != nil {
+		// Response may be just warnings, just error, or both.
+		for _, w := range resp.Warnings {
+			fmt.Printf("Warning: %s%s\n", prefix, w)
+		}
+		if !resp.Status {
+			// When JSON output is enabled the errors are in the JSON
+			if !root.JSONOutput {
+				for _, e := range resp.Errors {
+					fmt.Fprintf(os.Stderr, "ERROR: %s%s\n", prefix, e)
+				}
+			}
+			return "", false
 		}
 	}
+	fmt.Printf("Compose %s added to the queue\n", uuid)
+	return uuid, true
+}
 
-	return nil
+// waitForWeldrComposes waits for the started composes to finish
+// Each compose is printed with its status when it is done, errors and timeouts are
+// printed for each compose. It returns false if there were any errors or timeouts.
+func waitForWeldrComposes(uuids []string, timeout, interval time.Duration) bool {
+	if len(uuids) == 1 {
+		fmt.Printf("Waiting %v for compose to finish\n", timeout)
+	} else {
+		fmt.Printf("Waiting %v for %d composes to finish\n", timeout, len(uuids))
+	}
+	ok := true
+	var cancelled bool
+	for r := range waitForComposes(uuids, timeout, interval, maxRequests) {
+		switch {
+		case r.cancelled:
+			cancelled = true
+			ok = false
+		case r.err != nil:
+			fmt.Fprintf(os.Stderr, "ERROR: Wait: %s: %s\n", r.id, r.err)
+			ok = false
+		case r.timeout:
+			fmt.Fprintf(os.Stderr, "ERROR: Wait: %s: timeout after %v\n", r.id, timeout)
+			ok = false
+		default:
+			fmt.Printf("%s %s\n", r.id, r.status)
+		}
+	}
+	if cancelled {
+		fmt.Fprintf(os.Stderr, "ERROR: Wait: cancelled\n")
+	}
+	return ok
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/osbuild/weldr-client/v2/cloud"
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
 	"github.com/osbuild/weldr-client/v2/weldr"
 )
@@ -25,11 +26,17 @@ var (
 
   The full details of the start-ostree command can be viewed here:
   https://osbuild.org/docs/on-premises/commandline/building-ostree-images
+
+  When BLUEPRINT is a local file, ending in .toml or including a path, the compose is started using the cloud API, and
+  --distro and --arch can be used to select the distribution and architecture to build.
+  --repo adds the repository from a 'sources add' TOML file to the distribution's default
+  repositories, it can be used more than once.
 `,
 		Example: `  composer-cli compose start-ostree tmux-image fedora-iot-container
   composer-cli compose start-ostree tmux-image fedora-iot-container iot-name upload.toml
   composer-cli compose start-ostree --ref "rhel/edge/example" tmux-image fedora-iot-container
-  composer-cli compose start-ostree --ref "rhel/edge/example" --url http://10.0.2.2:8080/repo/ empty fedora-iot-installer`,
+  composer-cli compose start-ostree --ref "rhel/edge/example" --url http://10.0.2.2:8080/repo/ empty fedora-iot-installer
+  composer-cli compose start-ostree --distro rhel-9.4 --arch aarch64 --ref "rhel/edge/example" tmux-image.toml edge-commit`,
 		RunE: startOSTree,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 2 || len(args) == 4 {
@@ -50,6 +57,10 @@ func init() {
 	startOSTreeCmd.Flags().StringVarP(&ref, "ref", "", "", "OSTree reference")
 	startOSTreeCmd.Flags().StringVarP(&parent, "parent", "", "", "OSTree parent")
 	startOSTreeCmd.Flags().StringVarP(&url, "url", "", "", "OSTree url")
+	// distro and arch are defined in types.go
+	startOSTreeCmd.Flags().StringVarP(&distro, "distro", "", "", "Distribution to build, requires a local blueprint")
+	startOSTreeCmd.Flags().StringVarP(&arch, "arch", "", "", "Architecture to build, requires a local blueprint")
+	startOSTreeCmd.Flags().StringArrayVarP(&repoFiles, "repo", "", nil, "Source TOML file with an extra repository to use, requires a local blueprint")
 	startOSTreeCmd.Flags().BoolVarP(&wait, "wait", "", false, "Wait for compose to finish")
 	startOSTreeCmd.Flags().StringVarP(&timeoutStr, "timeout", "", "5m", "Maximum time to wait")
 	startOSTreeCmd.Flags().StringVarP(&pollStr, "poll", "", "10s", "Polling interval")
@@ -70,6 +81,40 @@ func startOSTree(cmd *cobra.Command, args []string) error {
 		return root.ExecutionError(cmd, "Wait Error: poll - %s", err)
 	}
 
+	// Is the blueprint a local file? If so, try to use the cloud API for the compose
+	// Only names that look like a file are checked, so that a file in the current
+	// directory with the same name as a blueprint on the server is not used.
+	var blueprint interface{}
+	var isLocal bool
+	if root.IsBlueprintFile(args[0]) {
+		blueprint, isLocal, err = readLocalBlueprint(args[0])
+		if err != nil {
+			return root.ExecutionError(cmd, "%s", err)
+		}
+	}
+	if isLocal {
+		// 2 args is saved locally, 4 is uploaded to the specified service
+		var image cloud.ImageRequestV1
+		if len(args) == 4 {
+			image, err = readUploadImage(args[3], args[2], size)
+			if err != nil {
+				return root.ExecutionError(cmd, "%s", err)
+			}
+			image.ImageType = args[1]
+		} else {
+			image = cloud.NewLocalImageRequest(args[1], size)
+		}
+		if len(ref) > 0 || len(parent) > 0 || len(url) > 0 {
+			image.OSTree = &cloud.OSTreeV1{URL: url, Ref: ref, Parent: parent}
+		}
+
+		return startCloudCompose(cmd, blueprint, []cloud.ImageRequestV1{image}, timeout, interval)
+	}
+
+	if err := checkLocalOnlyFlags(); err != nil {
+		return root.ExecutionError(cmd, "%s", err)
+	}
+
 	// 2 args is uploads
 	if len(args) == 2 {
 		uuid, resp, err = root.Client.StartOSTreeCompose(args[0], args[1], ref, parent, url, size)
@@ -543,3 +543,82 @@ Compose 876b2946-16cd-4f38-bace-0cdd0093d112 added to the queue
 	assert.Equal(t, "application/json", mc.Req.Header.Get("Content-Type"))
 	assert.Equal(t, "/api/v1/compose", mc.Req.URL.Path)
 }
+
+func TestCmdComposeStartOSTreeLocalBPDistro(t *testing.T) {
+	// Test the "compose start-ostree" command with a local blueprint, distro, and arch
+	mcc := root.SetupCloudCmdTest(distroMatrixTest())
+
+	tmpBP, err := os.CreateTemp("", "test-bp-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBP.Name()) //nolint:errcheck
+	_, err = tmpBP.Write([]byte(`name = "test bp"
+version = "1.1.0"
+`))
+	require.Nil(t, err)
+
+	// Make sure the optional command values are reset to their defaults
+	size = 0
+	ref = ""
+	parent = ""
+	url = ""
+	defer func() {
+		distro = ""
+		arch = ""
+		ref = ""
+	}()
+
+	cmd, out, err := root.ExecuteTest("compose", "start-ostree", "--distro", "rhel-9.4", "--arch", "aarch64",
+		"--ref", "rhel/9/aarch64/edge", tmpBP.Name(), "edge-commit")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, startOSTreeCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Compose 008fc5ad-adad-42ec-b412-7923733483a8 added to the queue\n", string(stdout))
+	assert.Equal(t, "POST", mcc.Req.Method)
+	assert.Equal(t, "/api/image-builder-composer/v2/compose", mcc.Req.URL.Path)
+	sentBody, err := io.ReadAll(mcc.Req.Body)
+	assert.Nil(t, mcc.Req.Body.Close())
+	require.Nil(t, err)
+	assert.Contains(t, string(sentBody), `"distribution":"rhel-9.4"`)
+	assert.Contains(t, string(sentBody), `"architecture":"aarch64","image_type":"edge-commit"`)
+	assert.Contains(t, string(sentBody), `"ostree":{"ref":"rhel/9/aarch64/edge"}`)
+}
+
+func TestCmdComposeStartOSTreeServerBPFile(t *testing.T) {
+	// A file in the current directory with the same name as the blueprint is not used
+	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{
+			"build_id": "876b2946-16cd-4f38-bace-0cdd0093d112",
+			"status": true
+}`
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	cwd, err := os.Getwd()
+	require.Nil(t, err)
+	tmpdir := t.TempDir()
+	require.Nil(t, os.Chdir(tmpdir))
+	defer os.Chdir(cwd) //nolint:errcheck
+	require.Nil(t, os.WriteFile("http-server", []byte("name = \"http-server\"\n"), 0600))
+
+	size = 0
+	ref = ""
+	parent = ""
+	url = ""
+
+	_, out, err := root.ExecuteTest("compose", "start-ostree", "http-server", "qcow2")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Compose 876b2946-16cd-4f38-bace-0cdd0093d112 added to the queue\n", string(stdout))
+	assert.Equal(t, "/api/v1/compose", mc.Req.URL.Path)
+}
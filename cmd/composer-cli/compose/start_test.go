@@ -6,14 +6,17 @@ package compose
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"net/http"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/osbuild/weldr-client/v2/cloud"
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
 )
 
@@ -381,7 +384,8 @@ key = "OBJECT_KEY"`))
 	require.NotNil(t, out.Stderr)
 	stdout, err := io.ReadAll(out.Stdout)
 	assert.Nil(t, err)
-	assert.Equal(t, "Compose 008fc5ad-adad-42ec-b412-7923733483a8 added to the queue\n", string(stdout))
+	assert.Equal(t, "Warning: "+tmpUpload.Name()+" uses the provider and settings format, the image name test-ami is not used\n"+
+		"Compose 008fc5ad-adad-42ec-b412-7923733483a8 added to the queue\n", string(stdout))
 	stderr, err := io.ReadAll(out.Stderr)
 	assert.Nil(t, err)
 	assert.Equal(t, []byte(""), stderr)
@@ -394,3 +398,480 @@ key = "OBJECT_KEY"`))
 	assert.Equal(t, "application/json", mcc.Req.Header.Get("Content-Type"))
 	assert.Equal(t, "/api/image-builder-composer/v2/compose", mcc.Req.URL.Path)
 }
+
+func TestCmdComposeStartLocalBPUploadTarget(t *testing.T) {
+	// Test the "compose start" command with a local blueprint file and upload target
+	// The IMAGE-NAME is used as the name of the uploaded image
+	mcc := root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"href": "/api/image-builder-composer/v2/compose", "id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeId"}`
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	// Need a temporary test file for the blueprint
+	tmpBP, err := os.CreateTemp("", "test-bp-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBP.Name()) //nolint:errcheck
+
+	_, err = tmpBP.Write([]byte(`name = "test bp"
+version = "1.1.0"
+[[packages]]
+name = "tmux"
+version = "3.5a"
+`))
+	require.Nil(t, err)
+
+	// Need a temporary test file for the upload
+	tmpUpload, err := os.CreateTemp("", "test-upload-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpUpload.Name()) //nolint:errcheck
+
+	_, err = tmpUpload.Write([]byte(`type = "aws"
+[upload_options]
+region = "AWS_REGION"`))
+	require.Nil(t, err)
+
+	// Make sure the compose.size value is reset to default
+	size = 0
+
+	// Start a compose
+	cmd, out, err := root.ExecuteTest("compose", "start", tmpBP.Name(), "ami", "test-ami", tmpUpload.Name())
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, startCmd)
+	require.NotNil(t, out.Stdout)
+	require.NotNil(t, out.Stderr)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Compose 008fc5ad-adad-42ec-b412-7923733483a8 added to the queue\n", string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+	assert.Equal(t, "POST", mcc.Req.Method)
+	sentBody, err := io.ReadAll(mcc.Req.Body)
+	assert.Nil(t, mcc.Req.Body.Close())
+	require.Nil(t, err)
+	assert.Contains(t, string(sentBody), `"blueprint":{"name":"test bp","packages":[{"name":"tmux","version":"3.5a"}],"version":"1.1.0"}`)
+	assert.Contains(t, string(sentBody), `"upload_targets":[{"type":"aws","upload_options":{"region":"AWS_REGION","snapshot_name":"test-ami"}}]`)
+	assert.Equal(t, "application/json", mcc.Req.Header.Get("Content-Type"))
+	assert.Equal(t, "/api/image-builder-composer/v2/compose", mcc.Req.URL.Path)
+}
+
+func TestCmdComposeStartTypes(t *testing.T) {
+	// Test the "compose start --type" command with a blueprint on the server
+	var sentBodies []string
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		sentBodies = append(sentBodies, string(body))
+		json := `{
+			"build_id": "876b2946-16cd-4f38-bace-0cdd0093d112",
+			"status": true
+}`
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	// Make sure the compose.size value is reset to default
+	size = 0
+	defer func() { typeList = "" }()
+
+	// Start a compose for each type
+	cmd, out, err := root.ExecuteTest("compose", "start", "--type", "qcow2,vmdk", "http-server")
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, startCmd)
+	require.NotNil(t, out.Stdout)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Compose 876b2946-16cd-4f38-bace-0cdd0093d112 added to the queue\n"+
+		"Compose 876b2946-16cd-4f38-bace-0cdd0093d112 added to the queue\n", string(stdout))
+	require.Equal(t, 2, len(sentBodies))
+	assert.Equal(t, `{"blueprint_name":"http-server","compose_type":"qcow2","branch":"master","size":0}`, sentBodies[0])
+	assert.Equal(t, `{"blueprint_name":"http-server","compose_type":"vmdk","branch":"master","size":0}`, sentBodies[1])
+}
+
+func TestCmdComposeStartTypesWait(t *testing.T) {
+	// Test the "compose start --type --wait" command, all of the composes are started before
+	// waiting, and an error starting one of them does not stop the others
+	var started []string
+	var mu sync.Mutex
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if request.Method == "GET" {
+			// The composes must all be started before waiting
+			assert.Equal(t, []string{"qcow2", "vmdk", "ami"}, started)
+			return &http.Response{
+				Request:    request,
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewReader([]byte(getInfoWithStatus("FINISHED")))),
+			}, nil
+		}
+
+		var body struct {
+			ComposeType string `json:"compose_type"`
+		}
+		data, err := io.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &body); err != nil {
+			return nil, err
+		}
+		started = append(started, body.ComposeType)
+		resp := `{"build_id": "` + body.ComposeType + `-uuid", "status": true}`
+		status := 200
+		if body.ComposeType == "vmdk" {
+			resp = `{"errors": [{"id": "UnknownComposeType", "msg": "Unknown compose type: vmdk"}], "status": false}`
+			status = 400
+		}
+		return &http.Response{
+			Request:    request,
+			StatusCode: status,
+			Body:       io.NopCloser(bytes.NewReader([]byte(resp))),
+		}, nil
+	})
+	size = 0
+	defer func() {
+		typeList = ""
+		wait = false
+	}()
+
+	_, out, err := root.ExecuteTest("compose", "start", "--type", "qcow2,vmdk,ami", "--wait", "--timeout", "1m", "--poll", "10ms", "http-server")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "Compose qcow2-uuid added to the queue\nCompose ami-uuid added to the queue\n"+
+		"Waiting 1m0s for 2 composes to finish\n")
+	assert.Contains(t, string(stdout), "qcow2-uuid FINISHED\n")
+	assert.Contains(t, string(stdout), "ami-uuid FINISHED\n")
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR: vmdk: UnknownComposeType: Unknown compose type: vmdk\n", string(stderr))
+	assert.Equal(t, []string{"qcow2", "vmdk", "ami"}, started)
+}
+
+func TestCmdComposeStartTypesArgs(t *testing.T) {
+	// Using --type removes the TYPE argument
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return nil, nil
+	})
+	defer func() { typeList = "" }()
+
+	_, out, err := root.ExecuteTest("compose", "start", "--type", "qcow2,vmdk", "http-server", "qcow2")
+	defer out.Close()
+	assert.ErrorContains(t, err, "Invalid number of arguments")
+}
+
+func TestCmdComposeStartLocalBPTypes(t *testing.T) {
+	// Test the "compose start --type" command with a local blueprint file
+	mcc := root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"href": "/api/image-builder-composer/v2/compose", "id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeId"}`
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	// Need a temporary test file
+	tmpBP, err := os.CreateTemp("", "test-bp-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBP.Name()) //nolint:errcheck
+
+	_, err = tmpBP.Write([]byte(`name = "test bp"
+version = "1.1.0"
+[[packages]]
+name = "tmux"
+version = "3.5a"
+`))
+	require.Nil(t, err)
+
+	// Make sure the compose.size value is reset to default
+	size = 0
+	defer func() { typeList = "" }()
+
+	// Start one compose with all of the images
+	cmd, out, err := root.ExecuteTest("compose", "start", "--type", "qcow2,ami,vmdk", tmpBP.Name())
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, startCmd)
+	require.NotNil(t, out.Stdout)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Compose 008fc5ad-adad-42ec-b412-7923733483a8 added to the queue\n", string(stdout))
+	assert.Equal(t, "POST", mcc.Req.Method)
+	sentBody, err := io.ReadAll(mcc.Req.Body)
+	assert.Nil(t, mcc.Req.Body.Close())
+	require.Nil(t, err)
+	var request cloud.ComposeRequestV1
+	err = json.Unmarshal(sentBody, &request)
+	require.Nil(t, err)
+	require.Equal(t, 3, len(request.ImageRequests))
+	assert.Equal(t, "qcow2", request.ImageRequests[0].ImageType)
+	assert.Equal(t, "ami", request.ImageRequests[1].ImageType)
+	assert.Equal(t, "vmdk", request.ImageRequests[2].ImageType)
+	assert.Equal(t, "/api/image-builder-composer/v2/compose", mcc.Req.URL.Path)
+}
+
+// distroMatrixTest returns a mock cloud API handler with a distribution matrix and compose response
+func distroMatrixTest() func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		var json string
+		if request.URL.Path == "/api/image-builder-composer/v2/distributions" {
+			json = `{
+  "rhel-9.4": {
+    "aarch64": {
+	  "qcow2": [{"name": "baseos"}],
+	  "edge-commit": [{"name": "baseos"}]
+	}
+  },
+  "fedora-41": {
+    "aarch64": {
+	  "qcow2": [{"name": "fedora"}]
+	}
+  }
+}`
+		} else {
+			json = `{"href": "/api/image-builder-composer/v2/compose", "id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeId"}`
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	}
+}
+
+func TestCmdComposeStartLocalBPDistro(t *testing.T) {
+	// Test the "compose start" command with a distro and arch
+	mcc := root.SetupCloudCmdTest(distroMatrixTest())
+
+	tmpBP, err := os.CreateTemp("", "test-bp-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBP.Name()) //nolint:errcheck
+	_, err = tmpBP.Write([]byte(`name = "test bp"
+version = "1.1.0"
+`))
+	require.Nil(t, err)
+
+	size = 0
+	defer func() {
+		distro = ""
+		arch = ""
+	}()
+
+	cmd, out, err := root.ExecuteTest("compose", "start", "--distro", "rhel-9.4", "--arch", "aarch64", tmpBP.Name(), "qcow2")
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, startCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Compose 008fc5ad-adad-42ec-b412-7923733483a8 added to the queue\n", string(stdout))
+	assert.Equal(t, "POST", mcc.Req.Method)
+	sentBody, err := io.ReadAll(mcc.Req.Body)
+	assert.Nil(t, mcc.Req.Body.Close())
+	require.Nil(t, err)
+	var request cloud.ComposeRequestV1
+	err = json.Unmarshal(sentBody, &request)
+	require.Nil(t, err)
+	assert.Equal(t, "rhel-9.4", request.Distribution)
+	require.Equal(t, 1, len(request.ImageRequests))
+	assert.Equal(t, "aarch64", request.ImageRequests[0].Architecture)
+	assert.Equal(t, "qcow2", request.ImageRequests[0].ImageType)
+}
+
+func TestCmdComposeStartLocalBPDistroUnsupported(t *testing.T) {
+	// Test the "compose start" command with types that are not in the distro matrix
+	mcc := root.SetupCloudCmdTest(distroMatrixTest())
+
+	tmpBP, err := os.CreateTemp("", "test-bp-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBP.Name()) //nolint:errcheck
+	_, err = tmpBP.Write([]byte(`name = "test bp"
+version = "1.1.0"
+`))
+	require.Nil(t, err)
+
+	size = 0
+	defer func() {
+		distro = ""
+		arch = ""
+	}()
+
+	_, out, err := root.ExecuteTest("compose", "start", "--distro", "rhel-9.4", "--arch", "aarch64", tmpBP.Name(), "vmdk")
+	defer out.Close()
+	assert.ErrorContains(t, err, "vmdk is not a supported image type for rhel-9.4 on aarch64")
+	assert.Equal(t, "GET", mcc.Req.Method)
+
+	_, out, err = root.ExecuteTest("compose", "start", "--distro", "rhel-9.4", "--arch", "x86_64", tmpBP.Name(), "qcow2")
+	defer out.Close()
+	assert.ErrorContains(t, err, "x86_64 is not a supported architecture")
+	assert.Equal(t, "GET", mcc.Req.Method)
+}
+
+func TestCmdComposeStartDistroServerBP(t *testing.T) {
+	// --distro is not supported with a blueprint on the server
+	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return nil, nil
+	})
+	defer func() { distro = "" }()
+
+	_, out, err := root.ExecuteTest("compose", "start", "--distro", "rhel-9.4", "http-server", "qcow2")
+	defer out.Close()
+	assert.ErrorContains(t, err, "--distro and --arch are only supported with a local blueprint file")
+	assert.Equal(t, "", mc.Req.Method)
+}
+
+func TestCmdComposeStartLocalBPRepo(t *testing.T) {
+	// Test the "compose start" command with a custom repository
+	mcc := root.SetupCloudCmdTest(distroMatrixTest())
+
+	tmpBP, err := os.CreateTemp("", "test-bp-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBP.Name()) //nolint:errcheck
+	_, err = tmpBP.Write([]byte(`name = "test bp"
+version = "1.1.0"
+`))
+	require.Nil(t, err)
+
+	tmpRepo, err := os.CreateTemp("", "test-repo-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpRepo.Name()) //nolint:errcheck
+	_, err = tmpRepo.Write([]byte(`id = "custom-source-1"
+type = "yum-baseurl"
+url = "https://url/path/to/repository/"
+check_ssl = true
+check_gpg = true
+gpgkeys = ["https://url/path/to/gpg-key"]
+distros = ["rhel-9.4"]
+package_sets = ["os"]
+`))
+	require.Nil(t, err)
+
+	size = 0
+	defer func() {
+		distro = ""
+		arch = ""
+		repoFiles = nil
+	}()
+
+	cmd, out, err := root.ExecuteTest("compose", "start", "--distro", "rhel-9.4", "--arch", "aarch64",
+		"--repo", tmpRepo.Name(), tmpBP.Name(), "qcow2")
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, startCmd)
+	assert.Equal(t, "POST", mcc.Req.Method)
+	sentBody, err := io.ReadAll(mcc.Req.Body)
+	assert.Nil(t, mcc.Req.Body.Close())
+	require.Nil(t, err)
+	// The repository is added to the distribution's default repositories
+	assert.Contains(t, string(sentBody), `"customizations":{"payload_repositories":[{"baseurl":"https://url/path/to/repository/","gpgkey":"https://url/path/to/gpg-key","check_gpg":true,"ignore_ssl":false,"rhsm":false,"package_sets":["os"]}]}`)
+	assert.Contains(t, string(sentBody), `"repositories":[]`)
+
+	// The source cannot be used with other distributions
+	_, out, err = root.ExecuteTest("compose", "start", "--distro", "fedora-41", "--arch", "aarch64",
+		"--repo", tmpRepo.Name(), tmpBP.Name(), "qcow2")
+	defer out.Close()
+	assert.ErrorContains(t, err, "source custom-source-1 does not support fedora-41")
+}
+
+func TestCmdComposeStartRepoServerBP(t *testing.T) {
+	// --repo is not supported with a blueprint on the server
+	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return nil, nil
+	})
+	defer func() { repoFiles = nil }()
+
+	_, out, err := root.ExecuteTest("compose", "start", "--repo", "custom.toml", "http-server", "qcow2")
+	defer out.Close()
+	assert.ErrorContains(t, err, "--repo is only supported with a local blueprint file")
+	assert.Equal(t, "", mc.Req.Method)
+}
+
+func TestCmdComposeStartLocalBPUploadMissingKey(t *testing.T) {
+	// Test the "compose start" command with an upload missing a required key
+	mcc := root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		return nil, nil
+	})
+
+	tmpBP, err := os.CreateTemp("", "test-bp-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBP.Name()) //nolint:errcheck
+	_, err = tmpBP.Write([]byte(`name = "test bp"
+version = "1.1.0"
+`))
+	require.Nil(t, err)
+
+	tmpUpload, err := os.CreateTemp("", "test-upload-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpUpload.Name()) //nolint:errcheck
+	_, err = tmpUpload.Write([]byte(`type = "azure"
+[upload_options]
+tenant_id = "TENANT"
+resource_group = "GROUP"`))
+	require.Nil(t, err)
+
+	size = 0
+	_, out, err := root.ExecuteTest("compose", "start", tmpBP.Name(), "vhd", "test-vhd", tmpUpload.Name())
+	defer out.Close()
+	assert.ErrorContains(t, err, "azure upload is missing the required upload_options key: subscription_id")
+	assert.Equal(t, "", mcc.Req.Method)
+}
+
+func TestCmdComposeStartLocalBPUploadNoName(t *testing.T) {
+	// Test the "compose start" command with an upload type that cannot name the image
+	mcc := root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"href": "/api/image-builder-composer/v2/compose", "id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeId"}`
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	tmpBP, err := os.CreateTemp("", "test-bp-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBP.Name()) //nolint:errcheck
+	_, err = tmpBP.Write([]byte(`name = "test bp"
+version = "1.1.0"
+`))
+	require.Nil(t, err)
+
+	tmpUpload, err := os.CreateTemp("", "test-upload-p*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpUpload.Name()) //nolint:errcheck
+	_, err = tmpUpload.Write([]byte(`type = "aws.s3"
+[upload_options]
+region = "AWS_REGION"`))
+	require.Nil(t, err)
+
+	size = 0
+	_, out, err := root.ExecuteTest("compose", "start", tmpBP.Name(), "image-installer", "test-iso", tmpUpload.Name())
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Warning: aws.s3 upload does not support an image name, the image name test-iso is not used\n"+
+		"Compose 008fc5ad-adad-42ec-b412-7923733483a8 added to the queue\n", string(stdout))
+	sentBody, err := io.ReadAll(mcc.Req.Body)
+	assert.Nil(t, mcc.Req.Body.Close())
+	require.Nil(t, err)
+	assert.Contains(t, string(sentBody), `"upload_targets":[{"type":"aws.s3","upload_options":{"region":"AWS_REGION"}}]`)
+}
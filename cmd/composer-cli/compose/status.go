@@ -7,6 +7,8 @@ package compose
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -28,65 +30,72 @@ var (
 )
 
 func init() {
+	root.EnableOutput(statusCmd)
 	composeCmd.AddCommand(statusCmd)
 }
 
 func status(cmd *cobra.Command, args []string) (rcErr error) {
-	w := tabwriter.NewWriter(os.Stdout, 5, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "ID\tStatus\tTime\tBlueprint\tVersion\tType\tSize")
-
-	// Check cloudapi for composes first
-	if root.Cloud.Exists() {
-		composes, _ := root.Cloud.ListComposes()
-		if len(composes) > 0 {
-			for i := range composes {
-				// Get as much detail as we can about the compose
-				// This depends on the type of build and how it was started so some fields may
-				// be blank. Currently no details are available so they are left blank.
-				bpName, bpVersion, imageType, size := composeDetails(composes[i].ID)
-				fmt.Fprintf(w, "%s\t%-8s\t%s\t%-15s\t%s\t%-16s\t%s\n", composes[i].ID,
-					root.Cloud.StatusMap(composes[i].Status),
-					"",
-					bpName, bpVersion, imageType, size)
-			}
-		}
-	}
-
-	// Check weldrapi for composes
-	composes, errors, err := root.Client.ListComposes()
+	composes, err := listComposes()
 	if err != nil {
-		return root.ExecutionError(cmd, "List Error: %s", err)
+		rcErr = root.ExecutionError(cmd, "List Error: %s", err)
 	}
-	if len(errors) > 0 {
-		rcErr = root.ExecutionErrors(cmd, errors)
+
+	// The cloud API composes are first, followed by the sorted weldr API composes
+	if firstWeldr := slices.IndexFunc(composes, func(c weldr.Compose) bool { return c.API == "weldr" }); firstWeldr >= 0 {
+		weldr.SortComposes(composes[firstWeldr:])
 	}
 
-	composes = weldr.SortComposeStatusV0(composes)
-	for i := range composes {
-		c := composes[i]
+	records := []composeRecord{}
+	var times []string
+	for _, c := range composes {
+		// Use the most recent time reported by the API
+		// Older cloud API servers do not report any times so they are left blank.
+		t := composeTime(c)
 
-		// Convert the API's float64 time to Time
-		var s float64
-		if c.JobFinished > 0 {
-			s = c.JobFinished
-		} else if c.JobStarted > 0 {
-			s = c.JobStarted
-		} else if c.JobCreated > 0 {
-			s = c.JobCreated
+		r := newComposeRecord(c)
+		if t.IsZero() {
+			times = append(times, "")
+		} else {
+			r.Time = t.UTC().Format(time.RFC3339)
+			times = append(times, t.Format("Mon Jan 2 15:04:05 2006"))
 		}
-		sec := int64(s)
-		nsec := int64(1 / (s - float64(sec)))
-		t := time.Unix(sec, nsec)
+		records = append(records, r)
+	}
 
-		var size string
-		if c.Size > 0 {
-			size = fmt.Sprintf("%d", c.Size)
+	if root.StructuredOutput() {
+		if err := printComposeRecords(records); err != nil {
+			return root.ExecutionError(cmd, "%s", err)
 		}
+		return rcErr
+	}
 
-		fmt.Fprintf(w, "%s\t%-8s\t%s\t%-15s\t%s\t%-16s\t%s\n", c.ID, c.Status, t.Format("Mon Jan 2 15:04:05 2006"),
-			c.Blueprint, c.Version, c.Type, size)
+	w := tabwriter.NewWriter(os.Stdout, 5, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tStatus\tTime\tBlueprint\tVersion\tType\tSize")
+	for i, r := range records {
+		fmt.Fprintf(w, "%s\t%-8s\t%s\t%-15s\t%s\t%-16s\t%s\n", r.ID, r.Status, times[i],
+			r.Blueprint, r.Version, strings.Join(r.Types, ","), recordSizes(r))
 	}
 	w.Flush() //nolint:errcheck
 
 	return rcErr
 }
+
+// recordSizes returns the sizes of the compose's images for the table
+// They are comma separated, an image using the default size is shown as '-' unless none
+// of them have a size, then the size is empty.
+func recordSizes(r composeRecord) string {
+	var sizes []string
+	var hasSize bool
+	for _, size := range r.Sizes {
+		if size > 0 {
+			sizes = append(sizes, fmt.Sprintf("%d", size))
+			hasSize = true
+		} else {
+			sizes = append(sizes, "-")
+		}
+	}
+	if !hasSize {
+		return ""
+	}
+	return strings.Join(sizes, ",")
+}
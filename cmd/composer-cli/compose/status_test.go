@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -423,3 +424,87 @@ func TestComposeStatusCloud(t *testing.T) {
 	assert.Equal(t, []byte(""), stderr)
 	assert.Equal(t, "GET", mcc.Req.Method)
 }
+
+func TestCmdComposeStatusOutput(t *testing.T) {
+	listOutputTest()
+
+	cmd, out, err := root.ExecuteTest("compose", "status", "--output", "yaml")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, statusCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, `- id: 008fc5ad-adad-42ec-b412-7923733483a8
+  api: cloud
+  status: RUNNING
+  blueprint: tmux-image
+  version: 0.0.1
+  types:
+    - qcow2
+    - ami
+  sizes:
+    - 4294967296
+    - 0
+- id: cefd01c3-629f-493e-af72-3f12981bb77b
+  api: weldr
+  status: FINISHED
+  time: "2020-12-16T20:08:19Z"
+  blueprint: tmux-bcl
+  version: 1.0.0
+  types:
+    - qcow2
+  sizes:
+    - 2147483648
+`, string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+}
+
+func TestCmdComposeStatusCloudFailed(t *testing.T) {
+	// A failed cloud compose has its time and the reason it failed
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"new": [], "run": [], "finished": [], "failed": []}`))),
+		}, nil
+	})
+	root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		sc := 200
+		json := `[{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeStatus", "status": "failure",
+  "created_at": "2026-10-16T10:00:00Z", "started_at": "2026-10-16T10:00:05Z", "finished_at": "2026-10-16T10:12:30Z",
+  "image_status": {"status": "failure", "error": {"id": 10, "reason": "osbuild build failed"}}}]`
+		if request.URL.Path != "/api/image-builder-composer/v2/composes/" {
+			sc = 404
+			json = `{"kind":"ComposeError", "...":"unknown url"}`
+		}
+		return &http.Response{
+			StatusCode: sc,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	cmd, out, err := root.ExecuteTest("compose", "status", "--output", "json")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), `"status": "FAILED",
+        "time": "2026-10-16T10:12:30Z",`)
+	assert.Contains(t, string(stdout), `"errors": [
+            "osbuild build failed"
+        ]`)
+
+	_, out, err = root.ExecuteTest("compose", "status")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err = io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	finished := time.Date(2026, 10, 16, 10, 12, 30, 0, time.UTC).Local().Format("Mon Jan 2 15:04:05 2006")
+	assert.Contains(t, string(stdout), "008fc5ad-adad-42ec-b412-7923733483a8   FAILED     "+finished)
+}
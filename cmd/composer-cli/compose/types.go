@@ -5,13 +5,11 @@
 package compose
 
 import (
-	"fmt"
 	"sort"
 
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
-	"github.com/osbuild/weldr-client/v2/internal/common"
 	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
@@ -33,6 +31,7 @@ var (
 func init() {
 	typesCmd.Flags().StringVarP(&distro, "distro", "", "", "Distribution")
 	typesCmd.Flags().StringVarP(&arch, "arch", "", "", "Architecture")
+	root.EnableOutput(typesCmd)
 	composeCmd.AddCommand(typesCmd)
 }
 
@@ -43,23 +42,18 @@ func types(cmd *cobra.Command, args []string) error {
 
 	// First check the cloudapi, if available use that
 	if root.Cloud.Exists() {
-		if len(distro) == 0 {
-			distro, err = common.GetHostDistroName()
-			if err != nil {
-				return root.ExecutionError(cmd, "Types Error determining host distribution: %s", err)
-			}
-		}
-
-		if len(arch) == 0 {
-			arch = common.HostArch()
+		distro, err = root.GetDistro(distro)
+		if err != nil {
+			return root.ExecutionError(cmd, "Types Error determining host distribution: %s", err)
 		}
+		arch = root.GetArch(arch)
 
 		types, err = root.Cloud.GetComposeTypes(distro, arch)
 		if err != nil {
 			return root.ExecutionError(cmd, "Types Error: %s", err)
 		}
 	} else {
-		types, resp, err = root.Client.GetComposeTypes(distro)
+		types, resp, err = root.Client.GetComposeTypes(root.DistroOrDefault(distro))
 		if err != nil {
 			return root.ExecutionError(cmd, "Types Error: %s", err)
 		}
@@ -69,8 +63,8 @@ func types(cmd *cobra.Command, args []string) error {
 	}
 
 	sort.Strings(types)
-	for i := range types {
-		fmt.Println(types[i])
+	if err := root.PrintList("Type", types); err != nil {
+		return root.ExecutionError(cmd, "%s", err)
 	}
 
 	return nil
@@ -8,6 +8,8 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -340,3 +342,106 @@ func TestCmdComposeTypesCloud(t *testing.T) {
 	assert.Equal(t, []byte(""), stderr)
 	assert.Equal(t, "GET", mc.Req.Method)
 }
+
+func TestCmdComposeTypesCloudProfile(t *testing.T) {
+	// Test the "compose types" command using the distro and arch from a profile
+	mc := root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{
+  "distro-1": {
+    "arch-1": {
+	  "image-1-1-1": [{"name": "fedora"}, {"name": "updates"}]
+	}
+  },
+  "distro-2": {
+    "arch-2": {
+	  "image-2-2-1": [{"name": "fedora"}, {"name": "updates"}]
+	}
+  }
+}`
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	config := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(config, []byte(`[profiles.test]
+distro = "distro-2"
+arch = "arch-2"
+`), 0600)
+	require.Nil(t, err)
+	root.SetupConfigTest(config)
+
+	// Clear the module's cmdline variables
+	arch = ""
+	distro = ""
+
+	// Get the image types
+	cmd, out, err := root.ExecuteTest("--profile", "test", "compose", "types")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, typesCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "image-2-2-1\n", string(stdout))
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+	assert.Equal(t, "GET", mc.Req.Method)
+}
+
+func TestCmdComposeTypesUnknownProfile(t *testing.T) {
+	// Test that an unknown profile is reported
+	root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	})
+
+	config := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(config, []byte("[profiles.test]\n"), 0600)
+	require.Nil(t, err)
+	root.SetupConfigTest(config)
+
+	cmd, out, err := root.ExecuteTest("--profile", "missing", "compose", "types")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	assert.Equal(t, cmd, typesCmd)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: unknown profile: missing")
+}
+
+func TestCmdComposeTypesOutput(t *testing.T) {
+	// Test the "compose types --output json" command
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"types": [{"name": "qcow2", "enabled": true}, {"name": "ami", "enabled": true}]}`
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	cmd, out, err := root.ExecuteTest("compose", "types", "--output", "json")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, typesCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "[\n    \"ami\",\n    \"qcow2\"\n]\n", string(stdout))
+
+	_, out, err = root.ExecuteTest("compose", "types", "--output", "csv")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err = io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Type\nami\nqcow2\n", string(stdout))
+}
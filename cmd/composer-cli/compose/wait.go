@@ -6,30 +6,44 @@ package compose
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 var (
 	waitCmd = &cobra.Command{
-		Use:     "wait UUID",
-		Short:   "Wait for a compose to finish",
-		Long:    "Wait for a compose to finish, fail, or time out",
-		Example: "  composer-cli compose wait 914bb03b-e4c8-4074-bc31-6869961ee2f3",
-		RunE:    waitForCompose,
-		Args:    cobra.ExactArgs(1),
-	}
-	wait       bool // Defined here, used by start and start-ostree
-	timeoutStr string
-	pollStr    string
+		Use:   "wait UUID...",
+		Short: "Wait for composes to finish",
+		Long: `Wait for one or more composes to finish, fail, or time out
+
+The composes are checked at the same time, with at most --max-requests requests
+to the server at once. Each compose is printed with its status as soon as it is
+done. It exits with an error if any of them failed, or did not finish before the
+timeout. Use --all-running to wait for all of the waiting and running composes.`,
+		Example: `  composer-cli compose wait 914bb03b-e4c8-4074-bc31-6869961ee2f3
+  composer-cli compose wait 914bb03b-e4c8-4074-bc31-6869961ee2f3 008fc5ad-adad-42ec-b412-7923733483a8
+  composer-cli compose wait --all-running --timeout 2h`,
+		RunE: waitForCompose,
+	}
+	wait        bool // Defined here, used by start and start-ostree
+	timeoutStr  string
+	pollStr     string
+	allRunning  bool
+	maxRequests int
 )
 
 func init() {
 	waitCmd.Flags().StringVarP(&timeoutStr, "timeout", "", "5m", "Maximum time to wait")
 	waitCmd.Flags().StringVarP(&pollStr, "poll", "", "10s", "Polling interval")
+	waitCmd.Flags().BoolVarP(&allRunning, "all-running", "", false, "Wait for all of the waiting and running composes")
+	waitCmd.Flags().IntVarP(&maxRequests, "max-requests", "", 4, "Maximum number of status requests to make at the same time")
 	composeCmd.AddCommand(waitCmd)
 }
 
@@ -42,32 +56,167 @@ func waitForCompose(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return root.ExecutionError(cmd, "poll - %s", err)
 	}
-	fmt.Printf("Waiting %v for compose to finish\n", timeout)
+	if interval >= timeout {
+		return root.ExecutionError(cmd, "Cannot wait, check interval (%v) must be < timeout (%v)", interval, timeout)
+	}
+	if maxRequests < 1 {
+		return root.ExecutionError(cmd, "--max-requests must be at least 1")
+	}
 
-	if root.Cloud.Exists() {
-		// Try the UUID with the cloud API first
-		aborted, status, err := root.Cloud.ComposeWait(args[0], timeout, interval)
-		if aborted {
-			return root.ExecutionError(cmd, "timeout after %v", timeout)
+	ids := args
+	if allRunning {
+		if len(args) > 0 {
+			return root.ExecutionError(cmd, "--all-running cannot be used with a list of UUIDs")
+		}
+		ids, err = runningComposes()
+		if err != nil {
+			return root.ExecutionError(cmd, "%s", err)
 		}
-		if err == nil {
-			fmt.Printf("%s %s\n", args[0], status.Status)
+		if len(ids) == 0 {
+			fmt.Println("No composes are waiting or running")
 			return nil
 		}
+	} else if len(ids) == 0 {
+		return root.ExecutionError(cmd, "missing the UUID of a compose")
 	}
 
-	// Not found with cloud API, try weldr API
-	aborted, info, resp, err := root.Client.ComposeWait(args[0], timeout, interval)
-	if aborted {
-		return root.ExecutionError(cmd, "timeout after %v", timeout)
+	if len(ids) == 1 {
+		fmt.Printf("Waiting %v for compose to finish\n", timeout)
+	} else {
+		fmt.Printf("Waiting %v for %d composes to finish\n", timeout, len(ids))
 	}
-	if err != nil {
-		return root.ExecutionError(cmd, "%s", err)
+
+	var failed, timedOut []string
+	var errors, cancelled bool
+	for r := range waitForComposes(ids, timeout, interval, maxRequests) {
+		switch {
+		case r.cancelled:
+			// Reported once, instead of for each compose
+			cancelled = true
+		case r.err != nil:
+			errors = true
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", r.err)
+		case r.timeout:
+			timedOut = append(timedOut, r.id)
+		default:
+			fmt.Printf("%s %s\n", r.id, r.status)
+			if r.failed {
+				failed = append(failed, r.id)
+			}
+		}
+	}
+
+	var problems []string
+	if len(failed) > 0 {
+		problems = append(problems, fmt.Sprintf("compose %s failed", strings.Join(failed, ", ")))
+	}
+	if len(timedOut) == 1 && len(ids) == 1 {
+		problems = append(problems, fmt.Sprintf("timeout after %v", timeout))
+	} else if len(timedOut) > 0 {
+		problems = append(problems, fmt.Sprintf("timeout after %v waiting for %s", timeout, strings.Join(timedOut, ", ")))
+	}
+	if cancelled {
+		problems = append(problems, "wait was cancelled")
 	}
-	if resp != nil {
-		return root.ExecutionErrors(cmd, resp.Errors)
+	if len(problems) == 0 && errors {
+		// The errors have already been printed
+		return root.ExecutionError(cmd, "")
+	}
+	for i, p := range problems {
+		if i == len(problems)-1 {
+			return root.ExecutionError(cmd, "%s", p)
+		}
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", p)
 	}
-	fmt.Printf("%s %s\n", info.ID, info.QueueStatus)
 
 	return nil
 }
+
+// runningComposes returns the UUIDs of the waiting and running composes from both APIs
+func runningComposes() ([]string, error) {
+	composes, err := listComposes()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, c := range composes {
+		if !c.Done() {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids, nil
+}
+
+// waitResult is the final status of a compose
+type waitResult struct {
+	id        string
+	status    string // Status as reported by the API
+	failed    bool
+	timeout   bool
+	cancelled bool // The command was cancelled before the compose was done
+	err       error
+}
+
+// waitForComposes checks the status of the composes until they are all done or timed out
+// The result for each compose is sent on the channel as soon as it is done, it is closed
+// when all of them have been sent. At most maxRequests status requests are made at once.
+func waitForComposes(ids []string, timeout, interval time.Duration, maxRequests int) <-chan waitResult {
+	results := make(chan waitResult)
+	requests := make(chan struct{}, maxRequests)
+	deadline := time.Now().Add(timeout)
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			results <- waitForOne(id, deadline, interval, requests)
+		}(id)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// waitForOne checks the status of a compose until it is done or the deadline has passed
+// The first check finds the API that has the compose, the cloud API is tried first.
+// requests limits the number of requests that are made at the same time.
+func waitForOne(id string, deadline time.Time, interval time.Duration, requests chan struct{}) waitResult {
+	ctx := root.Context()
+	var backend weldr.ComposeBackend
+	for {
+		var compose weldr.Compose
+		var err error
+		requests <- struct{}{}
+		if backend == nil {
+			backend, compose, err = weldr.FindCompose(id, composeBackends()...)
+		} else {
+			compose, err = backend.Info(id)
+		}
+		<-requests
+		if err != nil && ctx.Err() != nil {
+			return waitResult{id: id, cancelled: true, err: ctx.Err()}
+		} else if err != nil {
+			return waitResult{id: id, err: err}
+		}
+		r := waitResult{id: id, status: compose.Status, failed: compose.State == "FAILED"}
+		if compose.Done() {
+			return r
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			r.timeout = true
+			return r
+		}
+		check := time.NewTimer(min(interval, remaining))
+		select {
+		case <-check.C:
+		case <-ctx.Done():
+			check.Stop()
+			return waitResult{id: id, cancelled: true, err: ctx.Err()}
+		}
+	}
+}
@@ -2,10 +2,13 @@ package compose
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
 	"github.com/stretchr/testify/assert"
@@ -150,11 +153,11 @@ func TestCmdComposeWaitFailed(t *testing.T) {
 		}, nil
 	})
 
-	// Wait for a compose to finish
+	// Wait for a compose to finish, a failed compose is an error
 	cmd, out, err := root.ExecuteTest("compose", "wait", "--timeout", "2s", "--poll", "1s", "ddcf50e5-1ffa-4de6-95ed-42749ac1f389")
 	require.NotNil(t, out)
 	defer out.Close()
-	require.Nil(t, err)
+	require.NotNil(t, err)
 	require.NotNil(t, out.Stdout)
 	require.NotNil(t, out.Stderr)
 	require.NotNil(t, cmd)
@@ -164,7 +167,7 @@ func TestCmdComposeWaitFailed(t *testing.T) {
 	assert.Contains(t, string(stdout), "ddcf50e5-1ffa-4de6-95ed-42749ac1f389 FAILED\n")
 	stderr, err := io.ReadAll(out.Stderr)
 	assert.Nil(t, err)
-	assert.Equal(t, []byte(""), stderr)
+	assert.Equal(t, []byte("ERROR: compose ddcf50e5-1ffa-4de6-95ed-42749ac1f389 failed\n"), stderr)
 	assert.Equal(t, "GET", mc.Req.Method)
 }
 
@@ -196,3 +199,241 @@ func TestCmdComposeWaitFinished(t *testing.T) {
 	assert.Equal(t, []byte(""), stderr)
 	assert.Equal(t, "GET", mc.Req.Method)
 }
+
+// resetWaitFlags sets the wait flags back to their defaults
+func resetWaitFlags() {
+	timeoutStr = "5m"
+	pollStr = "10s"
+	allRunning = false
+	maxRequests = 4
+}
+
+// waitManyTest returns mock servers for composes that go through a list of statuses
+// The cloud API server only knows about the cloud composes. It also records the highest
+// number of requests that were made at the same time.
+type waitManyTest struct {
+	sync.Mutex
+	statuses map[string][]string
+	checks   map[string]int
+	inFlight int
+	maxSeen  int
+}
+
+func newWaitManyTest(statuses map[string][]string) *waitManyTest {
+	return &waitManyTest{statuses: statuses, checks: map[string]int{}}
+}
+
+// next returns the next status for the compose, or false if it is unknown
+// The cloud API statuses are lower case, the weldr API statuses are upper case.
+func (w *waitManyTest) next(id string, cloud bool) (string, bool) {
+	w.Lock()
+	defer w.Unlock()
+	statuses, ok := w.statuses[id]
+	if !ok || cloud != (strings.ToLower(statuses[0]) == statuses[0]) {
+		return "", false
+	}
+	status := statuses[min(w.checks[id], len(statuses)-1)]
+	w.checks[id]++
+	return status, true
+}
+
+// request counts the requests that are running at the same time
+func (w *waitManyTest) request() func() {
+	w.Lock()
+	w.inFlight++
+	w.maxSeen = max(w.maxSeen, w.inFlight)
+	w.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	return func() {
+		w.Lock()
+		w.inFlight--
+		w.Unlock()
+	}
+}
+
+func (w *waitManyTest) weldr(request *http.Request) (*http.Response, error) {
+	defer w.request()()
+	id := request.URL.Path[strings.LastIndex(request.URL.Path, "/")+1:]
+	queues := map[string]string{
+		"/api/v1/compose/queue": `{"new": [{"id": "ddcf50e5-1ffa-4de6-95ed-42749ac1f389", "queue_status": "WAITING"}],
+"run": [{"id": "11111111-1ffa-4de6-95ed-42749ac1f389", "queue_status": "RUNNING"}]}`,
+		"/api/v1/compose/finished": `{"finished": [{"id": "33333333-1ffa-4de6-95ed-42749ac1f389", "queue_status": "FINISHED"}]}`,
+		"/api/v1/compose/failed":   `{"failed": []}`,
+	}
+	if body, ok := queues[request.URL.Path]; ok {
+		return &http.Response{
+			Request:    request,
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+	status, ok := w.next(id, false)
+	if !ok {
+		body := fmt.Sprintf(`{"status": false, "errors": [{"id": "UnknownUUID", "msg": "%s is not a valid build uuid"}]}`, id)
+		return &http.Response{
+			Request:    request,
+			StatusCode: 400,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+	return &http.Response{
+		Request:    request,
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader([]byte(getInfoWithStatus(status)))),
+	}, nil
+}
+
+func (w *waitManyTest) cloud(request *http.Request) (*http.Response, error) {
+	defer w.request()()
+	if request.URL.Path == "/api/image-builder-composer/v2/composes/" {
+		body := `[{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeStatus", "status": "pending"},
+{"id": "22222222-adad-42ec-b412-7923733483a8", "kind": "ComposeStatus", "status": "success"}]`
+		return &http.Response{
+			Request:    request,
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+	id := request.URL.Path[strings.LastIndex(request.URL.Path, "/")+1:]
+	status, ok := w.next(id, true)
+	if !ok {
+		return &http.Response{
+			Request:    request,
+			StatusCode: 404,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"kind": "Error", "reason": "Compose not found"}`))),
+		}, nil
+	}
+	body := fmt.Sprintf(`{"id": "%s", "kind": "ComposeStatus", "status": "%s"}`, id, status)
+	return &http.Response{
+		Request:    request,
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+	}, nil
+}
+
+func TestCmdComposeWaitMany(t *testing.T) {
+	w := newWaitManyTest(map[string][]string{
+		"ddcf50e5-1ffa-4de6-95ed-42749ac1f389": {"WAITING", "RUNNING", "FINISHED"},
+		"11111111-1ffa-4de6-95ed-42749ac1f389": {"FINISHED"},
+		"008fc5ad-adad-42ec-b412-7923733483a8": {"pending", "pending", "success"},
+		"22222222-adad-42ec-b412-7923733483a8": {"success"},
+	})
+	root.SetupCmdTest(w.weldr)
+	root.SetupCloudCmdTest(w.cloud)
+	resetWaitFlags()
+
+	cmd, out, err := root.ExecuteTest("compose", "wait", "--poll", "10ms", "--max-requests", "2",
+		"ddcf50e5-1ffa-4de6-95ed-42749ac1f389",
+		"11111111-1ffa-4de6-95ed-42749ac1f389",
+		"008fc5ad-adad-42ec-b412-7923733483a8",
+		"22222222-adad-42ec-b412-7923733483a8")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, waitCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "Waiting 5m0s for 4 composes to finish\n")
+	assert.Contains(t, string(stdout), "ddcf50e5-1ffa-4de6-95ed-42749ac1f389 FINISHED\n")
+	assert.Contains(t, string(stdout), "11111111-1ffa-4de6-95ed-42749ac1f389 FINISHED\n")
+	assert.Contains(t, string(stdout), "008fc5ad-adad-42ec-b412-7923733483a8 success\n")
+	assert.Contains(t, string(stdout), "22222222-adad-42ec-b412-7923733483a8 success\n")
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+	assert.LessOrEqual(t, w.maxSeen, 2)
+}
+
+func TestCmdComposeWaitManyProblems(t *testing.T) {
+	w := newWaitManyTest(map[string][]string{
+		"ddcf50e5-1ffa-4de6-95ed-42749ac1f389": {"RUNNING"},
+		"11111111-1ffa-4de6-95ed-42749ac1f389": {"RUNNING", "FAILED"},
+		"008fc5ad-adad-42ec-b412-7923733483a8": {"success"},
+	})
+	root.SetupCmdTest(w.weldr)
+	root.SetupCloudCmdTest(w.cloud)
+	resetWaitFlags()
+
+	cmd, out, err := root.ExecuteTest("compose", "wait", "--timeout", "200ms", "--poll", "10ms",
+		"ddcf50e5-1ffa-4de6-95ed-42749ac1f389",
+		"11111111-1ffa-4de6-95ed-42749ac1f389",
+		"008fc5ad-adad-42ec-b412-7923733483a8",
+		"33333333-1ffa-4de6-95ed-42749ac1f389")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, waitCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "11111111-1ffa-4de6-95ed-42749ac1f389 FAILED\n")
+	assert.Contains(t, string(stdout), "008fc5ad-adad-42ec-b412-7923733483a8 success\n")
+	assert.NotContains(t, string(stdout), "ddcf50e5-1ffa-4de6-95ed-42749ac1f389")
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: UnknownUUID: 33333333-1ffa-4de6-95ed-42749ac1f389 is not a valid build uuid\n")
+	assert.Contains(t, string(stderr), "ERROR: compose 11111111-1ffa-4de6-95ed-42749ac1f389 failed\n")
+	assert.Contains(t, string(stderr), "ERROR: timeout after 200ms waiting for ddcf50e5-1ffa-4de6-95ed-42749ac1f389\n")
+}
+
+func TestCmdComposeWaitCancelled(t *testing.T) {
+	w := newWaitManyTest(map[string][]string{
+		"ddcf50e5-1ffa-4de6-95ed-42749ac1f389": {"RUNNING"},
+		"11111111-1ffa-4de6-95ed-42749ac1f389": {"RUNNING"},
+		"008fc5ad-adad-42ec-b412-7923733483a8": {"pending"},
+	})
+	root.SetupCmdTest(w.weldr)
+	root.SetupCloudCmdTest(w.cloud)
+	resetWaitFlags()
+	cancel := root.SetupCancelTest()
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	_, out, err := root.ExecuteTest("compose", "wait", "--poll", "10ms",
+		"ddcf50e5-1ffa-4de6-95ed-42749ac1f389",
+		"11111111-1ffa-4de6-95ed-42749ac1f389",
+		"008fc5ad-adad-42ec-b412-7923733483a8")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	// The cancellation is reported once, not for each compose
+	assert.Equal(t, "ERROR: wait was cancelled\n", string(stderr))
+}
+
+func TestCmdComposeWaitAllRunning(t *testing.T) {
+	w := newWaitManyTest(map[string][]string{
+		"ddcf50e5-1ffa-4de6-95ed-42749ac1f389": {"RUNNING", "FINISHED"},
+		"11111111-1ffa-4de6-95ed-42749ac1f389": {"FINISHED"},
+		"008fc5ad-adad-42ec-b412-7923733483a8": {"pending", "success"},
+		"22222222-adad-42ec-b412-7923733483a8": {"success"},
+	})
+	root.SetupCmdTest(w.weldr)
+	root.SetupCloudCmdTest(w.cloud)
+	resetWaitFlags()
+
+	cmd, out, err := root.ExecuteTest("compose", "wait", "--poll", "10ms", "--all-running")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, waitCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "Waiting 5m0s for 3 composes to finish\n")
+	assert.Contains(t, string(stdout), "ddcf50e5-1ffa-4de6-95ed-42749ac1f389 FINISHED\n")
+	assert.Contains(t, string(stdout), "11111111-1ffa-4de6-95ed-42749ac1f389 FINISHED\n")
+	assert.Contains(t, string(stdout), "008fc5ad-adad-42ec-b412-7923733483a8 success\n")
+	assert.NotContains(t, string(stdout), "22222222-adad-42ec-b412-7923733483a8")
+
+	// UUIDs cannot be used with --all-running
+	resetWaitFlags()
+	_, out, err = root.ExecuteTest("compose", "wait", "--all-running", "ddcf50e5-1ffa-4de6-95ed-42749ac1f389")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "--all-running cannot be used with a list of UUIDs")
+}
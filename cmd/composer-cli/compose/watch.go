@@ -0,0 +1,150 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	watchCmd = &cobra.Command{
+		Use:   "watch UUID",
+		Short: "Watch a compose and run commands when its status changes",
+		Long: `Watch a compose, printing each change in its status until it has finished or failed
+
+A command can be run for each change with --on-change, or when the compose reaches
+a status with --on-waiting, --on-running, --on-finished, and --on-failed. The
+commands are run by the shell, with these replaced by the details of the change:
+
+  {id}        UUID of the compose
+  {status}    New status, as reported by the API
+  {previous}  Previous status, empty for the first change
+  {state}     New status as WAITING, RUNNING, FINISHED, or FAILED
+
+Each value is quoted for the shell, so the placeholders should not be put inside
+quotes. They are also set in the COMPOSE_ID, COMPOSE_STATUS, COMPOSE_PREVIOUS, and
+COMPOSE_STATE environment variables. The cloud API's statuses are pending,
+success, and failure, use {state} to get the same status for both APIs. The cloud
+API does not report when a compose is waiting to start, pending is RUNNING, so
+--on-waiting is only used with weldr API composes.`,
+		Example: `  composer-cli compose watch 914bb03b-e4c8-4074-bc31-6869961ee2f3
+  composer-cli compose watch --on-finished 'notify.sh {id}' 914bb03b-e4c8-4074-bc31-6869961ee2f3
+  composer-cli compose watch --on-change 'logger "compose {id} is {state}"' 914bb03b-e4c8-4074-bc31-6869961ee2f3`,
+		RunE: watchCompose,
+		Args: cobra.ExactArgs(1),
+	}
+	watchPollStr string
+	onChange     string
+	onWaiting    string
+	onRunning    string
+	onFinished   string
+	onFailed     string
+)
+
+func init() {
+	watchCmd.Flags().StringVarP(&watchPollStr, "poll", "", "10s", "Polling interval")
+	watchCmd.Flags().StringVarP(&onChange, "on-change", "", "", "Command to run each time the status changes")
+	watchCmd.Flags().StringVarP(&onWaiting, "on-waiting", "", "", "Command to run when the compose is waiting to start, weldr API only")
+	watchCmd.Flags().StringVarP(&onRunning, "on-running", "", "", "Command to run when the compose is running")
+	watchCmd.Flags().StringVarP(&onFinished, "on-finished", "", "", "Command to run when the compose has finished")
+	watchCmd.Flags().StringVarP(&onFailed, "on-failed", "", "", "Command to run when the compose has failed")
+	composeCmd.AddCommand(watchCmd)
+}
+
+func watchCompose(cmd *cobra.Command, args []string) error {
+	interval, err := time.ParseDuration(watchPollStr)
+	if err != nil {
+		return root.ExecutionError(cmd, "poll - %s", err)
+	}
+
+	var events <-chan weldr.ComposeEvent
+	if root.Cloud.Exists() {
+		// Try the UUID with the cloud API first
+		if _, err := root.Cloud.ComposeInfo(args[0]); err == nil {
+			events = root.Cloud.ComposeWatch(args[0], interval)
+		}
+	}
+	if events == nil {
+		events = root.Client.ComposeWatch(args[0], interval)
+	}
+
+	var last weldr.ComposeEvent
+	for ev := range events {
+		if ev.Err != nil {
+			return root.ExecutionError(cmd, "%s", ev.Err)
+		}
+		last = ev
+		if len(ev.Previous) > 0 {
+			fmt.Printf("%s %s %s -> %s\n", ev.Time.Format(time.RFC3339), ev.ID, ev.Previous, ev.Status)
+		} else {
+			fmt.Printf("%s %s %s\n", ev.Time.Format(time.RFC3339), ev.ID, ev.Status)
+		}
+
+		runHook(onChange, ev)
+		switch ev.State {
+		case "WAITING":
+			runHook(onWaiting, ev)
+		case "RUNNING":
+			runHook(onRunning, ev)
+		case "FINISHED":
+			runHook(onFinished, ev)
+		case "FAILED":
+			runHook(onFailed, ev)
+		}
+	}
+	if !last.Done {
+		// The channel is closed without an error when the command is cancelled
+		return root.ExecutionError(cmd, "watch of %s was cancelled", args[0])
+	}
+	if last.State == "FAILED" {
+		return root.ExecutionError(cmd, "compose %s failed", args[0])
+	}
+
+	return nil
+}
+
+// runHook runs a user's command for a compose event
+// The {id}, {status}, {previous}, and {state} placeholders are replaced with the event's
+// details, quoted so that the server's values cannot run other commands. A failing command
+// is reported as a warning, it does not stop the watch.
+func runHook(command string, ev weldr.ComposeEvent) {
+	if len(command) == 0 {
+		return
+	}
+	replacer := strings.NewReplacer(
+		"{id}", shellQuote(ev.ID),
+		"{status}", shellQuote(ev.Status),
+		"{previous}", shellQuote(ev.Previous),
+		"{state}", shellQuote(ev.State))
+
+	hook := exec.Command("sh", "-c", replacer.Replace(command))
+	if os.Stdout != nil {
+		// Stdout is disabled when --json is used
+		hook.Stdout = os.Stdout
+	}
+	hook.Stderr = os.Stderr
+	hook.Env = append(os.Environ(),
+		"COMPOSE_ID="+ev.ID,
+		"COMPOSE_STATUS="+ev.Status,
+		"COMPOSE_PREVIOUS="+ev.Previous,
+		"COMPOSE_STATE="+ev.State)
+	if err := hook.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: %q failed: %s\n", command, err)
+	}
+}
+
+// shellQuote returns the value in single quotes so that the shell uses it as one word
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+// resetWatchFlags sets the watch flags back to their defaults
+func resetWatchFlags() {
+	watchPollStr = "10s"
+	onChange = ""
+	onWaiting = ""
+	onRunning = ""
+	onFinished = ""
+	onFailed = ""
+}
+
+// watchStatusTest returns a mock server that returns each status in turn from compose/info
+func watchStatusTest(statuses []string) func(request *http.Request) (*http.Response, error) {
+	var checks int
+	return func(request *http.Request) (*http.Response, error) {
+		status := statuses[min(checks, len(statuses)-1)]
+		checks++
+		body := fmt.Sprintf(`{"id": "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7", "queue_status": "%s"}`, status)
+		return &http.Response{
+			Request:    request,
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func TestCmdComposeWatch(t *testing.T) {
+	root.SetupCmdTest(watchStatusTest([]string{"WAITING", "RUNNING", "RUNNING", "FINISHED"}))
+	resetWatchFlags()
+	hooks := filepath.Join(t.TempDir(), "hooks.txt")
+
+	cmd, out, err := root.ExecuteTest("compose", "watch", "--poll", "10ms",
+		"--on-change", "echo change {id} {previous} {status} >> "+hooks,
+		"--on-running", "echo running $COMPOSE_STATE >> "+hooks,
+		"--on-finished", "echo finished {state} >> "+hooks,
+		"--on-failed", "echo failed >> "+hooks,
+		"b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, watchCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7 WAITING\n")
+	assert.Contains(t, string(stdout), "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7 WAITING -> RUNNING\n")
+	assert.Contains(t, string(stdout), "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7 RUNNING -> FINISHED\n")
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+
+	data, err := os.ReadFile(hooks)
+	require.Nil(t, err)
+	// The empty previous status is passed as an empty argument
+	assert.Equal(t, `change b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7  WAITING
+change b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7 WAITING RUNNING
+running RUNNING
+change b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7 RUNNING FINISHED
+finished FINISHED
+`, string(data))
+}
+
+func TestRunHookQuoting(t *testing.T) {
+	// The server's values are not run by the shell
+	hooks := filepath.Join(t.TempDir(), "hooks.txt")
+	ev := weldr.ComposeEvent{
+		ID:     "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7",
+		Status: "$(echo injected); echo 'injected'",
+		State:  "RUNNING",
+	}
+	runHook("echo {status} {state} > "+hooks, ev)
+	data, err := os.ReadFile(hooks)
+	require.Nil(t, err)
+	assert.Equal(t, "$(echo injected); echo 'injected' RUNNING\n", string(data))
+}
+
+func TestCmdComposeWatchFailed(t *testing.T) {
+	root.SetupCmdTest(watchStatusTest([]string{"RUNNING", "FAILED"}))
+	resetWatchFlags()
+
+	cmd, out, err := root.ExecuteTest("compose", "watch", "--poll", "10ms",
+		"--on-failed", "echo failed {id}; exit 1",
+		"b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, watchCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "RUNNING -> FAILED\n")
+	assert.Contains(t, string(stdout), "failed b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7\n")
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "WARNING: \"echo failed {id}; exit 1\" failed: exit status 1")
+	assert.Contains(t, string(stderr), "compose b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7 failed")
+}
+
+func TestCmdComposeWatchUnknown(t *testing.T) {
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{
+"status":false,
+"errors":[{"id":"UnknownUUID","msg":"4b668b1a-e6b8-4dce-8828-4a8e3bef2345 is not a valid build uuid"}]
+}`
+		return &http.Response{
+			Request:    request,
+			StatusCode: 400,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+	resetWatchFlags()
+
+	cmd, out, err := root.ExecuteTest("compose", "watch", "4b668b1a-e6b8-4dce-8828-4a8e3bef2345")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, watchCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stdout)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "UnknownUUID: 4b668b1a-e6b8-4dce-8828-4a8e3bef2345 is not a valid build uuid")
+}
+
+func TestCmdComposeWatchCloud(t *testing.T) {
+	var checks int
+	root.SetupCloudCmdTest(func(request *http.Request) (*http.Response, error) {
+		status := "pending"
+		if checks > 2 {
+			status = "success"
+		}
+		checks++
+		body := `{"id": "008fc5ad-adad-42ec-b412-7923733483a8", "kind": "ComposeStatus", "status": "` + status + `"}`
+		return &http.Response{
+			Request:    request,
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	})
+	resetWatchFlags()
+	hooks := filepath.Join(t.TempDir(), "hooks.txt")
+
+	cmd, out, err := root.ExecuteTest("compose", "watch", "--poll", "10ms",
+		"--on-finished", "echo {status} {state} >> "+hooks,
+		"008fc5ad-adad-42ec-b412-7923733483a8")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, watchCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "008fc5ad-adad-42ec-b412-7923733483a8 pending\n")
+	assert.Contains(t, string(stdout), "008fc5ad-adad-42ec-b412-7923733483a8 pending -> success\n")
+	data, err := os.ReadFile(hooks)
+	require.Nil(t, err)
+	assert.Equal(t, "success FINISHED\n", string(data))
+}
@@ -5,8 +5,6 @@
 package distros
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
@@ -24,6 +22,7 @@ var (
 )
 
 func init() {
+	root.EnableOutput(listCmd)
 	distrosCmd.AddCommand(listCmd)
 }
 
@@ -48,8 +47,8 @@ func list(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	for _, name := range distros {
-		fmt.Println(name)
+	if err := root.PrintList("Name", distros); err != nil {
+		return root.ExecutionError(cmd, "%s", err)
 	}
 
 	return nil
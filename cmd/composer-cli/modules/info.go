@@ -34,7 +34,7 @@ func init() {
 func info(cmd *cobra.Command, args []string) error {
 	names := root.GetCommaArgs(args)
 
-	modules, resp, err := root.Client.ModulesInfo(names, distro)
+	modules, resp, err := root.Client.ModulesInfo(names, root.DistroOrDefault(distro))
 	if err != nil {
 		return root.ExecutionError(cmd, "Info Error: %s", err)
 	}
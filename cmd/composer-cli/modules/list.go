@@ -5,8 +5,6 @@
 package modules
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
@@ -30,6 +28,7 @@ var (
 
 func init() {
 	listCmd.Flags().StringVarP(&distro, "distro", "", "", "Return results for distribution")
+	root.EnableOutput(listCmd)
 	modulesCmd.AddCommand(listCmd)
 }
 
@@ -39,9 +38,9 @@ func list(cmd *cobra.Command, args []string) error {
 	var err error
 
 	if len(args) > 0 {
-		modules, resp, err = root.Client.SearchModules(args, distro)
+		modules, resp, err = root.Client.SearchModules(args, root.DistroOrDefault(distro))
 	} else {
-		modules, resp, err = root.Client.ListModules(distro)
+		modules, resp, err = root.Client.ListModules(root.DistroOrDefault(distro))
 	}
 	if err != nil {
 		return root.ExecutionError(cmd, "List Error: %s", err)
@@ -50,8 +49,12 @@ func list(cmd *cobra.Command, args []string) error {
 		return root.ExecutionErrors(cmd, resp.Errors)
 	}
 
+	var names []string
 	for i := range modules {
-		fmt.Println(modules[i].Name)
+		names = append(names, modules[i].Name)
+	}
+	if err := root.PrintList("Name", names); err != nil {
+		return root.ExecutionError(cmd, "%s", err)
 	}
 
 	return nil
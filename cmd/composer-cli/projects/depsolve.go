@@ -35,6 +35,7 @@ var (
 func init() {
 	depsolveCmd.Flags().StringVarP(&distro, "distro", "", "", "Distribution")
 	depsolveCmd.Flags().StringVarP(&arch, "arch", "", "", "Architecture")
+	root.EnableOutput(depsolveCmd)
 	projectsCmd.AddCommand(depsolveCmd)
 }
 
@@ -58,16 +59,11 @@ func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 
 	var err error
 	if root.Cloud.Exists() {
-		if len(distro) == 0 {
-			distro, err = common.GetHostDistroName()
-			if err != nil {
-				return root.ExecutionError(cmd, "Error determining host distribution: %s", err)
-			}
-		}
-
-		if len(arch) == 0 {
-			arch = common.HostArch()
+		distro, err = root.GetDistro(distro)
+		if err != nil {
+			return root.ExecutionError(cmd, "Error determining host distribution: %s", err)
 		}
+		arch = root.GetArch(arch)
 
 		type pkg struct {
 			Name    string `json:"name"`
@@ -90,11 +86,14 @@ func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 		if err != nil {
 			return root.ExecutionError(cmd, "Depsolve Error: %s", err)
 		}
+		if root.StructuredOutput() {
+			return printPackages(cmd, deps)
+		}
 		for _, d := range deps {
 			fmt.Printf("    %s\n", d)
 		}
 	} else {
-		deps, errors, err := root.Client.DepsolveProjects(names, distro)
+		deps, errors, err := root.Client.DepsolveProjects(names, root.DistroOrDefault(distro))
 		if err != nil {
 			return root.ExecutionError(cmd, "Depsolve Error: %s", err)
 		}
@@ -116,9 +115,40 @@ func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 			return root.ExecutionError(cmd, "")
 		}
 
+		if root.StructuredOutput() {
+			packages := []common.PackageNEVRA{}
+			for _, p := range projects {
+				packages = append(packages, common.PackageNEVRA{
+					Name:    p.Name,
+					Epoch:   p.Epoch,
+					Version: p.Version,
+					Release: p.Release,
+					Arch:    p.Arch,
+				})
+			}
+			if err := printPackages(cmd, packages); err != nil {
+				return err
+			}
+			return rcErr
+		}
 		for _, p := range projects {
 			fmt.Printf("    %s\n", p)
 		}
 	}
 	return rcErr
 }
+
+// printPackages prints the depsolved packages using the --output format
+func printPackages(cmd *cobra.Command, packages []common.PackageNEVRA) error {
+	if packages == nil {
+		packages = []common.PackageNEVRA{}
+	}
+	var rows [][]string
+	for _, p := range packages {
+		rows = append(rows, root.PackageFields(p))
+	}
+	if err := root.PrintOutput(packages, root.PackageHeader, rows); err != nil {
+		return root.ExecutionError(cmd, "%s", err)
+	}
+	return nil
+}
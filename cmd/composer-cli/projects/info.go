@@ -10,7 +10,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
-	"github.com/osbuild/weldr-client/v2/internal/common"
 )
 
 var (
@@ -37,16 +36,11 @@ func info(cmd *cobra.Command, args []string) error {
 
 	var err error
 	if root.Cloud.Exists() {
-		if len(distro) == 0 {
-			distro, err = common.GetHostDistroName()
-			if err != nil {
-				return root.ExecutionError(cmd, "Error determining host distribution: %s", err)
-			}
-		}
-
-		if len(arch) == 0 {
-			arch = common.HostArch()
+		distro, err = root.GetDistro(distro)
+		if err != nil {
+			return root.ExecutionError(cmd, "Error determining host distribution: %s", err)
 		}
+		arch = root.GetArch(arch)
 
 		packages, err := root.Cloud.SearchPackages(names, distro, arch)
 		if err != nil {
@@ -63,7 +57,7 @@ func info(cmd *cobra.Command, args []string) error {
 			fmt.Printf("\n\n")
 		}
 	} else {
-		projects, resp, err := root.Client.ProjectsInfo(names, distro)
+		projects, resp, err := root.Client.ProjectsInfo(names, root.DistroOrDefault(distro))
 		if err != nil {
 			return root.ExecutionError(cmd, "Info Error: %s", err)
 		}
@@ -10,7 +10,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
-	"github.com/osbuild/weldr-client/v2/internal/common"
 )
 
 var (
@@ -36,16 +35,11 @@ func init() {
 func list(cmd *cobra.Command, args []string) error {
 	var err error
 	if root.Cloud.Exists() {
-		if len(distro) == 0 {
-			distro, err = common.GetHostDistroName()
-			if err != nil {
-				return root.ExecutionError(cmd, "Error determining host distribution: %s", err)
-			}
-		}
-
-		if len(arch) == 0 {
-			arch = common.HostArch()
+		distro, err = root.GetDistro(distro)
+		if err != nil {
+			return root.ExecutionError(cmd, "Error determining host distribution: %s", err)
 		}
+		arch = root.GetArch(arch)
 
 		packages, err := root.Cloud.SearchPackages([]string{"*"}, distro, arch)
 		if err != nil {
@@ -60,7 +54,7 @@ func list(cmd *cobra.Command, args []string) error {
 			fmt.Printf("\n\n")
 		}
 	} else {
-		projects, resp, err := root.Client.ListProjects(distro)
+		projects, resp, err := root.Client.ListProjects(root.DistroOrDefault(distro))
 		if err != nil {
 			return root.ExecutionError(cmd, "List Error: %s", err)
 		}
@@ -0,0 +1,262 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+
+	"github.com/osbuild/weldr-client/v2/internal/common"
+)
+
+// Config holds the settings from the composer-cli configuration file
+//
+// The file is ~/.config/composer-cli/config.toml and looks like this:
+//
+//	default-profile = "builder"
+//
+//	[profiles.builder]
+//	server-url = "https://builder.example.com:443"
+//	cacert = "/etc/pki/composer/ca.pem"
+//	distro = "rhel-9.4"
+//	arch = "aarch64"
+//	output = "json"
+//	retries = 5
+//	retry-backoff = "1s"
+//
+//	[profiles.builder.bump-rules]
+//	package-removed = "minor"
+type Config struct {
+	DefaultProfile string             `toml:"default-profile"`
+	Profiles       map[string]Profile `toml:"profiles"`
+}
+
+// Profile is a named set of defaults for the commandline flags
+// Flags passed on the commandline override the profile's values
+type Profile struct {
+	Socket          string   `toml:"socket"`
+	CloudSocket     string   `toml:"cloudsocket"`
+	API             *int     `toml:"api"`
+	Timeout         *int     `toml:"timeout"`
+	WeldrOnly       *bool    `toml:"weldr-only"`
+	ServerURL       string   `toml:"server-url"`
+	CACert          string   `toml:"cacert"`
+	Cert            string   `toml:"cert"`
+	Key             string   `toml:"key"`
+	Token           string   `toml:"token"`
+	Distro          string   `toml:"distro"`
+	Arch            string   `toml:"arch"`
+	Output          string   `toml:"output"`
+	Retries         *int     `toml:"retries"`
+	RetryBackoff    string   `toml:"retry-backoff"`
+	RetryMaxBackoff string   `toml:"retry-max-backoff"`
+	RetryJitter     *float64 `toml:"retry-jitter"`
+
+	BumpRules map[string]string `toml:"bump-rules"`
+}
+
+var (
+	// profileName is the --profile selected on the cmdline
+	profileName string
+
+	// profile holds the settings from the selected profile, it is empty if none is used
+	profile Profile
+)
+
+// ConfigPath returns the path to the user's configuration file
+// This can be overridden by setting COMPOSER_CLI_CONFIG
+func ConfigPath() (string, error) {
+	if path := os.Getenv("COMPOSER_CLI_CONFIG"); len(path) > 0 {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "composer-cli", "config.toml"), nil
+}
+
+// LoadConfig reads the configuration file
+// A missing file is not an error, it returns an empty Config
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	_, err := toml.DecodeFile(path, &config)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Config{}, nil
+	} else if err != nil {
+		return Config{}, fmt.Errorf("reading %s - %s", path, err)
+	}
+	return config, nil
+}
+
+// SelectProfile returns the named profile from the configuration
+// If name is empty the COMPOSER_CLI_PROFILE environment variable is used, and then
+// the default-profile from the file. If none of them are set an empty Profile is returned.
+func (c Config) SelectProfile(name string) (Profile, error) {
+	if len(name) == 0 {
+		name = os.Getenv("COMPOSER_CLI_PROFILE")
+	}
+	if len(name) == 0 {
+		name = c.DefaultProfile
+	}
+	if len(name) == 0 {
+		return Profile{}, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile: %s", name)
+	}
+	return p, nil
+}
+
+// applyProfile sets the flag variables from the profile
+// Only the flags that were not set on the commandline are changed
+func applyProfile(flags *pflag.FlagSet, p Profile) error {
+	setString := func(flag string, dst *string, value string) {
+		if len(value) > 0 && !flags.Changed(flag) {
+			*dst = value
+		}
+	}
+	setString("socket", &weldrSocketPath, p.Socket)
+	setString("cloudsocket", &cloudSocketPath, p.CloudSocket)
+	setString("server-url", &serverURL, p.ServerURL)
+	setString("cacert", &caCertPath, p.CACert)
+	setString("cert", &clientCertPath, p.Cert)
+	setString("key", &clientKeyPath, p.Key)
+	setString("token", &serverToken, p.Token)
+
+	if p.API != nil && !flags.Changed("api") {
+		apiVersion = *p.API
+	}
+	if p.Timeout != nil && !flags.Changed("timeout") {
+		httpTimeout = *p.Timeout
+	}
+	if p.WeldrOnly != nil && !flags.Changed("weldr-only") {
+		weldrOnly = *p.WeldrOnly
+	}
+	if p.Retries != nil && !flags.Changed("retries") {
+		retries = *p.Retries
+	}
+	if p.RetryJitter != nil && !flags.Changed("retry-jitter") {
+		retryJitter = *p.RetryJitter
+	}
+
+	// The backoff times are durations, eg. 500ms or 2s
+	setDuration := func(flag string, dst *time.Duration, value string) error {
+		if len(value) == 0 || flags.Changed(flag) {
+			return nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s in profile: %s", flag, value)
+		}
+		*dst = d
+		return nil
+	}
+	if err := setDuration("retry-backoff", &retryBackoff, p.RetryBackoff); err != nil {
+		return err
+	}
+	if err := setDuration("retry-max-backoff", &retryMaxBackoff, p.RetryMaxBackoff); err != nil {
+		return err
+	}
+
+	// output selects the default --output format, not the raw --json output
+	switch format, _, _ := strings.Cut(p.Output, "="); format {
+	case "":
+	case "table", "json", "yaml", "csv", "template":
+		if !flags.Changed("json") && !flags.Changed("output") {
+			outputFormat = p.Output
+			outputFromProfile = true
+		}
+	default:
+		return fmt.Errorf("invalid output in profile: %s, it should be table, json, yaml, csv, or template=TEMPLATE", p.Output)
+	}
+
+	return nil
+}
+
+// initProfile loads the configuration file and applies the selected profile
+// If path is empty the default ConfigPath is used
+func initProfile(flags *pflag.FlagSet, path string) error {
+	profile = Profile{}
+	if len(path) == 0 {
+		var err error
+		path, err = ConfigPath()
+		if err != nil && len(profileName) > 0 {
+			return err
+		} else if err != nil {
+			// No config directory, nothing to load
+			return nil
+		}
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	p, err := config.SelectProfile(profileName)
+	if err != nil {
+		return err
+	}
+	if err := applyProfile(flags, p); err != nil {
+		return err
+	}
+	profile = p
+	return nil
+}
+
+// DistroOrDefault returns distro if it is set, otherwise the profile's default distribution
+// This may be empty, letting the server select the distribution.
+func DistroOrDefault(distro string) string {
+	if len(distro) > 0 {
+		return distro
+	}
+	return profile.Distro
+}
+
+// ArchOrDefault returns arch if it is set, otherwise the profile's default architecture
+// This may be empty, letting the server select the architecture.
+func ArchOrDefault(arch string) string {
+	if len(arch) > 0 {
+		return arch
+	}
+	return profile.Arch
+}
+
+// BumpRules returns the profile's rules for blueprints push --bump as KIND=LEVEL strings
+func BumpRules() []string {
+	var rules []string
+	for kind, level := range profile.BumpRules {
+		rules = append(rules, kind+"="+level)
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+// GetDistro returns the distribution to use
+// This is distro if it is set, otherwise the profile's default or the host's distribution
+func GetDistro(distro string) (string, error) {
+	if d := DistroOrDefault(distro); len(d) > 0 {
+		return d, nil
+	}
+	return common.GetHostDistroName()
+}
+
+// GetArch returns the architecture to use
+// This is arch if it is set, otherwise the profile's default or the host's architecture
+func GetArch(arch string) string {
+	if a := ArchOrDefault(arch); len(a) > 0 {
+		return a
+	}
+	return common.HostArch()
+}
@@ -0,0 +1,236 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `default-profile = "local"
+
+[profiles.local]
+socket = "/run/weldr/test.socket"
+
+[profiles.builder]
+server-url = "https://builder.example.com:443"
+cacert = "/etc/pki/composer/ca.pem"
+api = 0
+timeout = 600
+weldr-only = true
+distro = "rhel-9.4"
+arch = "aarch64"
+output = "json"
+
+[profiles.broken]
+output = "xml"
+`
+
+func writeTestConfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.Nil(t, os.WriteFile(path, []byte(testConfig), 0600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig(writeTestConfig(t))
+	require.Nil(t, err)
+	assert.Equal(t, "local", config.DefaultProfile)
+	require.Equal(t, 3, len(config.Profiles))
+	assert.Equal(t, "https://builder.example.com:443", config.Profiles["builder"].ServerURL)
+	require.NotNil(t, config.Profiles["builder"].Timeout)
+	assert.Equal(t, 600, *config.Profiles["builder"].Timeout)
+	assert.Nil(t, config.Profiles["local"].Timeout)
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	config, err := LoadConfig(filepath.Join(t.TempDir(), "config.toml"))
+	require.Nil(t, err)
+	assert.Equal(t, Config{}, config)
+}
+
+func TestLoadConfigError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.Nil(t, os.WriteFile(path, []byte("[profiles.bad\n"), 0600))
+	_, err := LoadConfig(path)
+	assert.ErrorContains(t, err, "reading "+path)
+}
+
+func TestConfigPath(t *testing.T) {
+	t.Setenv("COMPOSER_CLI_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/test-config")
+	path, err := ConfigPath()
+	require.Nil(t, err)
+	assert.Equal(t, "/tmp/test-config/composer-cli/config.toml", path)
+
+	t.Setenv("COMPOSER_CLI_CONFIG", "/etc/composer-cli.toml")
+	path, err = ConfigPath()
+	require.Nil(t, err)
+	assert.Equal(t, "/etc/composer-cli.toml", path)
+}
+
+func TestSelectProfile(t *testing.T) {
+	config, err := LoadConfig(writeTestConfig(t))
+	require.Nil(t, err)
+
+	// The default profile
+	t.Setenv("COMPOSER_CLI_PROFILE", "")
+	p, err := config.SelectProfile("")
+	require.Nil(t, err)
+	assert.Equal(t, "/run/weldr/test.socket", p.Socket)
+
+	// The environment overrides the default
+	t.Setenv("COMPOSER_CLI_PROFILE", "builder")
+	p, err = config.SelectProfile("")
+	require.Nil(t, err)
+	assert.Equal(t, "https://builder.example.com:443", p.ServerURL)
+
+	// The name overrides the environment
+	p, err = config.SelectProfile("local")
+	require.Nil(t, err)
+	assert.Equal(t, "/run/weldr/test.socket", p.Socket)
+
+	_, err = config.SelectProfile("missing")
+	assert.ErrorContains(t, err, "unknown profile: missing")
+
+	// No profile selected at all
+	t.Setenv("COMPOSER_CLI_PROFILE", "")
+	p, err = Config{}.SelectProfile("")
+	require.Nil(t, err)
+	assert.Equal(t, Profile{}, p)
+}
+
+func TestApplyProfile(t *testing.T) {
+	config, err := LoadConfig(writeTestConfig(t))
+	require.Nil(t, err)
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&serverURL, "server-url", "", "")
+	flags.IntVar(&httpTimeout, "timeout", 240, "")
+	flags.IntVar(&apiVersion, "api", 1, "")
+	flags.BoolVar(&JSONOutput, "json", false, "")
+	flags.StringVar(&outputFormat, "output", "", "")
+	flags.BoolVar(&weldrOnly, "weldr-only", false, "")
+	defer func() {
+		serverURL = ""
+		caCertPath = ""
+		httpTimeout = 240
+		apiVersion = 1
+		JSONOutput = false
+		outputFormat = ""
+		outputFromProfile = false
+		weldrOnly = false
+	}()
+
+	// Flags on the cmdline override the profile
+	require.Nil(t, flags.Parse([]string{"--timeout", "30"}))
+	require.Nil(t, applyProfile(flags, config.Profiles["builder"]))
+	assert.Equal(t, "https://builder.example.com:443", serverURL)
+	assert.Equal(t, "/etc/pki/composer/ca.pem", caCertPath)
+	assert.Equal(t, 30, httpTimeout)
+	assert.Equal(t, 0, apiVersion)
+	assert.Equal(t, "json", outputFormat)
+	assert.True(t, outputFromProfile)
+	assert.False(t, JSONOutput)
+	assert.True(t, weldrOnly)
+
+	assert.ErrorContains(t, applyProfile(flags, config.Profiles["broken"]), "invalid output in profile: xml, it should be table, json, yaml, csv, or template=TEMPLATE")
+	assert.ErrorContains(t, applyProfile(flags, Profile{Output: "text"}), "invalid output in profile: text")
+}
+
+func TestProfileDefaults(t *testing.T) {
+	defer func() { profile = Profile{} }()
+
+	profile = Profile{Distro: "rhel-9.4", Arch: "aarch64"}
+	assert.Equal(t, "rhel-9.4", DistroOrDefault(""))
+	assert.Equal(t, "fedora-41", DistroOrDefault("fedora-41"))
+	assert.Equal(t, "aarch64", ArchOrDefault(""))
+	assert.Equal(t, "x86_64", ArchOrDefault("x86_64"))
+	d, err := GetDistro("")
+	require.Nil(t, err)
+	assert.Equal(t, "rhel-9.4", d)
+	assert.Equal(t, "aarch64", GetArch(""))
+
+	profile = Profile{}
+	assert.Equal(t, "", DistroOrDefault(""))
+	assert.NotEqual(t, "", GetArch(""))
+}
+
+func TestProfileBumpRules(t *testing.T) {
+	defer func() { profile = Profile{} }()
+
+	var config Config
+	_, err := toml.Decode(`[profiles.bumps.bump-rules]
+package-removed = "minor"
+field-changed = "none"
+`, &config)
+	require.Nil(t, err)
+	profile = config.Profiles["bumps"]
+	assert.Equal(t, []string{"field-changed=none", "package-removed=minor"}, BumpRules())
+
+	profile = Profile{}
+	assert.Nil(t, BumpRules())
+}
+
+func TestApplyProfileOutput(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.BoolVar(&JSONOutput, "json", false, "")
+	flags.StringVar(&outputFormat, "output", "", "")
+	defer func() {
+		JSONOutput = false
+		outputFormat = ""
+		outputFromProfile = false
+	}()
+
+	// The --output formats can be selected by the profile
+	require.Nil(t, applyProfile(flags, Profile{Output: "yaml"}))
+	assert.Equal(t, "yaml", outputFormat)
+	assert.True(t, outputFromProfile)
+	assert.False(t, JSONOutput)
+	require.Nil(t, applyProfile(flags, Profile{Output: "template={{.id}}"}))
+	assert.Equal(t, "template={{.id}}", outputFormat)
+
+	// --output on the cmdline overrides the profile's json
+	outputFormat = ""
+	require.Nil(t, flags.Parse([]string{"--output", "csv"}))
+	require.Nil(t, applyProfile(flags, Profile{Output: "json"}))
+	assert.Equal(t, "csv", outputFormat)
+	assert.False(t, JSONOutput)
+}
+
+func TestApplyProfileRetry(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.IntVar(&retries, "retries", 3, "")
+	flags.DurationVar(&retryBackoff, "retry-backoff", 500*time.Millisecond, "")
+	flags.DurationVar(&retryMaxBackoff, "retry-max-backoff", 10*time.Second, "")
+	flags.Float64Var(&retryJitter, "retry-jitter", 0.2, "")
+	defer func() {
+		retries = 3
+		retryBackoff = 500 * time.Millisecond
+		retryMaxBackoff = 10 * time.Second
+		retryJitter = 0.2
+	}()
+
+	five := 5
+	jitter := 0.5
+	p := Profile{Retries: &five, RetryBackoff: "1s", RetryMaxBackoff: "30s", RetryJitter: &jitter}
+
+	// Flags on the cmdline override the profile
+	require.Nil(t, flags.Parse([]string{"--retry-max-backoff", "5s"}))
+	require.Nil(t, applyProfile(flags, p))
+	assert.Equal(t, 5, retries)
+	assert.Equal(t, time.Second, retryBackoff)
+	assert.Equal(t, 5*time.Second, retryMaxBackoff)
+	assert.Equal(t, 0.5, retryJitter)
+
+	assert.ErrorContains(t, applyProfile(flags, Profile{RetryBackoff: "soon"}), "invalid retry-backoff in profile: soon")
+}
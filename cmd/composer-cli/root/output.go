@@ -0,0 +1,219 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/osbuild/weldr-client/v2/internal/common"
+)
+
+var (
+	// outputFormat is the --output selection, the template is parsed by setupOutput
+	outputFormat   string
+	outputTemplate *template.Template
+	// outputFromProfile is true when outputFormat is the profile's default output
+	outputFromProfile bool
+)
+
+// outputAnnotation marks the commands that support --output
+const outputAnnotation = "output"
+
+// templateFuncs are the extra functions available to --output templates
+var templateFuncs = template.FuncMap{
+	// join returns the elements of a list separated by sep
+	"join": func(list []interface{}, sep string) string {
+		var s []string
+		for _, e := range list {
+			s = append(s, fmt.Sprint(e))
+		}
+		return strings.Join(s, sep)
+	},
+}
+
+// setupOutput checks the --output format and parses the template
+func setupOutput() error {
+	outputTemplate = nil
+	format, text, isTemplate := strings.Cut(outputFormat, "=")
+	switch {
+	case isTemplate && format == "template":
+		t, err := template.New("output").Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return fmt.Errorf("--output template: %s", err)
+		}
+		outputTemplate = t
+	case isTemplate:
+		return fmt.Errorf("--output %s does not take a value", format)
+	case format == "template":
+		return fmt.Errorf("--output template requires a template, eg. template='{{range .}}{{.id}}{{end}}'")
+	case format == "", format == "table", format == "json", format == "yaml", format == "csv":
+	default:
+		return fmt.Errorf("unknown --output format %q, use table, json, yaml, csv, or template=TEMPLATE", format)
+	}
+	if JSONOutput && len(format) > 0 {
+		return fmt.Errorf("--json cannot be used with --output")
+	}
+	return nil
+}
+
+// EnableOutput marks a command as supporting the --output formats
+// Selecting json, yaml, csv, or a template for the other commands is an error.
+func EnableOutput(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[outputAnnotation] = "true"
+}
+
+// checkOutput returns an error if --output is used with a command that does not support it
+// The profile's output is a default, the commands that do not support it use their
+// normal output instead.
+func checkOutput(cmd *cobra.Command) error {
+	if !StructuredOutput() || cmd.Annotations[outputAnnotation] == "true" {
+		return nil
+	}
+	if outputFromProfile {
+		outputFormat = ""
+		outputTemplate = nil
+		return nil
+	}
+	return fmt.Errorf("%s does not support --output %s", cmd.CommandPath(), OutputFormat())
+}
+
+// OutputFormat returns the --output format, table, json, yaml, csv, or template
+func OutputFormat() string {
+	format, _, _ := strings.Cut(outputFormat, "=")
+	if len(format) == 0 {
+		return "table"
+	}
+	return format
+}
+
+// StructuredOutput returns true when --output selects json, yaml, csv, or a template
+// The command should then pass its normalized data to PrintOutput instead of printing
+// its normal output.
+func StructuredOutput() bool {
+	return OutputFormat() != "table"
+}
+
+// PrintOutput prints a command's normalized data using the --output format
+// json and yaml output data using the names from its json tags, and a template is
+// executed with the same fields, eg. '{{range .}}{{.id}}{{"\n"}}{{end}}', lists can be
+// joined with '{{join .types ","}}'. csv writes
+// the header and the rows, lists in a field are usually joined with a comma.
+func PrintOutput(data interface{}, header []string, rows [][]string) error {
+	switch OutputFormat() {
+	case "json":
+		out, err := json.MarshalIndent(data, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "yaml":
+		out, err := marshalYAML(data)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
+	case "csv":
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.Write(header); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		fmt.Print(buf.String())
+	case "template":
+		fields, err := jsonFields(data)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := outputTemplate.Execute(&buf, fields); err != nil {
+			return fmt.Errorf("--output template: %s", err)
+		}
+		fmt.Print(buf.String())
+	default:
+		return fmt.Errorf("%s output is printed by the command", OutputFormat())
+	}
+	return nil
+}
+
+// PrintList prints a list of names, one per line, or using the --output format
+// header is the name of the column used for the csv output.
+func PrintList(header string, names []string) error {
+	if !StructuredOutput() {
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	rows := [][]string{}
+	for _, name := range names {
+		rows = append(rows, []string{name})
+	}
+	if names == nil {
+		names = []string{}
+	}
+	return PrintOutput(names, []string{header}, rows)
+}
+
+// PackageHeader is the --output csv header for a list of packages
+var PackageHeader = []string{"Name", "Epoch", "Version", "Release", "Arch"}
+
+// PackageFields returns the --output csv fields for a package
+func PackageFields(pkg common.PackageNEVRA) []string {
+	return []string{pkg.Name, fmt.Sprintf("%d", pkg.Epoch), pkg.Version, pkg.Release, pkg.Arch}
+}
+
+// jsonFields converts data to the generic form of its JSON
+// This is used so that the field names are the same as the json and yaml output.
+func jsonFields(data interface{}) (interface{}, error) {
+	out, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var fields interface{}
+	d := json.NewDecoder(bytes.NewReader(out))
+	d.UseNumber()
+	if err := d.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// marshalYAML converts data to YAML using the names and order of its JSON fields
+// JSON is valid YAML, so it is parsed to keep the order of the fields and then
+// written in the block style.
+func marshalYAML(data interface{}) ([]byte, error) {
+	out, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(out, &node); err != nil {
+		return nil, err
+	}
+	var blockStyle func(n *yaml.Node)
+	blockStyle = func(n *yaml.Node) {
+		n.Style = 0
+		for _, c := range n.Content {
+			blockStyle(c)
+		}
+	}
+	blockStyle(&node)
+	return yaml.Marshal(&node)
+}
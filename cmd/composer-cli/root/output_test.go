@@ -0,0 +1,154 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRecord struct {
+	ID    string   `json:"id"`
+	Size  uint64   `json:"size"`
+	Types []string `json:"types"`
+}
+
+var testRecords = []testRecord{
+	{ID: "008fc5ad-adad-42ec-b412-7923733483a8", Size: 4096, Types: []string{"qcow2", "ami"}},
+	{ID: "1.0", Types: []string{}},
+}
+
+// capturePrintOutput prints the test records using format and returns the output
+func capturePrintOutput(t *testing.T, format string) (string, error) {
+	outputFormat = format
+	defer func() { outputFormat = "" }()
+	if err := setupOutput(); err != nil {
+		return "", err
+	}
+
+	output, err := NewOutputCapture()
+	require.Nil(t, err)
+	defer output.Close()
+	rows := [][]string{
+		{testRecords[0].ID, "4096", "qcow2,ami"},
+		{testRecords[1].ID, "0", ""},
+	}
+	err = PrintOutput(testRecords, []string{"ID", "Size", "Types"}, rows)
+	require.Nil(t, output.Rewind())
+	stdout, rerr := io.ReadAll(output.Stdout)
+	require.Nil(t, rerr)
+	return string(stdout), err
+}
+
+func TestPrintOutputJSON(t *testing.T) {
+	out, err := capturePrintOutput(t, "json")
+	require.Nil(t, err)
+	assert.Equal(t, `[
+    {
+        "id": "008fc5ad-adad-42ec-b412-7923733483a8",
+        "size": 4096,
+        "types": [
+            "qcow2",
+            "ami"
+        ]
+    },
+    {
+        "id": "1.0",
+        "size": 0,
+        "types": []
+    }
+]
+`, out)
+}
+
+func TestPrintOutputYAML(t *testing.T) {
+	out, err := capturePrintOutput(t, "yaml")
+	require.Nil(t, err)
+	assert.Equal(t, `- id: 008fc5ad-adad-42ec-b412-7923733483a8
+  size: 4096
+  types:
+    - qcow2
+    - ami
+- id: "1.0"
+  size: 0
+  types: []
+`, out)
+}
+
+func TestPrintOutputCSV(t *testing.T) {
+	out, err := capturePrintOutput(t, "csv")
+	require.Nil(t, err)
+	assert.Equal(t, "ID,Size,Types\n008fc5ad-adad-42ec-b412-7923733483a8,4096,\"qcow2,ami\"\n1.0,0,\n", out)
+}
+
+func TestPrintOutputTemplate(t *testing.T) {
+	out, err := capturePrintOutput(t, `template={{range .}}{{.id}} {{.size}} {{join .types "+"}}{{"\n"}}{{end}}`)
+	require.Nil(t, err)
+	assert.Equal(t, "008fc5ad-adad-42ec-b412-7923733483a8 4096 qcow2+ami\n1.0 0 \n", out)
+
+	// Unknown fields are printed as <no value>
+	out, err = capturePrintOutput(t, `template={{range .}}{{.missing}}{{end}}`)
+	require.Nil(t, err)
+	assert.Equal(t, "<no value><no value>", out)
+}
+
+func TestSetupOutputErrors(t *testing.T) {
+	defer func() {
+		outputFormat = ""
+		JSONOutput = false
+	}()
+
+	for format, msg := range map[string]string{
+		"xml":                "unknown --output format",
+		"template":           "requires a template",
+		"template={{.id":     "--output template",
+		"csv=yes":            "does not take a value",
+		"template={{.id}}{{": "--output template",
+	} {
+		outputFormat = format
+		assert.ErrorContains(t, setupOutput(), msg, format)
+	}
+
+	outputFormat = "yaml"
+	JSONOutput = true
+	assert.ErrorContains(t, setupOutput(), "--json cannot be used with --output")
+
+	outputFormat = "table"
+	JSONOutput = false
+	assert.Nil(t, setupOutput())
+	assert.False(t, StructuredOutput())
+}
+
+func TestCheckOutput(t *testing.T) {
+	defer func() {
+		outputFormat = ""
+		outputFromProfile = false
+	}()
+	parent := &cobra.Command{Use: "composer-cli"}
+	info := &cobra.Command{Use: "info"}
+	list := &cobra.Command{Use: "list"}
+	parent.AddCommand(info, list)
+	EnableOutput(list)
+	outputFromProfile = false
+
+	// The normal output is always supported
+	outputFormat = "table"
+	assert.Nil(t, checkOutput(info))
+
+	outputFormat = "yaml"
+	assert.Nil(t, checkOutput(list))
+	assert.EqualError(t, checkOutput(info), "composer-cli info does not support --output yaml")
+
+	// The profile's output is only used by the commands that support it
+	outputFromProfile = true
+	assert.Nil(t, checkOutput(list))
+	assert.Equal(t, "yaml", OutputFormat())
+	assert.Nil(t, checkOutput(info))
+	assert.Equal(t, "table", OutputFormat())
+}
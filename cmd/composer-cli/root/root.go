@@ -15,9 +15,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"os/signal"
 	"path"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -47,6 +50,16 @@ var (
 		Use:   path.Base(os.Args[0]),
 		Short: "composer commandline tool",
 		Long:  "commandline tool for osbuild-composer",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Report problems setting up the clients before running the command
+			if initErr != nil {
+				return ExecutionError(cmd, "%s", initErr)
+			}
+			if err := checkOutput(cmd); err != nil {
+				return ExecutionError(cmd, "%s", err)
+			}
+			return nil
+		},
 	}
 	docCmd = &cobra.Command{
 		Use:   "doc DIRECTORY",
@@ -76,10 +89,24 @@ var (
 	// JSONOutput is the state of --json cmdline flag
 	JSONOutput      bool
 	logPath         string
+	logFile         *os.File
+	cancelCtx       context.CancelFunc
+	clientCtx       context.Context
 	weldrSocketPath string
 	cloudSocketPath string
 	testMode        int
 	weldrOnly       bool
+	serverURL       string
+	caCertPath      string
+	clientCertPath  string
+	clientKeyPath   string
+	serverToken     string
+	noProgress      bool
+	retries         int
+	retryBackoff    time.Duration
+	retryMaxBackoff time.Duration
+	retryJitter     float64
+	initErr         error
 
 	// Version is set by the build
 	Version = "DEVEL"
@@ -99,12 +126,24 @@ var (
 func init() {
 	rootCmd.PersistentFlags().IntVarP(&apiVersion, "api", "a", 1, "WELDR Server API Version to use")
 	rootCmd.PersistentFlags().BoolVarP(&JSONOutput, "json", "j", false, "Output the raw JSON response instead of the normal output")
-	rootCmd.PersistentFlags().StringVar(&logPath, "log", "", "Path to optional logfile")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "Output format: table, json, yaml, csv, or template=TEMPLATE")
+	rootCmd.PersistentFlags().StringVar(&logPath, "log", "", "Path to optional logfile, each request is logged as a line of JSON")
 	rootCmd.PersistentFlags().StringVarP(&weldrSocketPath, "socket", "s", "/run/weldr/api.socket", "Path to the WELDR API server's socket file")
 	rootCmd.PersistentFlags().StringVarP(&cloudSocketPath, "cloudsocket", "", "/run/cloudapi/api.socket", "Path to the cloudapi server's socket file")
 	rootCmd.PersistentFlags().IntVar(&testMode, "test", 0, "Pass test mode to compose. 1=Mock compose with fail. 2=Mock compose with finished.")
-	rootCmd.PersistentFlags().IntVar(&httpTimeout, "timeout", 240, "Timeout to use for server communication. Set to 0 for no timeout")
+	rootCmd.PersistentFlags().IntVar(&httpTimeout, "timeout", 240, "Maximum time in seconds to wait for the server to respond. Set to 0 for no timeout")
 	rootCmd.PersistentFlags().BoolVarP(&weldrOnly, "weldr-only", "", false, "Only use the WELDR API; skip using the newer Cloud API")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Name of the configuration profile to use. Defaults to $COMPOSER_CLI_PROFILE")
+	rootCmd.PersistentFlags().StringVar(&serverURL, "server-url", "", "URL of a remote server, eg. https://builder:443, instead of the local sockets")
+	rootCmd.PersistentFlags().StringVar(&caCertPath, "cacert", "", "Path to a CA bundle used to verify the remote server")
+	rootCmd.PersistentFlags().StringVar(&clientCertPath, "cert", "", "Path to a client certificate for the remote server")
+	rootCmd.PersistentFlags().StringVar(&clientKeyPath, "key", "", "Path to the client certificate's key")
+	rootCmd.PersistentFlags().StringVar(&serverToken, "token", "", "Bearer token for an https remote server. Defaults to $COMPOSER_CLI_TOKEN")
+	rootCmd.PersistentFlags().BoolVar(&noProgress, "no-progress", false, "Do not show the progress of file downloads")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 3, "Number of times to retry a request when the server cannot be reached. Set to 0 to disable retries")
+	rootCmd.PersistentFlags().DurationVar(&retryBackoff, "retry-backoff", 500*time.Millisecond, "Time to wait before the first retry, it is doubled for each retry")
+	rootCmd.PersistentFlags().DurationVar(&retryMaxBackoff, "retry-max-backoff", 10*time.Second, "Maximum time to wait between retries")
+	rootCmd.PersistentFlags().Float64Var(&retryJitter, "retry-jitter", 0.2, "Fraction of the retry wait that is randomized, from 0 to 1")
 }
 
 // Init sets up Cobra and adds the doc command to the root cmdline parser
@@ -119,39 +158,151 @@ func Init() {
 }
 
 func initConfig() {
-	initWeldrClient()
-	initCloudClient()
+	initErr = initProfile(rootCmd.PersistentFlags(), "")
+	if err := checkRemoteFlags(); err != nil && initErr == nil {
+		initErr = err
+	}
+	ctx := initContext()
+	initWeldrClient(ctx)
+	initCloudClient(ctx)
 	setupJSONOutput()
+	setupRequestLog()
+	setupProgress()
+	if err := setupRetry(); err != nil && initErr == nil {
+		initErr = err
+	}
+	if err := setupOutput(); err != nil && initErr == nil {
+		initErr = err
+	}
 }
 
-func initWeldrClient() {
-	var ctx context.Context
-	var cancel context.CancelFunc
-	ctx = context.Background()
+// initContext sets up the context shared by the clients for the whole command
+// It is cancelled when the user hits ctrl-c so that requests, waits, and downloads
+// are aborted instead of being left running.
+func initContext() context.Context {
+	if cancelCtx != nil {
+		cancelCtx()
+	}
+	clientCtx, cancelCtx = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	return clientCtx
+}
 
-	if httpTimeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, time.Duration(httpTimeout)*time.Second)
-		defer cancel()
+// Context returns the context used by the clients
+// It is cancelled when the user hits ctrl-c, commands that wait for something other
+// than a request to the server should stop when it is done.
+func Context() context.Context {
+	if clientCtx == nil {
+		return context.Background()
 	}
+	return clientCtx
+}
 
-	Client = weldr.InitClientUnixSocket(ctx, apiVersion, weldrSocketPath)
+// stopContext cancels the client context and stops listening for signals
+func stopContext() {
+	if cancelCtx != nil {
+		cancelCtx()
+		cancelCtx = nil
+	}
 }
 
-func initCloudClient() {
-	var ctx context.Context
-	var cancel context.CancelFunc
-	ctx = context.Background()
+func initWeldrClient(ctx context.Context) {
+	if len(serverURL) > 0 {
+		var err error
+		Client, err = initRemoteWeldrClient(ctx)
+		if err != nil {
+			initErr = err
+			return
+		}
+	} else {
+		Client = weldr.InitClientUnixSocket(ctx, apiVersion, weldrSocketPath)
+	}
+	Client.SetTimeout(time.Duration(httpTimeout) * time.Second)
+}
 
-	if httpTimeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, time.Duration(httpTimeout)*time.Second)
-		defer cancel()
+func initRemoteWeldrClient(ctx context.Context) (weldr.Client, error) {
+	tlsConfig, err := weldr.NewTLSConfig(caCertPath, clientCertPath, clientKeyPath)
+	if err != nil {
+		return weldr.Client{}, err
+	}
+	client, err := weldr.InitClientURL(ctx, apiVersion, serverURL, tlsConfig)
+	if err != nil {
+		return weldr.Client{}, err
 	}
+	client.SetToken(remoteToken())
+	return client, nil
+}
 
+func initCloudClient(ctx context.Context) {
 	if weldrOnly {
 		// Skip the cloudapi by removing the socketPath
 		cloudSocketPath = ""
+	} else if len(serverURL) > 0 {
+		var err error
+		Cloud, err = initRemoteCloudClient(ctx)
+		if err != nil {
+			initErr = err
+			return
+		}
+		Cloud.SetTimeout(time.Duration(httpTimeout) * time.Second)
+		return
 	}
 	Cloud = cloud.InitClientUnixSocket(ctx, cloudSocketPath)
+	Cloud.SetTimeout(time.Duration(httpTimeout) * time.Second)
+}
+
+func initRemoteCloudClient(ctx context.Context) (cloud.Client, error) {
+	tlsConfig, err := cloud.NewTLSConfig(caCertPath, clientCertPath, clientKeyPath)
+	if err != nil {
+		return cloud.Client{}, err
+	}
+	client, err := cloud.InitClientURL(ctx, serverURL, tlsConfig)
+	if err != nil {
+		return cloud.Client{}, err
+	}
+	client.SetToken(remoteToken())
+	return client, nil
+}
+
+// checkRemoteFlags returns an error if the remote server settings cannot be used
+// The certificates and token are only used with an https --server-url, they are an
+// error instead of being ignored. The token is never sent over plain http.
+func checkRemoteFlags() error {
+	settings := []struct{ flag, value string }{
+		{"cacert", caCertPath},
+		{"cert", clientCertPath},
+		{"key", clientKeyPath},
+		{"token", serverToken},
+	}
+	if len(serverURL) == 0 {
+		for _, s := range settings {
+			if len(s.value) > 0 {
+				return fmt.Errorf("--%s can only be used with --server-url", s.flag)
+			}
+		}
+		return nil
+	}
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Scheme == "https" {
+		// Invalid urls are reported when creating the clients
+		return nil
+	}
+	for _, s := range settings[:3] {
+		if len(s.value) > 0 {
+			return fmt.Errorf("--%s can only be used with an https --server-url", s.flag)
+		}
+	}
+	if len(remoteToken()) > 0 {
+		return fmt.Errorf("the token is only sent over https, not to %s", serverURL)
+	}
+	return nil
+}
+
+// remoteToken returns the bearer token from --token or the environment
+func remoteToken() string {
+	if len(serverToken) > 0 {
+		return serverToken
+	}
+	return os.Getenv("COMPOSER_CLI_TOKEN")
 }
 
 // setupJSONOutput configures the callback function and disables Stdout
@@ -193,9 +344,74 @@ func setupJSONOutput() {
 	}
 }
 
+// setupRequestLog opens the --log file and passes it to the clients
+// Every request is appended to it as a line of JSON
+func setupRequestLog() {
+	if len(logPath) == 0 {
+		return
+	}
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: cannot open logfile: %s\n", err)
+		return
+	}
+	logFile = f
+	Client.SetLogWriter(logFile)
+	Cloud.SetLogWriter(logFile)
+}
+
+// setupProgress reports the progress of file downloads on stderr
+// On a terminal the progress line is updated as the file downloads, otherwise a line is
+// printed every few seconds for long downloads.
+func setupProgress() {
+	if noProgress {
+		Client.SetProgressWriter(nil)
+		Cloud.SetProgressWriter(nil)
+		return
+	}
+	Client.SetProgressWriter(os.Stderr)
+	Cloud.SetProgressWriter(os.Stderr)
+}
+
+// setupRetry sets the retry policy of the clients from the --retries flags
+// Each retry is reported on stderr.
+func setupRetry() error {
+	policy := weldr.RetryPolicy{
+		Attempts:   retries + 1,
+		Backoff:    retryBackoff,
+		MaxBackoff: retryMaxBackoff,
+		Jitter:     retryJitter,
+	}
+	if retries < 0 {
+		return fmt.Errorf("--retries must be 0 or more")
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	notify := func(method, route string, retry int, delay time.Duration, err error) {
+		fmt.Fprintf(os.Stderr, "WARNING: %s %s failed: %s, retrying in %v (%d of %d)\n",
+			method, route, err, delay.Round(time.Millisecond), retry, retries)
+	}
+	Client.SetRetryPolicy(policy)
+	Client.SetRetryCallback(notify)
+	Cloud.SetRetryPolicy(policy)
+	Cloud.SetRetryCallback(notify)
+	return nil
+}
+
+// closeRequestLog closes the --log file if it was opened
+func closeRequestLog() {
+	if logFile != nil {
+		logFile.Close() //nolint:errcheck
+		logFile = nil
+	}
+}
+
 // Execute runs the commands on the commandline
 func Execute() error {
 	err := rootCmd.Execute()
+	stopContext()
+	closeRequestLog()
 	if JSONOutput {
 		s, jerr := json.MarshalIndent(jsonResponses, "", "    ")
 		if jerr == nil {
@@ -248,3 +464,9 @@ func GetCommaArgs(args []string) []string {
 	}
 	return result
 }
+
+// IsBlueprintFile returns true if a blueprint argument names a local file instead of a
+// blueprint on the server. It must end in .toml or include a path, eg. ./tmux-image
+func IsBlueprintFile(name string) bool {
+	return strings.HasSuffix(name, ".toml") || strings.ContainsRune(name, os.PathSeparator)
+}
@@ -68,3 +68,47 @@ func TestOutputCapture(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Contains(t, string(stderr), "frobozz")
 }
+
+func TestCheckRemoteFlags(t *testing.T) {
+	defer func() {
+		serverURL = ""
+		caCertPath = ""
+		serverToken = ""
+	}()
+	t.Setenv("COMPOSER_CLI_TOKEN", "")
+
+	assert.Nil(t, checkRemoteFlags())
+
+	// The remote settings are not ignored without --server-url
+	caCertPath = "/etc/pki/composer/ca.pem"
+	assert.ErrorContains(t, checkRemoteFlags(), "--cacert can only be used with --server-url")
+	serverURL = "https://builder.example.com"
+	assert.Nil(t, checkRemoteFlags())
+	serverURL = "http://builder.example.com"
+	assert.ErrorContains(t, checkRemoteFlags(), "--cacert can only be used with an https --server-url")
+
+	// The token is not sent over http
+	caCertPath = ""
+	serverToken = "secret"
+	assert.ErrorContains(t, checkRemoteFlags(), "the token is only sent over https, not to http://builder.example.com")
+	serverURL = ""
+	assert.ErrorContains(t, checkRemoteFlags(), "--token can only be used with --server-url")
+	serverURL = "https://builder.example.com"
+	assert.Nil(t, checkRemoteFlags())
+
+	// The environment token is only checked when a server is used
+	serverToken = ""
+	t.Setenv("COMPOSER_CLI_TOKEN", "secret")
+	serverURL = "http://builder.example.com"
+	assert.ErrorContains(t, checkRemoteFlags(), "the token is only sent over https")
+	serverURL = ""
+	assert.Nil(t, checkRemoteFlags())
+}
+
+func TestIsBlueprintFile(t *testing.T) {
+	assert.True(t, IsBlueprintFile("tmux-image.toml"))
+	assert.True(t, IsBlueprintFile("./tmux-image"))
+	assert.True(t, IsBlueprintFile("/srv/blueprints/tmux-image"))
+	assert.False(t, IsBlueprintFile("tmux-image"))
+	assert.False(t, IsBlueprintFile("NEWEST"))
+}
@@ -12,8 +12,10 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/osbuild/weldr-client/v2/cloud"
 	"github.com/osbuild/weldr-client/v2/weldr"
@@ -34,6 +36,9 @@ type OutputCapture struct {
 var mockWeldrClient weldr.MockClient
 var mockCloudClient cloud.MockClient
 
+// testConfigPath is the configuration file used by the next ExecuteTest
+var testConfigPath string
+
 // NewOutputCapture returns an initialized struct with stdout and stderr redirected to files
 // The user needs to call .Rewind() before reading the output
 // And they need to call .Close() to cleanup the temporary files and
@@ -104,6 +109,24 @@ func ExecuteTest(args ...string) (*cobra.Command, *OutputCapture, error) {
 	JSONOutput = false
 	testMode = 0
 	httpTimeout = 240
+	logPath = ""
+	noProgress = false
+	retries = 3
+	retryBackoff = 500 * time.Millisecond
+	retryMaxBackoff = 10 * time.Second
+	retryJitter = 0.2
+	outputFormat = ""
+	outputFromProfile = false
+	profileName = ""
+	profile = Profile{}
+	serverURL = ""
+	caCertPath = ""
+	clientCertPath = ""
+	clientKeyPath = ""
+	serverToken = ""
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		f.Changed = false
+	})
 
 	rootCmd.SetArgs(args)
 
@@ -112,6 +135,10 @@ func ExecuteTest(args ...string) (*cobra.Command, *OutputCapture, error) {
 		return nil, nil, err
 	}
 	ranCmd, err := rootCmd.ExecuteC()
+	closeRequestLog()
+	stopContext()
+	clientCtx = nil
+	testConfigPath = ""
 
 	// If JSON output was enabled restore the captured Stdout
 	if JSONOutput {
@@ -139,9 +166,27 @@ func cobraInit() {
 	if !cobraInitialized {
 		cobra.OnInitialize(func() {
 			// This function is called at the start of each command execution
+			// Only load a configuration file if the test has set one
+			initErr = nil
+			if len(testConfigPath) > 0 {
+				initErr = initProfile(rootCmd.PersistentFlags(), testConfigPath)
+			}
+			if err := checkRemoteFlags(); err != nil && initErr == nil {
+				initErr = err
+			}
 			Client = weldr.NewClient(context.Background(), &mockWeldrClient, 1, "")
 			Cloud = cloud.NewTestClient(context.Background(), &mockCloudClient, "")
+			Client.SetTimeout(time.Duration(httpTimeout) * time.Second)
+			Cloud.SetTimeout(time.Duration(httpTimeout) * time.Second)
 			setupJSONOutput()
+			setupRequestLog()
+			setupProgress()
+			if err := setupRetry(); err != nil && initErr == nil {
+				initErr = err
+			}
+			if err := setupOutput(); err != nil && initErr == nil {
+				initErr = err
+			}
 		})
 		cobraInitialized = true
 	}
@@ -157,6 +202,19 @@ func SetupCmdTest(f func(request *http.Request) (*http.Response, error)) *weldr.
 	return &mockWeldrClient
 }
 
+// SetupConfigTest sets the configuration file to use for the next ExecuteTest
+// Without it no configuration file is used by the tests.
+func SetupConfigTest(path string) {
+	testConfigPath = path
+}
+
+// SetupCancelTest sets up the context returned by Context for the next ExecuteTest
+// Calling the returned function cancels it, the same as the user hitting ctrl-c.
+func SetupCancelTest() context.CancelFunc {
+	clientCtx, cancelCtx = context.WithCancel(context.Background())
+	return cancelCtx
+}
+
 // SetupCloudCmdTest initializes the cloud client with a Mock Client used to capture test details
 // Pass in a function to be run when the client queries the server. Set cloud test functions.
 func SetupCloudCmdTest(f func(request *http.Request) (*http.Response, error)) *cloud.MockClient {
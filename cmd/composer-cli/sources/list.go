@@ -5,8 +5,6 @@
 package sources
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
@@ -24,6 +22,7 @@ var (
 )
 
 func init() {
+	root.EnableOutput(listCmd)
 	sourcesCmd.AddCommand(listCmd)
 }
 
@@ -36,8 +35,8 @@ func list(cmd *cobra.Command, args []string) error {
 		return root.ExecutionErrors(cmd, resp.Errors)
 	}
 
-	for _, name := range sources {
-		fmt.Println(name)
+	if err := root.PrintList("Name", sources); err != nil {
+		return root.ExecutionError(cmd, "%s", err)
 	}
 
 	return nil
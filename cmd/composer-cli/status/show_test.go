@@ -8,6 +8,9 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -137,3 +140,84 @@ func TestCmdStatusShowJSON(t *testing.T) {
 	assert.Equal(t, "GET", mcc.Req.Method)
 	assert.Equal(t, "/api/image-builder-composer/v2/openapi", mcc.Req.URL.Path)
 }
+
+func TestCmdStatusShowLog(t *testing.T) {
+	// Test the "status show" command with a request log
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"api":"1","db_supported":true,"db_version":"0","schema_version":"0","backend":"osbuild-composer","build":"devel","msgs":[]}`
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	logFile := filepath.Join(t.TempDir(), "requests.log")
+	cmd, out, err := root.ExecuteTest("--log", logFile, "status", "show")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, showCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "API server status:")
+
+	data, err := os.ReadFile(logFile)
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Equal(t, 1, len(lines))
+	assert.Contains(t, lines[0], `"method":"GET"`)
+	assert.Contains(t, lines[0], `"route":"/api/status"`)
+	assert.Contains(t, lines[0], `"status":200`)
+	assert.Contains(t, lines[0], `"latency_ms"`)
+}
+
+func TestCmdStatusShowRetry(t *testing.T) {
+	// Test the "status show" command retrying while the server restarts
+	var attempts int
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return &http.Response{
+				StatusCode: 503,
+				Body:       io.NopCloser(bytes.NewReader([]byte("Service Unavailable"))),
+			}, nil
+		}
+		json := `{"api":"1","db_supported":true,"db_version":"0","schema_version":"0","backend":"osbuild-composer","build":"devel","msgs":[]}`
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	cmd, out, err := root.ExecuteTest("--retries", "2", "--retry-backoff", "1ms", "status", "show")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, showCmd)
+	stdout, err := io.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "API server status:")
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Regexp(t, `^WARNING: GET /api/status failed: server returned status 503, retrying in \S+ \(1 of 2\)\n$`, string(stderr))
+	assert.Equal(t, 2, attempts)
+}
+
+func TestCmdStatusShowRetryError(t *testing.T) {
+	// Test an invalid retry flag
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return nil, nil
+	})
+
+	_, out, err := root.ExecuteTest("--retry-jitter", "2", "status", "show")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stderr, err := io.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "retry jitter must be between 0 and 1")
+}
@@ -17,11 +17,17 @@ type InfoBlueprint struct {
 type Package struct {
 	Name    string `json:"name" toml:"name"`
 	Version string `json:"version,omitempty" toml:"version,omitempty"`
+
+	// Extra holds the fields of a blueprint package that are not part of the schema
+	Extra map[string]interface{} `json:"-" toml:"-"`
 }
 
 // Group specifies a package group.
 type Group struct {
 	Name string `json:"name" toml:"name"`
+
+	// Extra holds the fields of a blueprint group that are not part of the schema
+	Extra map[string]interface{} `json:"-" toml:"-"`
 }
 
 // String returns the name of the package with the optional version
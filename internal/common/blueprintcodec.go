@@ -0,0 +1,380 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+)
+
+// plainBlueprint and plainCustomizations have the same fields without the
+// methods, so that they can be encoded and decoded without recursing.
+type plainBlueprint Blueprint
+type plainCustomizations Customizations
+
+// ParseBlueprintTOML parses a TOML blueprint
+// Fields that are not part of the schema are kept in the Extra maps of the structs they
+// are found in, so that they are written out again by BlueprintTOML and json.Marshal.
+func ParseBlueprintTOML(data []byte) (Blueprint, error) {
+	var p plainBlueprint
+	if _, err := toml.Decode(string(data), &p); err != nil {
+		return Blueprint{}, err
+	}
+	var raw map[string]interface{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return Blueprint{}, err
+	}
+	bp := Blueprint(p)
+	setExtra(reflect.ValueOf(&bp).Elem(), raw, "toml")
+	return bp, nil
+}
+
+// BlueprintTOML returns the blueprint as TOML, including any unknown fields
+func BlueprintTOML(bp Blueprint) ([]byte, error) {
+	return encodeTOML(bp)
+}
+
+// BlueprintFromJSON converts a blueprint that was decoded from JSON into an interface{},
+// like the ones returned by GetBlueprintsJSON, into a Blueprint
+func BlueprintFromJSON(v interface{}) (Blueprint, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Blueprint{}, err
+	}
+	var bp Blueprint
+	if err := json.Unmarshal(data, &bp); err != nil {
+		return Blueprint{}, err
+	}
+	return bp, nil
+}
+
+// UnmarshalTOML is used by toml.Decode and keeps the unknown fields
+// Type errors will not have the correct line number, use ParseBlueprintTOML for that.
+func (bp *Blueprint) UnmarshalTOML(data interface{}) error {
+	raw, ok := data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("blueprint should be a table, not %T", data)
+	}
+	doc, err := encodeTOML(raw)
+	if err != nil {
+		return err
+	}
+	var p plainBlueprint
+	if _, err := toml.Decode(string(doc), &p); err != nil {
+		return err
+	}
+	*bp = Blueprint(p)
+	setExtra(reflect.ValueOf(bp).Elem(), raw, "toml")
+	return nil
+}
+
+// MarshalTOML is used by the toml Encoder and writes the unknown fields with the known ones
+func (bp Blueprint) MarshalTOML() ([]byte, error) {
+	v, err := withExtra(reflect.ValueOf(plainBlueprint(bp)))
+	if err != nil {
+		return nil, err
+	}
+	return encodeTOML(v.Interface())
+}
+
+// MarshalJSON writes the blueprint and its unknown fields
+func (bp Blueprint) MarshalJSON() ([]byte, error) {
+	v, err := withExtra(reflect.ValueOf(plainBlueprint(bp)))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v.Interface())
+}
+
+// UnmarshalJSON reads the blueprint and keeps the unknown fields
+func (bp *Blueprint) UnmarshalJSON(data []byte) error {
+	var p plainBlueprint
+	if err := decodeJSON(data, &p); err != nil {
+		return err
+	}
+	raw, err := decodeJSONNumbers(data)
+	if err != nil {
+		return err
+	}
+	*bp = Blueprint(p)
+	setExtra(reflect.ValueOf(bp).Elem(), raw, "json")
+	return nil
+}
+
+// MarshalJSON writes the customizations and their unknown fields
+func (c Customizations) MarshalJSON() ([]byte, error) {
+	v, err := withExtra(reflect.ValueOf(plainCustomizations(c)))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v.Interface())
+}
+
+// UnmarshalJSON reads the customizations and keeps the unknown fields
+func (c *Customizations) UnmarshalJSON(data []byte) error {
+	var p plainCustomizations
+	if err := decodeJSON(data, &p); err != nil {
+		return err
+	}
+	raw, err := decodeJSONNumbers(data)
+	if err != nil {
+		return err
+	}
+	*c = Customizations(p)
+	setExtra(reflect.ValueOf(c).Elem(), raw, "json")
+	return nil
+}
+
+// structFields returns the types of a struct's fields, using the names from the tag
+// Fields named "-" are skipped.
+func structFields(t reflect.Type, tag string) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		name := tagName(t.Field(i), tag)
+		if name == "" {
+			continue
+		}
+		fields[name] = t.Field(i).Type
+	}
+	return fields
+}
+
+// tagName returns the name of the field from the tag, or "" if it has none or is "-"
+func tagName(f reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// decodeJSONNumbers decodes the JSON data, numbers are kept as json.Number so that
+// integers are not turned into floats.
+func decodeJSONNumbers(data []byte) (interface{}, error) {
+	var raw interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// decodeJSON decodes the JSON data into v and converts the numbers in its interface{}
+// fields, eg. minsize, to int64 or float64 so that integers are not written to TOML as floats.
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	convertNumbers(reflect.ValueOf(v).Elem())
+	return nil
+}
+
+// convertNumbers replaces the json.Number values of interface{} fields with an int64, or
+// a float64 if it is not an integer. The Extra maps are not changed, they keep json.Number.
+func convertNumbers(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			convertNumbers(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			convertNumbers(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Name != "Extra" {
+				convertNumbers(v.Field(i))
+			}
+		}
+	case reflect.Interface:
+		n, ok := v.Interface().(json.Number)
+		if !ok || !v.CanSet() {
+			return
+		}
+		if i, err := n.Int64(); err == nil {
+			v.Set(reflect.ValueOf(i))
+		} else if f, err := n.Float64(); err == nil {
+			v.Set(reflect.ValueOf(f))
+		}
+	}
+}
+
+// setExtra saves the fields in raw that are not part of the struct in its Extra map
+// It does the same for each of the structs, and lists of structs, that the struct contains.
+// raw is the same value decoded into maps and lists, using tag for the field names.
+func setExtra(v reflect.Value, raw interface{}, tag string) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			setExtra(v.Elem(), raw, tag)
+		}
+	case reflect.Slice:
+		items := reflect.ValueOf(raw)
+		if items.Kind() != reflect.Slice {
+			return
+		}
+		for i := 0; i < v.Len() && i < items.Len(); i++ {
+			setExtra(v.Index(i), items.Index(i).Interface(), tag)
+		}
+	case reflect.Struct:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		known := make(map[string]bool)
+		for i := 0; i < v.NumField(); i++ {
+			name := tagName(v.Type().Field(i), tag)
+			if name == "" {
+				continue
+			}
+			known[name] = true
+			if value, ok := m[name]; ok {
+				setExtra(v.Field(i), value, tag)
+			}
+		}
+		var extra map[string]interface{}
+		for k, value := range m {
+			if known[k] {
+				continue
+			}
+			if extra == nil {
+				extra = make(map[string]interface{})
+			}
+			extra[k] = value
+		}
+		if f := v.FieldByName("Extra"); f.IsValid() && f.CanSet() {
+			f.Set(reflect.ValueOf(extra))
+		}
+	}
+}
+
+// hasExtra returns true if the value, or any of the structs in it, has unknown fields
+func hasExtra(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer:
+		return !v.IsNil() && hasExtra(v.Elem())
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if hasExtra(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		if f := v.FieldByName("Extra"); f.IsValid() && f.Len() > 0 {
+			return true
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if hasExtra(v.Field(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withExtra returns the value with the unknown fields added to the end of each struct
+// The structs with unknown fields are copied into new struct types with a field for each of
+// them, so that the json and toml encoders write them after the known fields. Values without
+// any unknown fields are returned unchanged.
+func withExtra(v reflect.Value) (reflect.Value, error) {
+	if !hasExtra(v) {
+		return v, nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		return withExtra(v.Elem())
+	case reflect.Slice:
+		items := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, err := withExtra(v.Index(i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			items[i] = item.Interface()
+		}
+		return reflect.ValueOf(items), nil
+	case reflect.Struct:
+		var fields []reflect.StructField
+		var values []reflect.Value
+		known := make(map[string]bool)
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.Name == "Extra" {
+				continue
+			}
+			known[tagName(f, "json")] = true
+			known[tagName(f, "toml")] = true
+			value, err := withExtra(v.Field(i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			fields = append(fields, reflect.StructField{Name: f.Name, Type: value.Type(), Tag: f.Tag})
+			values = append(values, value)
+		}
+		extra := v.FieldByName("Extra").Interface().(map[string]interface{})
+		keys := make([]string, 0, len(extra))
+		for k := range extra {
+			if !known[k] {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if !validExtraName(k) {
+				return reflect.Value{}, fmt.Errorf("cannot write the unknown field %q", k)
+			}
+			fields = append(fields, reflect.StructField{
+				Name: fmt.Sprintf("Extra%d", i),
+				Type: reflect.TypeOf((*interface{})(nil)).Elem(),
+				Tag:  reflect.StructTag(fmt.Sprintf("json:%s toml:%s", strconv.Quote(k), strconv.Quote(k))),
+			})
+			value := extra[k]
+			values = append(values, reflect.ValueOf(&value).Elem())
+		}
+		s := reflect.New(reflect.StructOf(fields)).Elem()
+		for i, value := range values {
+			s.Field(i).Set(value)
+		}
+		return s, nil
+	}
+	return v, nil
+}
+
+// validExtraName returns true if the name can be used as the name of a field in a struct tag
+// These are the characters allowed by encoding/json, it ignores the names that have others.
+func validExtraName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+// encodeTOML returns the value encoded as a TOML document
+func encodeTOML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
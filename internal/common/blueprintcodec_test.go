@@ -0,0 +1,329 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// futureBlueprint has fields at the top level and in customizations that are not in the schema
+const futureBlueprint = `name = "future"
+version = "0.1.0"
+gadget = "yes"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[widgets]]
+size = 2
+
+[customizations]
+hostname = "server"
+sprocket = 5
+
+[customizations.kernel]
+append = "nosmt=force"
+
+[customizations.flux]
+capacitor = true
+`
+
+func TestParseBlueprintTOML(t *testing.T) {
+	bp, err := ParseBlueprintTOML([]byte(futureBlueprint))
+	require.Nil(t, err)
+	assert.Equal(t, "future", bp.Name)
+	assert.Equal(t, "0.1.0", bp.Version)
+	assert.Equal(t, []Package{{Name: "tmux", Version: "*"}}, bp.Packages)
+	assert.Equal(t, map[string]interface{}{
+		"gadget":  "yes",
+		"widgets": []map[string]interface{}{{"size": int64(2)}},
+	}, bp.Extra)
+	require.NotNil(t, bp.Customizations)
+	require.NotNil(t, bp.Customizations.Hostname)
+	assert.Equal(t, "server", *bp.Customizations.Hostname)
+	assert.Equal(t, "nosmt=force", bp.Customizations.Kernel.Append)
+	assert.Equal(t, map[string]interface{}{
+		"sprocket": int64(5),
+		"flux":     map[string]interface{}{"capacitor": true},
+	}, bp.Customizations.Extra)
+
+	// No unknown fields
+	bp, err = ParseBlueprintTOML([]byte("name = \"plain\"\n[customizations]\nhostname = \"server\"\n"))
+	require.Nil(t, err)
+	assert.Nil(t, bp.Extra)
+	assert.Nil(t, bp.Customizations.Extra)
+}
+
+func TestParseBlueprintTOMLError(t *testing.T) {
+	_, err := ParseBlueprintTOML([]byte("name = \"test\"\nversion = 1\n"))
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = ParseBlueprintTOML([]byte("name = \"test\"\n[[packages]\n"))
+	assert.NotNil(t, err)
+}
+
+func TestBlueprintTOML(t *testing.T) {
+	bp, err := ParseBlueprintTOML([]byte(futureBlueprint))
+	require.Nil(t, err)
+	bp.Version = "0.2.0"
+	data, err := BlueprintTOML(bp)
+	require.Nil(t, err)
+	assert.Equal(t, `name = "future"
+version = "0.2.0"
+gadget = "yes"
+
+[[packages]]
+  name = "tmux"
+  version = "*"
+
+[customizations]
+  hostname = "server"
+  sprocket = 5
+  [customizations.kernel]
+    append = "nosmt=force"
+  [customizations.flux]
+    capacitor = true
+
+[[widgets]]
+  size = 2
+`, string(data))
+
+	// It reads back the same
+	again, err := ParseBlueprintTOML(data)
+	require.Nil(t, err)
+	assert.Equal(t, bp, again)
+}
+
+// nestedFutureBlueprint has fields that are not in the schema inside lists and tables
+const nestedFutureBlueprint = `name = "nested"
+
+[[packages]]
+name = "tmux"
+arch = "x86_64"
+
+[[packages]]
+name = "strace"
+
+[customizations.kernel]
+append = "nosmt=force"
+futurekernel = "yes"
+
+[[customizations.user]]
+name = "admin"
+newuserfield = 1
+
+[customizations.disk]
+type = "gpt"
+
+[[customizations.disk.partitions]]
+type = "lvm"
+
+[[customizations.disk.partitions.logical_volumes]]
+name = "root"
+future_lv = true
+`
+
+func TestParseBlueprintTOMLNested(t *testing.T) {
+	bp, err := ParseBlueprintTOML([]byte(nestedFutureBlueprint))
+	require.Nil(t, err)
+	assert.Nil(t, bp.Extra)
+	require.Len(t, bp.Packages, 2)
+	assert.Equal(t, map[string]interface{}{"arch": "x86_64"}, bp.Packages[0].Extra)
+	assert.Nil(t, bp.Packages[1].Extra)
+	require.NotNil(t, bp.Customizations)
+	assert.Nil(t, bp.Customizations.Extra)
+	assert.Equal(t, map[string]interface{}{"futurekernel": "yes"}, bp.Customizations.Kernel.Extra)
+	assert.Equal(t, map[string]interface{}{"newuserfield": int64(1)}, bp.Customizations.User[0].Extra)
+	lv := bp.Customizations.Disk.Partitions[0].LogicalVolumes[0]
+	assert.Equal(t, "root", lv.Name)
+	assert.Equal(t, map[string]interface{}{"future_lv": true}, lv.Extra)
+}
+
+func TestBlueprintTOMLNested(t *testing.T) {
+	bp, err := ParseBlueprintTOML([]byte(nestedFutureBlueprint))
+	require.Nil(t, err)
+	data, err := BlueprintTOML(bp)
+	require.Nil(t, err)
+	assert.Equal(t, `name = "nested"
+
+[[packages]]
+  name = "tmux"
+  arch = "x86_64"
+
+[[packages]]
+  name = "strace"
+
+[customizations]
+  [customizations.kernel]
+    append = "nosmt=force"
+    futurekernel = "yes"
+
+  [[customizations.user]]
+    name = "admin"
+    newuserfield = 1
+  [customizations.disk]
+    type = "gpt"
+
+    [[customizations.disk.partitions]]
+      type = "lvm"
+
+      [[customizations.disk.partitions.logical_volumes]]
+        name = "root"
+        future_lv = true
+`, string(data))
+
+	// It reads back the same
+	again, err := ParseBlueprintTOML(data)
+	require.Nil(t, err)
+	assert.Equal(t, bp, again)
+}
+
+func TestBlueprintJSONNested(t *testing.T) {
+	bp, err := ParseBlueprintTOML([]byte(nestedFutureBlueprint))
+	require.Nil(t, err)
+	data, err := json.Marshal(bp)
+	require.Nil(t, err)
+	assert.JSONEq(t, `{
+		"name": "nested",
+		"packages": [{"name": "tmux", "arch": "x86_64"}, {"name": "strace"}],
+		"customizations": {
+			"kernel": {"append": "nosmt=force", "futurekernel": "yes"},
+			"user": [{"name": "admin", "newuserfield": 1}],
+			"disk": {
+				"type": "gpt",
+				"partitions": [{"type": "lvm", "logical_volumes": [{"name": "root", "future_lv": true}]}]
+			}
+		}
+	}`, string(data))
+
+	var decoded Blueprint
+	require.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]interface{}{"arch": "x86_64"}, decoded.Packages[0].Extra)
+	assert.Equal(t, map[string]interface{}{"futurekernel": "yes"}, decoded.Customizations.Kernel.Extra)
+	assert.Equal(t, json.Number("1"), decoded.Customizations.User[0].Extra["newuserfield"])
+
+	// And back to the same TOML
+	out, err := BlueprintTOML(decoded)
+	require.Nil(t, err)
+	again, err := ParseBlueprintTOML(out)
+	require.Nil(t, err)
+	assert.Equal(t, bp, again)
+}
+
+func TestBlueprintUnknownFieldName(t *testing.T) {
+	// Names that cannot be written are an error, not silently dropped
+	bp := Blueprint{Name: "test", Packages: []Package{{Name: "tmux", Extra: map[string]interface{}{"a,b": 1}}}}
+	_, err := BlueprintTOML(bp)
+	assert.ErrorContains(t, err, `cannot write the unknown field "a,b"`)
+	_, err = json.Marshal(bp)
+	assert.ErrorContains(t, err, `cannot write the unknown field "a,b"`)
+}
+
+func TestBlueprintTOMLInterface(t *testing.T) {
+	// toml.Unmarshal and the Encoder also keep the unknown fields
+	var bp Blueprint
+	require.Nil(t, toml.Unmarshal([]byte(futureBlueprint), &bp))
+	assert.Equal(t, "yes", bp.Extra["gadget"])
+	assert.Equal(t, int64(5), bp.Customizations.Extra["sprocket"])
+
+	data, err := encodeTOML(bp)
+	require.Nil(t, err)
+	again, err := ParseBlueprintTOML(data)
+	require.Nil(t, err)
+	assert.Equal(t, bp, again)
+}
+
+func TestBlueprintJSON(t *testing.T) {
+	bp, err := ParseBlueprintTOML([]byte(futureBlueprint))
+	require.Nil(t, err)
+	data, err := json.Marshal(bp)
+	require.Nil(t, err)
+	assert.JSONEq(t, `{
+		"name": "future",
+		"version": "0.1.0",
+		"gadget": "yes",
+		"packages": [{"name": "tmux", "version": "*"}],
+		"widgets": [{"size": 2}],
+		"customizations": {
+			"hostname": "server",
+			"sprocket": 5,
+			"kernel": {"append": "nosmt=force"},
+			"flux": {"capacitor": true}
+		}
+	}`, string(data))
+
+	var decoded Blueprint
+	require.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "future", decoded.Name)
+	assert.Equal(t, json.Number("5"), decoded.Customizations.Extra["sprocket"])
+
+	// The numbers are still integers when written as TOML
+	out, err := BlueprintTOML(decoded)
+	require.Nil(t, err)
+	assert.Contains(t, string(out), "sprocket = 5\n")
+	assert.Contains(t, string(out), "[[widgets]]\n  size = 2\n")
+}
+
+func TestBlueprintJSONNumbers(t *testing.T) {
+	// Integers in the fields that can be a number or a string are still integers in the TOML
+	data := `{
+		"name": "sizes",
+		"customizations": {
+			"filesystem": [{"mountpoint": "/var", "minsize": 1073741824}],
+			"disk": {"minsize": 2147483648, "partitions": [{"type": "lvm", "minsize": 1024, "logical_volumes": [{"name": "root", "minsize": 512.5}]}]},
+			"directories": [{"path": "/srv/data", "user": 1000, "group": 1000}],
+			"files": [{"path": "/srv/data/motd", "user": "root", "group": 10}]
+		}
+	}`
+	var bp Blueprint
+	require.Nil(t, json.Unmarshal([]byte(data), &bp))
+	c := bp.Customizations
+	assert.Equal(t, int64(1073741824), c.Filesystem[0].MinSize)
+	assert.Equal(t, int64(2147483648), c.Disk.MinSize)
+	assert.Equal(t, int64(1024), c.Disk.Partitions[0].MinSize)
+	assert.Equal(t, 512.5, c.Disk.Partitions[0].LogicalVolumes[0].MinSize)
+	assert.Equal(t, int64(1000), c.Directories[0].User)
+	assert.Equal(t, "root", c.Files[0].User)
+	assert.Equal(t, int64(10), c.Files[0].Group)
+
+	out, err := BlueprintTOML(bp)
+	require.Nil(t, err)
+	assert.Contains(t, string(out), "minsize = 1073741824\n")
+	assert.Contains(t, string(out), "minsize = 2147483648\n")
+	assert.Contains(t, string(out), "minsize = 1024\n")
+	assert.Contains(t, string(out), "user = 1000\n")
+	assert.Contains(t, string(out), "group = 10\n")
+
+	// It reads back the same
+	again, err := ParseBlueprintTOML(out)
+	require.Nil(t, err)
+	assert.Equal(t, bp, again)
+
+	// Also when the JSON was decoded into an interface{} with float64 numbers
+	var v interface{}
+	require.Nil(t, json.Unmarshal([]byte(data), &v))
+	fromJSON, err := BlueprintFromJSON(v)
+	require.Nil(t, err)
+	assert.Equal(t, bp, fromJSON)
+}
+
+func TestBlueprintFromJSON(t *testing.T) {
+	var v interface{}
+	require.Nil(t, json.Unmarshal([]byte(`{"name": "frozen", "packages": [{"name": "tmux", "version": "3.5a-1.fc41.x86_64"}], "future": 1}`), &v))
+	bp, err := BlueprintFromJSON(v)
+	require.Nil(t, err)
+	assert.Equal(t, "frozen", bp.Name)
+	assert.Equal(t, "tmux-3.5a-1.fc41.x86_64", bp.Packages[0].String())
+	assert.Equal(t, json.Number("1"), bp.Extra["future"])
+
+	_, err = BlueprintFromJSON([]interface{}{"not", "a", "blueprint"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,348 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The kinds of changes between two blueprints
+const (
+	PackageAdded         = "package-added"
+	PackageRemoved       = "package-removed"
+	PackageChanged       = "package-changed"
+	ModuleAdded          = "module-added"
+	ModuleRemoved        = "module-removed"
+	ModuleChanged        = "module-changed"
+	GroupAdded           = "group-added"
+	GroupRemoved         = "group-removed"
+	EnabledModuleAdded   = "enabled-module-added"
+	EnabledModuleRemoved = "enabled-module-removed"
+	EnabledModuleChanged = "enabled-module-changed"
+	ContainerAdded       = "container-added"
+	ContainerRemoved     = "container-removed"
+	ContainerChanged     = "container-changed"
+	CustomizationAdded   = "customization-added"
+	CustomizationRemoved = "customization-removed"
+	CustomizationChanged = "customization-changed"
+	FieldAdded           = "field-added"
+	FieldRemoved         = "field-removed"
+	FieldChanged         = "field-changed"
+	VersionChanged       = "version-changed"
+)
+
+// BlueprintChange is a difference between two blueprints
+// Path is the field that changed, eg. packages or customizations.services.enabled.
+// Name is the name of the item for lists of packages, users, etc. Old and New
+// are the values, Old is nil for additions and New is nil for removals.
+type BlueprintChange struct {
+	Kind string      `json:"kind"`
+	Path string      `json:"path"`
+	Name string      `json:"name,omitempty"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// blueprintLists are the top level lists, with the kind of item and the field used to match them
+var blueprintLists = map[string]struct{ kind, key string }{
+	"packages":        {"package", "name"},
+	"modules":         {"module", "name"},
+	"groups":          {"group", "name"},
+	"enabled_modules": {"enabled-module", "name"},
+	"containers":      {"container", "source"},
+}
+
+// customizationKeys are the fields used to match the items in lists of customizations
+var customizationKeys = map[string]string{
+	"customizations.sshkey":         "user",
+	"customizations.user":           "name",
+	"customizations.group":          "name",
+	"customizations.filesystem":     "mountpoint",
+	"customizations.directories":    "path",
+	"customizations.files":          "path",
+	"customizations.repositories":   "id",
+	"customizations.firewall.zones": "name",
+}
+
+// DiffBlueprints returns the changes made to the from blueprint to get the to blueprint
+// Lists are compared as sets, so changing the order of the packages is not a change.
+func DiffBlueprints(from, to Blueprint) ([]BlueprintChange, error) {
+	a, err := blueprintMap(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := blueprintMap(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var d blueprintDiff
+	for _, key := range unionKeys(a, b) {
+		if key == "version" {
+			if a[key] != b[key] {
+				d.add(VersionChanged, key, "", a[key], b[key])
+			}
+			continue
+		}
+		if l, ok := blueprintLists[key]; ok {
+			d.list(l.kind, key, l.key, a[key], b[key])
+			continue
+		}
+		if key == "customizations" {
+			d.value("customization", key, a[key], b[key])
+			continue
+		}
+		d.value("field", key, a[key], b[key])
+	}
+	return d.changes, nil
+}
+
+// blueprintMap returns the blueprint as a map, the same as its JSON
+func blueprintMap(bp Blueprint) (map[string]interface{}, error) {
+	data, err := json.Marshal(bp)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// unionKeys returns the sorted keys that are in either map
+func unionKeys(a, b map[string]interface{}) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type blueprintDiff struct {
+	changes []BlueprintChange
+}
+
+func (d *blueprintDiff) add(kind, path, name string, before, after interface{}) {
+	d.changes = append(d.changes, BlueprintChange{Kind: kind, Path: path, Name: name, Old: before, New: after})
+}
+
+// list compares a top level list of items, matching them using the key field
+// For packages and modules the version is used for Old and New, unless it is the same
+// and only their other fields changed.
+func (d *blueprintDiff) list(kind, path, key string, a, b interface{}) {
+	al, _ := a.([]interface{})
+	bl, _ := b.([]interface{})
+	if !allKeyed(al, key) || !allKeyed(bl, key) {
+		d.set(kind, path, al, bl)
+		return
+	}
+	from := itemsByKey(a, key)
+	to := itemsByKey(b, key)
+	version := func(item map[string]interface{}) interface{} {
+		if kind == "package" || kind == "module" {
+			return item["version"]
+		}
+		return item
+	}
+
+	for _, name := range unionKeys(toAny(from), toAny(to)) {
+		before, inFrom := from[name]
+		after, inTo := to[name]
+		switch {
+		case !inFrom:
+			d.add(kind+"-added", path, name, nil, version(after))
+		case !inTo:
+			d.add(kind+"-removed", path, name, version(before), nil)
+		case !reflect.DeepEqual(before, after) && reflect.DeepEqual(version(before), version(after)):
+			d.add(kind+"-changed", path, name, before, after)
+		case !reflect.DeepEqual(before, after):
+			d.add(kind+"-changed", path, name, version(before), version(after))
+		}
+	}
+}
+
+// allKeyed returns true if all of the items are tables with the key field
+func allKeyed(list []interface{}, key string) bool {
+	for _, i := range list {
+		item, ok := i.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := item[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// itemsByKey returns the list of tables keyed by the value of the key field
+func itemsByKey(list interface{}, key string) map[string]map[string]interface{} {
+	items := make(map[string]map[string]interface{})
+	l, _ := list.([]interface{})
+	for _, i := range l {
+		item, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := item[key]; ok {
+			items[fmt.Sprintf("%v", name)] = item
+		}
+	}
+	return items
+}
+
+// toAny returns the map with interface{} values so that it can be used with unionKeys
+func toAny(m map[string]map[string]interface{}) map[string]interface{} {
+	r := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		r[k] = v
+	}
+	return r
+}
+
+// value compares two values, descending into tables and lists
+func (d *blueprintDiff) value(kind, path string, a, b interface{}) {
+	_, aMap := a.(map[string]interface{})
+	_, bMap := b.(map[string]interface{})
+	_, aList := a.([]interface{})
+	_, bList := b.([]interface{})
+	switch {
+	case (aMap || a == nil) && (bMap || b == nil) && (aMap || bMap):
+		am, _ := a.(map[string]interface{})
+		bm, _ := b.(map[string]interface{})
+		for _, key := range unionKeys(am, bm) {
+			d.value(kind, joinKey(path, key), am[key], bm[key])
+		}
+	case (aList || a == nil) && (bList || b == nil) && (aList || bList):
+		al, _ := a.([]interface{})
+		bl, _ := b.([]interface{})
+		if key, ok := customizationKeys[path]; ok && allKeyed(al, key) && allKeyed(bl, key) {
+			d.keyedList(kind, path, key, al, bl)
+		} else {
+			d.set(kind, path, al, bl)
+		}
+	case a == nil:
+		d.add(kind+"-added", path, "", nil, b)
+	case b == nil:
+		d.add(kind+"-removed", path, "", a, nil)
+	case !reflect.DeepEqual(a, b):
+		d.add(kind+"-changed", path, "", a, b)
+	}
+}
+
+// keyedList compares lists of tables, matching them using the key field
+// The fields of the tables that are in both lists are compared.
+func (d *blueprintDiff) keyedList(kind, path, key string, a, b []interface{}) {
+	from := itemsByKey(a, key)
+	to := itemsByKey(b, key)
+	for _, name := range unionKeys(toAny(from), toAny(to)) {
+		before, inFrom := from[name]
+		after, inTo := to[name]
+		switch {
+		case !inFrom:
+			d.add(kind+"-added", path, name, nil, after)
+		case !inTo:
+			d.add(kind+"-removed", path, name, before, nil)
+		default:
+			d.value(kind, fmt.Sprintf("%s[%s]", path, name), before, after)
+		}
+	}
+}
+
+// set compares lists as sets, the items that are only in one of them are added or removed
+func (d *blueprintDiff) set(kind, path string, a, b []interface{}) {
+	contains := func(list []interface{}, item interface{}) bool {
+		return slices.ContainsFunc(list, func(i interface{}) bool { return reflect.DeepEqual(i, item) })
+	}
+	for _, item := range a {
+		if !contains(b, item) {
+			d.add(kind+"-removed", path, "", item, nil)
+		}
+	}
+	for _, item := range b {
+		if !contains(a, item) {
+			d.add(kind+"-added", path, "", nil, item)
+		}
+	}
+}
+
+// The version levels, from the smallest change to the largest
+var bumpLevels = []string{"none", "patch", "minor", "major"}
+
+// DefaultBumpRules are the version levels used for each kind of change
+// Removing things may break the users of the image, so it is a major change. Adding
+// things is a minor change, and changing them is a patch.
+var DefaultBumpRules = map[string]string{
+	PackageAdded:         "minor",
+	PackageRemoved:       "major",
+	PackageChanged:       "patch",
+	ModuleAdded:          "minor",
+	ModuleRemoved:        "major",
+	ModuleChanged:        "patch",
+	GroupAdded:           "minor",
+	GroupRemoved:         "major",
+	EnabledModuleAdded:   "minor",
+	EnabledModuleRemoved: "major",
+	EnabledModuleChanged: "patch",
+	ContainerAdded:       "minor",
+	ContainerRemoved:     "major",
+	ContainerChanged:     "patch",
+	CustomizationAdded:   "minor",
+	CustomizationRemoved: "minor",
+	CustomizationChanged: "patch",
+	FieldAdded:           "patch",
+	FieldRemoved:         "patch",
+	FieldChanged:         "patch",
+	VersionChanged:       "none",
+}
+
+// ParseBumpRules returns the default rules changed by a list of KIND=LEVEL rules
+// eg. package-removed=minor, the level is one of none, patch, minor, or major.
+func ParseBumpRules(defaults map[string]string, rules []string) (map[string]string, error) {
+	result := make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		result[k] = v
+	}
+	for _, r := range rules {
+		kind, level, found := strings.Cut(r, "=")
+		kind = strings.TrimSpace(kind)
+		level = strings.TrimSpace(level)
+		if !found {
+			return nil, fmt.Errorf("bump rule %q should be KIND=LEVEL", r)
+		}
+		if _, ok := DefaultBumpRules[kind]; !ok {
+			return nil, fmt.Errorf("unknown kind of change in bump rule: %s", kind)
+		}
+		if !slices.Contains(bumpLevels, level) {
+			return nil, fmt.Errorf("unknown level in bump rule %s, it should be one of %s", r, strings.Join(bumpLevels, ", "))
+		}
+		result[kind] = level
+	}
+	return result, nil
+}
+
+// BumpLevel returns the largest version level used by the changes
+// It returns "none" if none of the changes need a new version.
+func BumpLevel(changes []BlueprintChange, rules map[string]string) string {
+	level := 0
+	for _, c := range changes {
+		if i := slices.Index(bumpLevels, rules[c.Kind]); i > level {
+			level = i
+		}
+	}
+	return bumpLevels[level]
+}
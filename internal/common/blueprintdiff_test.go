@@ -0,0 +1,169 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestBlueprint(t *testing.T, data string) Blueprint {
+	bp, err := ParseBlueprintTOML([]byte(data))
+	require.Nil(t, err)
+	return bp
+}
+
+const diffFromBlueprint = `name = "server"
+description = "A server"
+version = "1.0.0"
+
+[[packages]]
+name = "httpd"
+version = "2.4.*"
+
+[[packages]]
+name = "strace"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[groups]]
+name = "core"
+
+[customizations]
+hostname = "www"
+
+[[customizations.user]]
+name = "admin"
+groups = ["wheel"]
+
+[customizations.services]
+enabled = ["httpd", "sshd"]
+`
+
+func TestDiffBlueprints(t *testing.T) {
+	from := parseTestBlueprint(t, diffFromBlueprint)
+	to := parseTestBlueprint(t, `name = "server"
+description = "The web server"
+version = "1.1.0"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[packages]]
+name = "httpd"
+version = "2.4.62"
+
+[[packages]]
+name = "vim-enhanced"
+
+[[modules]]
+name = "nodejs"
+version = "20"
+
+[customizations]
+hostname = "www"
+
+[[customizations.user]]
+name = "admin"
+groups = ["wheel", "users"]
+
+[[customizations.user]]
+name = "builder"
+
+[customizations.services]
+enabled = ["sshd", "httpd", "cockpit.socket"]
+
+[customizations.kernel]
+append = "nosmt=force"
+`)
+	changes, err := DiffBlueprints(from, to)
+	require.Nil(t, err)
+	assert.Equal(t, []BlueprintChange{
+		{Kind: CustomizationAdded, Path: "customizations.kernel.append", New: "nosmt=force"},
+		{Kind: CustomizationAdded, Path: "customizations.services.enabled", New: "cockpit.socket"},
+		{Kind: CustomizationAdded, Path: "customizations.user[admin].groups", New: "users"},
+		{Kind: CustomizationAdded, Path: "customizations.user", Name: "builder", New: map[string]interface{}{"name": "builder"}},
+		{Kind: FieldChanged, Path: "description", Old: "A server", New: "The web server"},
+		{Kind: GroupRemoved, Path: "groups", Name: "core", Old: map[string]interface{}{"name": "core"}},
+		{Kind: ModuleAdded, Path: "modules", Name: "nodejs", New: "20"},
+		{Kind: PackageChanged, Path: "packages", Name: "httpd", Old: "2.4.*", New: "2.4.62"},
+		{Kind: PackageRemoved, Path: "packages", Name: "strace"},
+		{Kind: PackageAdded, Path: "packages", Name: "vim-enhanced"},
+		{Kind: VersionChanged, Path: "version", Old: "1.0.0", New: "1.1.0"},
+	}, changes)
+}
+
+func TestDiffBlueprintsSame(t *testing.T) {
+	// Reordering the lists is not a change
+	from := parseTestBlueprint(t, diffFromBlueprint)
+	to := parseTestBlueprint(t, `name = "server"
+description = "A server"
+version = "1.0.0"
+packages = [{name = "tmux", version = "*"}, {name = "strace"}, {name = "httpd", version = "2.4.*"}]
+groups = [{name = "core"}]
+
+[customizations]
+hostname = "www"
+user = [{name = "admin", groups = ["wheel"]}]
+services = {enabled = ["sshd", "httpd"]}
+`)
+	changes, err := DiffBlueprints(from, to)
+	require.Nil(t, err)
+	assert.Nil(t, changes)
+}
+
+func TestDiffBlueprintsExtra(t *testing.T) {
+	from := parseTestBlueprint(t, "name = \"future\"\ngadget = 1\n[customizations]\nsprocket = \"small\"\n")
+	to := parseTestBlueprint(t, "name = \"future\"\ngadget = 2\n")
+	changes, err := DiffBlueprints(from, to)
+	require.Nil(t, err)
+	assert.Equal(t, []BlueprintChange{
+		{Kind: CustomizationRemoved, Path: "customizations.sprocket", Old: "small"},
+		{Kind: FieldChanged, Path: "gadget", Old: float64(1), New: float64(2)},
+	}, changes)
+}
+
+func TestDiffBlueprintsNestedExtra(t *testing.T) {
+	// Changes to unknown fields inside lists and tables are found
+	from := parseTestBlueprint(t, "name = \"future\"\n[[packages]]\nname = \"tmux\"\narch = \"x86_64\"\n[customizations.kernel]\nfuturekernel = \"a\"\n")
+	to := parseTestBlueprint(t, "name = \"future\"\n[[packages]]\nname = \"tmux\"\narch = \"aarch64\"\n[customizations.kernel]\nfuturekernel = \"b\"\n")
+	changes, err := DiffBlueprints(from, to)
+	require.Nil(t, err)
+	assert.Equal(t, []BlueprintChange{
+		{Kind: CustomizationChanged, Path: "customizations.kernel.futurekernel", Old: "a", New: "b"},
+		{Kind: PackageChanged, Path: "packages", Name: "tmux",
+			Old: map[string]interface{}{"name": "tmux", "arch": "x86_64"},
+			New: map[string]interface{}{"name": "tmux", "arch": "aarch64"}},
+	}, changes)
+}
+
+func TestBumpLevel(t *testing.T) {
+	assert.Equal(t, "none", BumpLevel(nil, DefaultBumpRules))
+	assert.Equal(t, "none", BumpLevel([]BlueprintChange{{Kind: VersionChanged}}, DefaultBumpRules))
+	assert.Equal(t, "patch", BumpLevel([]BlueprintChange{{Kind: PackageChanged}, {Kind: FieldChanged}}, DefaultBumpRules))
+	assert.Equal(t, "minor", BumpLevel([]BlueprintChange{{Kind: PackageChanged}, {Kind: PackageAdded}}, DefaultBumpRules))
+	assert.Equal(t, "major", BumpLevel([]BlueprintChange{{Kind: PackageRemoved}, {Kind: PackageAdded}}, DefaultBumpRules))
+
+	rules, err := ParseBumpRules(DefaultBumpRules, []string{"package-removed=minor", "field-changed = none"})
+	require.Nil(t, err)
+	assert.Equal(t, "minor", BumpLevel([]BlueprintChange{{Kind: PackageRemoved}}, rules))
+	assert.Equal(t, "none", BumpLevel([]BlueprintChange{{Kind: FieldChanged}}, rules))
+	// The defaults are not changed
+	assert.Equal(t, "major", DefaultBumpRules[PackageRemoved])
+}
+
+func TestParseBumpRulesErrors(t *testing.T) {
+	_, err := ParseBumpRules(DefaultBumpRules, []string{"package-removed"})
+	assert.ErrorContains(t, err, "should be KIND=LEVEL")
+	_, err = ParseBumpRules(DefaultBumpRules, []string{"package-renamed=major"})
+	assert.ErrorContains(t, err, "unknown kind of change")
+	_, err = ParseBumpRules(DefaultBumpRules, []string{"package-added=huge"})
+	assert.ErrorContains(t, err, "unknown level")
+}
@@ -0,0 +1,534 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrBlueprintRewrite is returned when a change cannot be made to the text of the blueprint
+// and BlueprintEditor.Rewrite is not set.
+var ErrBlueprintRewrite = errors.New("the change cannot be made without rewriting the blueprint and losing its comments and formatting")
+
+// BlueprintEditor makes changes to a TOML blueprint
+// The changes are made to the text of the blueprint so that its comments and the order
+// of its fields are kept. When a change cannot be made that way, eg. the packages are an
+// inline array, it returns ErrBlueprintRewrite unless Rewrite is set, in which case the
+// whole blueprint is written out again by BlueprintTOML.
+type BlueprintEditor struct {
+	Rewrite bool
+
+	lines     []string
+	bp        Blueprint
+	rewritten bool
+}
+
+// NewBlueprintEditor returns an editor for the TOML blueprint
+func NewBlueprintEditor(data []byte) (*BlueprintEditor, error) {
+	bp, err := ParseBlueprintTOML(data)
+	if err != nil {
+		return nil, err
+	}
+	return &BlueprintEditor{lines: strings.Split(string(data), "\n"), bp: bp}, nil
+}
+
+// Blueprint returns the edited blueprint
+func (e *BlueprintEditor) Blueprint() Blueprint {
+	return e.bp
+}
+
+// Bytes returns the edited blueprint as TOML
+func (e *BlueprintEditor) Bytes() []byte {
+	return []byte(strings.Join(e.lines, "\n"))
+}
+
+// Rewritten returns true if a change rewrote the whole blueprint
+func (e *BlueprintEditor) Rewritten() bool {
+	return e.rewritten
+}
+
+// apply makes a change to the text and checks that it matches the expected blueprint
+// If it doesn't, and Rewrite is set, the blueprint is encoded as TOML instead. They are
+// compared as TOML so that eg. an empty array matches a missing one, and an int matches
+// an int64. The TOML includes the unknown fields, so a change that loses one of them
+// does not match.
+func (e *BlueprintEditor) apply(bp Blueprint, edit func(d *tomlDoc) error) error {
+	want, err := BlueprintTOML(bp)
+	if err != nil {
+		return err
+	}
+
+	d := &tomlDoc{lines: slices.Clone(e.lines)}
+	err = edit(d)
+	if err == nil {
+		text := []byte(strings.Join(d.lines, "\n"))
+		var parsed Blueprint
+		if parsed, err = ParseBlueprintTOML(text); err == nil {
+			var got []byte
+			if got, err = BlueprintTOML(parsed); err == nil && bytes.Equal(got, want) {
+				e.lines = d.lines
+				e.bp = parsed
+				return nil
+			} else if err == nil {
+				err = fmt.Errorf("the edited blueprint does not match the change")
+			}
+		}
+	}
+	if !e.Rewrite {
+		return fmt.Errorf("%w: %s", ErrBlueprintRewrite, err)
+	}
+
+	parsed, err := ParseBlueprintTOML(want)
+	if err != nil {
+		return err
+	}
+	e.lines = strings.Split(string(want), "\n")
+	e.bp = parsed
+	e.rewritten = true
+	return nil
+}
+
+// customizations returns a copy of the blueprint's customizations that can be changed
+func customizations(bp Blueprint) Customizations {
+	if bp.Customizations == nil {
+		return Customizations{}
+	}
+	return *bp.Customizations
+}
+
+// AddPackage adds a package to the blueprint, or changes the version of an existing package
+// The version is optional.
+func (e *BlueprintEditor) AddPackage(name, version string) error {
+	bp := e.bp
+	i := slices.IndexFunc(bp.Packages, func(p Package) bool { return p.Name == name })
+	if i >= 0 && bp.Packages[i].Version == version {
+		return fmt.Errorf("package %s is already in the blueprint", name)
+	}
+	bp.Packages = slices.Clone(bp.Packages)
+	if i >= 0 {
+		bp.Packages[i].Version = version
+		return e.apply(bp, func(d *tomlDoc) error {
+			var value interface{}
+			if len(version) > 0 {
+				value = version
+			}
+			return d.setKey("packages", i, "version", value)
+		})
+	}
+	pkg := Package{Name: name, Version: version}
+	bp.Packages = append(bp.Packages, pkg)
+	return e.apply(bp, func(d *tomlDoc) error {
+		return d.appendTable("packages", pkg)
+	})
+}
+
+// RemovePackage removes a package from the blueprint
+func (e *BlueprintEditor) RemovePackage(name string) error {
+	bp := e.bp
+	i := slices.IndexFunc(bp.Packages, func(p Package) bool { return p.Name == name })
+	if i < 0 {
+		return fmt.Errorf("package %s is not in the blueprint", name)
+	}
+	bp.Packages = slices.Delete(slices.Clone(bp.Packages), i, i+1)
+	if len(bp.Packages) == 0 {
+		bp.Packages = nil
+	}
+	return e.apply(bp, func(d *tomlDoc) error {
+		return d.removeTable("packages", i)
+	})
+}
+
+// AddGroup adds a package group to the blueprint
+func (e *BlueprintEditor) AddGroup(name string) error {
+	bp := e.bp
+	if slices.ContainsFunc(bp.Groups, func(g Group) bool { return g.Name == name }) {
+		return fmt.Errorf("group %s is already in the blueprint", name)
+	}
+	group := Group{Name: name}
+	bp.Groups = append(slices.Clone(bp.Groups), group)
+	return e.apply(bp, func(d *tomlDoc) error {
+		return d.appendTable("groups", group)
+	})
+}
+
+// AddUser adds a user account to the blueprint's customizations
+func (e *BlueprintEditor) AddUser(user UserCustomization) error {
+	bp := e.bp
+	c := customizations(bp)
+	if slices.ContainsFunc(c.User, func(u UserCustomization) bool { return u.Name == user.Name }) {
+		return fmt.Errorf("user %s is already in the blueprint", user.Name)
+	}
+	c.User = append(slices.Clone(c.User), user)
+	bp.Customizations = &c
+	return e.apply(bp, func(d *tomlDoc) error {
+		return d.appendTable("customizations.user", user)
+	})
+}
+
+// SetHostname sets the hostname in the blueprint's customizations
+func (e *BlueprintEditor) SetHostname(hostname string) error {
+	bp := e.bp
+	c := customizations(bp)
+	c.Hostname = &hostname
+	bp.Customizations = &c
+	return e.apply(bp, func(d *tomlDoc) error {
+		return d.setKey("customizations", 0, "hostname", hostname)
+	})
+}
+
+// EnableService adds a systemd service to the services to enable
+// It is also removed from the disabled and masked services.
+func (e *BlueprintEditor) EnableService(name string) error {
+	bp := e.bp
+	c := customizations(bp)
+	var old ServicesCustomization
+	if c.Services != nil {
+		old = *c.Services
+	}
+	if slices.Contains(old.Enabled, name) {
+		return fmt.Errorf("service %s is already enabled", name)
+	}
+	services := ServicesCustomization{
+		Enabled:  append(slices.Clone(old.Enabled), name),
+		Disabled: withoutString(old.Disabled, name),
+		Masked:   withoutString(old.Masked, name),
+	}
+	c.Services = &services
+	bp.Customizations = &c
+
+	return e.apply(bp, func(d *tomlDoc) error {
+		if err := d.setKey("customizations.services", 0, "enabled", services.Enabled); err != nil {
+			return err
+		}
+		if len(services.Disabled) != len(old.Disabled) {
+			err := d.setKey("customizations.services", 0, "disabled", listValue(services.Disabled))
+			if err != nil {
+				return err
+			}
+		}
+		if len(services.Masked) != len(old.Masked) {
+			return d.setKey("customizations.services", 0, "masked", listValue(services.Masked))
+		}
+		return nil
+	})
+}
+
+// listValue returns the list as a value for setKey, an empty list removes the key
+func listValue(list []string) interface{} {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// withoutString returns the list without the string, or nil if nothing is left
+func withoutString(list []string, s string) []string {
+	var result []string
+	for _, l := range list {
+		if l != s {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// AddFile adds a file to the blueprint's customizations
+func (e *BlueprintEditor) AddFile(file FileCustomization) error {
+	bp := e.bp
+	c := customizations(bp)
+	if slices.ContainsFunc(c.Files, func(f FileCustomization) bool { return f.Path == file.Path }) {
+		return fmt.Errorf("file %s is already in the blueprint", file.Path)
+	}
+	c.Files = append(slices.Clone(c.Files), file)
+	bp.Customizations = &c
+	return e.apply(bp, func(d *tomlDoc) error {
+		return d.appendTable("customizations.files", file)
+	})
+}
+
+// SetVersion sets the version of the blueprint
+func (e *BlueprintEditor) SetVersion(version string) error {
+	bp := e.bp
+	bp.Version = version
+	return e.apply(bp, func(d *tomlDoc) error {
+		return d.setKey("", 0, "version", version)
+	})
+}
+
+// BumpVersion increments the major, minor, or patch part of the blueprint's version
+func (e *BlueprintEditor) BumpVersion(level string) error {
+	version, err := BumpVersion(e.bp.Version, level)
+	if err != nil {
+		return err
+	}
+	return e.SetVersion(version)
+}
+
+// BumpVersion increments the major, minor, or patch part of a semantic version
+// The parts after the one that is incremented are reset to 0. An empty version
+// is treated as 0.0.0
+func BumpVersion(version, level string) (string, error) {
+	if len(version) == 0 {
+		version = "0.0.0"
+	}
+	if !semverRegex.MatchString(version) {
+		return "", fmt.Errorf("version %q is not a semantic version, eg. 0.0.1", version)
+	}
+	var parts [3]int
+	for i, s := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return "", fmt.Errorf("version %q: %s", version, err)
+		}
+		parts[i] = n
+	}
+	switch level {
+	case "major":
+		parts = [3]int{parts[0] + 1, 0, 0}
+	case "minor":
+		parts = [3]int{parts[0], parts[1] + 1, 0}
+	case "patch":
+		parts[2]++
+	default:
+		return "", fmt.Errorf("unknown version level %q, it should be major, minor, or patch", level)
+	}
+	return fmt.Sprintf("%d.%d.%d", parts[0], parts[1], parts[2]), nil
+}
+
+// tomlDoc is the text of a TOML document that is being edited
+type tomlDoc struct {
+	lines []string
+}
+
+// tomlItem is a table header or a key in a TOML document
+type tomlItem struct {
+	table string // Name of the table for headers, or the table that the key is in
+	key   string // Empty for headers
+	array bool   // Header of an array of tables
+	first int    // Index of the first line
+	last  int    // Index of the last line, values can continue over several lines
+}
+
+// items returns the headers and keys in the document
+func (d *tomlDoc) items() []tomlItem {
+	var items []tomlItem
+	var table string
+	var depth int        // Depth of brackets in a value that continues on the next line
+	var multiline string // Quotes of a multiline string that continues on the next line
+	for i, text := range d.lines {
+		if len(multiline) > 0 {
+			if strings.Contains(text, multiline) {
+				multiline = ""
+			}
+			items[len(items)-1].last = i
+			continue
+		}
+		if depth > 0 {
+			depth += bracketDepth(text)
+			items[len(items)-1].last = i
+			continue
+		}
+		s := strings.TrimSpace(text)
+		switch {
+		case len(s) == 0 || s[0] == '#':
+		case strings.HasPrefix(s, "[["):
+			name, _, _ := strings.Cut(s[2:], "]]")
+			table = strings.Join(splitKey(name), ".")
+			items = append(items, tomlItem{table: table, array: true, first: i, last: i})
+		case s[0] == '[':
+			name, _, _ := strings.Cut(s[1:], "]")
+			table = strings.Join(splitKey(name), ".")
+			items = append(items, tomlItem{table: table, first: i, last: i})
+		default:
+			key, value, found := strings.Cut(s, "=")
+			if !found {
+				continue
+			}
+			items = append(items, tomlItem{table: table, key: strings.Join(splitKey(key), "."), first: i, last: i})
+			value = strings.TrimSpace(value)
+			for _, q := range []string{`"""`, `'''`} {
+				if strings.HasPrefix(value, q) && !strings.Contains(value[3:], q) {
+					multiline = q
+				}
+			}
+			if len(multiline) == 0 {
+				depth = bracketDepth(value)
+			}
+		}
+	}
+	return items
+}
+
+// section returns the header and keys of the nth table with the name
+// The top level table is "", it has no header and its header is returned as nil.
+func (d *tomlDoc) section(table string, n int) (header *tomlItem, keys []tomlItem, found bool) {
+	var in bool
+	if len(table) == 0 {
+		in, found = true, true
+	}
+	for _, item := range d.items() {
+		if len(item.key) == 0 {
+			if in {
+				break
+			}
+			if item.table == table {
+				if n == 0 {
+					header, in, found = &item, true, true
+					continue
+				}
+				n--
+			}
+			continue
+		}
+		if in {
+			keys = append(keys, item)
+		}
+	}
+	return header, keys, found
+}
+
+// end returns the index after the last line with content
+func (d *tomlDoc) end() int {
+	end := len(d.lines)
+	for end > 0 && len(strings.TrimSpace(d.lines[end-1])) == 0 {
+		end--
+	}
+	return end
+}
+
+// insert adds the lines at the index
+func (d *tomlDoc) insert(i int, lines ...string) {
+	d.lines = slices.Insert(d.lines, i, lines...)
+}
+
+// keyValue returns the TOML for the key and value
+func keyValue(key string, value interface{}) ([]string, error) {
+	data, err := encodeTOML(map[string]interface{}{key: value})
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n"), nil
+}
+
+// indent returns the whitespace at the start of the line
+func indent(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// setKey sets the value of a key in the nth table with the name, a nil value removes it
+// If the table is not in the document it is added to the end of it.
+func (d *tomlDoc) setKey(table string, n int, key string, value interface{}) error {
+	header, keys, found := d.section(table, n)
+	i := slices.IndexFunc(keys, func(item tomlItem) bool { return item.key == key })
+	if value == nil {
+		if i >= 0 {
+			d.lines = slices.Delete(d.lines, keys[i].first, keys[i].last+1)
+		}
+		return nil
+	}
+	lines, err := keyValue(key, value)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case i >= 0:
+		lines[0] = indent(d.lines[keys[i].first]) + lines[0]
+		d.lines = slices.Replace(d.lines, keys[i].first, keys[i].last+1, lines...)
+	case found && len(keys) > 0:
+		lines[0] = indent(d.lines[keys[len(keys)-1].first]) + lines[0]
+		d.insert(keys[len(keys)-1].last+1, lines...)
+	case found && header != nil:
+		d.insert(header.last+1, lines...)
+	case found:
+		d.insert(0, lines...)
+	default:
+		end := d.end()
+		d.insert(end, append([]string{"", fmt.Sprintf("[%s]", table)}, lines...)...)
+	}
+	return nil
+}
+
+// appendTable adds the value to the end of an array of tables
+// An empty inline array is replaced, other inline arrays cannot be added to.
+func (d *tomlDoc) appendTable(table string, value interface{}) error {
+	data, err := encodeTOML(value)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+
+	parent, name := "", table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		parent, name = table[:i], table[i+1:]
+	}
+	_, keys, _ := d.section(parent, 0)
+	if i := slices.IndexFunc(keys, func(item tomlItem) bool { return item.key == name }); i >= 0 {
+		_, v, _ := strings.Cut(d.lines[keys[i].first], "=")
+		if strings.ReplaceAll(strings.TrimSpace(v), " ", "") != "[]" || keys[i].first != keys[i].last {
+			return fmt.Errorf("%s is an inline array", table)
+		}
+		d.lines = slices.Delete(d.lines, keys[i].first, keys[i].last+1)
+	}
+
+	// Add it after the last table in the array, using the same indentation
+	at := d.end()
+	var prefix string
+	for n := 0; ; n++ {
+		header, keys, found := d.section(table, n)
+		if !found || header == nil || !header.array {
+			break
+		}
+		at = header.last + 1
+		if len(keys) > 0 {
+			at = keys[len(keys)-1].last + 1
+			prefix = indent(d.lines[keys[0].first])
+		}
+	}
+	for i := range lines {
+		lines[i] = prefix + lines[i]
+	}
+	d.insert(at, append([]string{"", fmt.Sprintf("[[%s]]", table)}, lines...)...)
+	return nil
+}
+
+// removeTable removes the nth table from an array of tables
+func (d *tomlDoc) removeTable(table string, n int) error {
+	header, _, found := d.section(table, n)
+	if !found || header == nil || !header.array {
+		return fmt.Errorf("%s[%d] is not a table", table, n)
+	}
+	// Remove the table with the comments before it, up to the comments before the
+	// next table. The last table also takes the blank lines before it.
+	start, end := header.first, d.end()
+	for start > 0 && isComment(d.lines[start-1]) {
+		start--
+	}
+	next := slices.IndexFunc(d.items(), func(item tomlItem) bool {
+		return len(item.key) == 0 && item.first > header.first
+	})
+	if next >= 0 {
+		end = d.items()[next].first
+		for end > header.last+1 && isComment(d.lines[end-1]) {
+			end--
+		}
+	} else {
+		for start > 0 && len(strings.TrimSpace(d.lines[start-1])) == 0 {
+			start--
+		}
+	}
+	d.lines = slices.Delete(d.lines, start, end)
+	return nil
+}
+
+// isComment returns true if the line only has a comment
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "#")
+}
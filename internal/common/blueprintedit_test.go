@@ -0,0 +1,258 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commentedBlueprint is a hand written blueprint with comments
+const commentedBlueprint = `# The web server image
+name = "httpd-server"
+description = "Apache web server"
+version = "1.2.3"
+
+# The web server
+[[packages]]
+name = "httpd"
+version = "*"
+
+# For debugging
+[[packages]]
+name = "strace"
+
+[customizations]
+hostname = "www" # changed by ansible
+
+[customizations.services]
+enabled = ["httpd"]
+disabled = ["cups", "sshd"]
+`
+
+// serverBlueprint is how the server writes a blueprint without packages
+const serverBlueprint = `name = "base"
+description = ""
+version = "0.0.1"
+modules = []
+groups = []
+packages = []
+`
+
+func newEditor(t *testing.T, data string) *BlueprintEditor {
+	e, err := NewBlueprintEditor([]byte(data))
+	require.Nil(t, err)
+	return e
+}
+
+func TestEditAddPackage(t *testing.T) {
+	e := newEditor(t, commentedBlueprint)
+	require.Nil(t, e.AddPackage("tmux", "3.5"))
+	assert.Equal(t, `# The web server image
+name = "httpd-server"
+description = "Apache web server"
+version = "1.2.3"
+
+# The web server
+[[packages]]
+name = "httpd"
+version = "*"
+
+# For debugging
+[[packages]]
+name = "strace"
+
+[[packages]]
+name = "tmux"
+version = "3.5"
+
+[customizations]
+hostname = "www" # changed by ansible
+
+[customizations.services]
+enabled = ["httpd"]
+disabled = ["cups", "sshd"]
+`, string(e.Bytes()))
+	assert.Equal(t, []Package{{Name: "httpd", Version: "*"}, {Name: "strace"}, {Name: "tmux", Version: "3.5"}}, e.Blueprint().Packages)
+
+	// Adding it again changes the version
+	require.Nil(t, e.AddPackage("strace", "6.*"))
+	assert.Contains(t, string(e.Bytes()), "# For debugging\n[[packages]]\nname = \"strace\"\nversion = \"6.*\"\n\n[[packages]]\nname = \"tmux\"")
+	assert.ErrorContains(t, e.AddPackage("strace", "6.*"), "already in the blueprint")
+}
+
+func TestEditAddPackageServer(t *testing.T) {
+	e := newEditor(t, serverBlueprint)
+	require.Nil(t, e.AddPackage("tmux", ""))
+	require.Nil(t, e.AddGroup("core"))
+	assert.Equal(t, `name = "base"
+description = ""
+version = "0.0.1"
+modules = []
+
+[[packages]]
+name = "tmux"
+
+[[groups]]
+name = "core"
+`, string(e.Bytes()))
+}
+
+func TestEditInlineArray(t *testing.T) {
+	// Inline arrays of tables cannot be edited, it is an error unless Rewrite is set
+	inline := "# Packages are inline\nname = \"inline\"\npackages = [{name = \"tmux\"}]\n"
+	e := newEditor(t, inline)
+	err := e.AddPackage("vim", "")
+	assert.True(t, errors.Is(err, ErrBlueprintRewrite))
+	assert.ErrorContains(t, err, "packages is an inline array")
+	assert.Equal(t, inline, string(e.Bytes()))
+	assert.False(t, e.Rewritten())
+
+	// The blueprint is rewritten without the comments
+	e.Rewrite = true
+	require.Nil(t, e.AddPackage("vim", ""))
+	assert.True(t, e.Rewritten())
+	assert.Equal(t, `name = "inline"
+
+[[packages]]
+  name = "tmux"
+
+[[packages]]
+  name = "vim"
+`, string(e.Bytes()))
+}
+
+func TestEditNestedUnknown(t *testing.T) {
+	// Fields that are not in the schema are kept by the edits
+	e := newEditor(t, "name = \"future\"\n\n[[packages]]\nname = \"tmux\"\narch = \"x86_64\"\n")
+	require.Nil(t, e.AddPackage("tmux", "3.5"))
+	assert.Equal(t, "name = \"future\"\n\n[[packages]]\nname = \"tmux\"\narch = \"x86_64\"\nversion = \"3.5\"\n", string(e.Bytes()))
+	assert.Equal(t, map[string]interface{}{"arch": "x86_64"}, e.Blueprint().Packages[0].Extra)
+	assert.False(t, e.Rewritten())
+}
+
+func TestEditRemovePackage(t *testing.T) {
+	e := newEditor(t, commentedBlueprint)
+	require.Nil(t, e.RemovePackage("httpd"))
+	assert.Equal(t, `# The web server image
+name = "httpd-server"
+description = "Apache web server"
+version = "1.2.3"
+
+# For debugging
+[[packages]]
+name = "strace"
+
+[customizations]
+hostname = "www" # changed by ansible
+
+[customizations.services]
+enabled = ["httpd"]
+disabled = ["cups", "sshd"]
+`, string(e.Bytes()))
+
+	require.Nil(t, e.RemovePackage("strace"))
+	assert.Nil(t, e.Blueprint().Packages)
+	assert.Contains(t, string(e.Bytes()), "version = \"1.2.3\"\n\n[customizations]\n")
+	assert.ErrorContains(t, e.RemovePackage("strace"), "not in the blueprint")
+
+	// Removing the last table in the document
+	e = newEditor(t, "name = \"last\"\n\n[[packages]]\nname = \"tmux\"\n")
+	require.Nil(t, e.RemovePackage("tmux"))
+	assert.Equal(t, "name = \"last\"\n", string(e.Bytes()))
+}
+
+func TestEditCustomizations(t *testing.T) {
+	e := newEditor(t, commentedBlueprint)
+	require.Nil(t, e.SetHostname("web01"))
+	require.Nil(t, e.EnableService("sshd"))
+	uid := 1000
+	require.Nil(t, e.AddUser(UserCustomization{Name: "admin", Groups: []string{"wheel"}, UID: &uid}))
+	require.Nil(t, e.AddFile(FileCustomization{Path: "/etc/motd", Mode: "0644", Data: "Welcome\n"}))
+	assert.Equal(t, `# The web server image
+name = "httpd-server"
+description = "Apache web server"
+version = "1.2.3"
+
+# The web server
+[[packages]]
+name = "httpd"
+version = "*"
+
+# For debugging
+[[packages]]
+name = "strace"
+
+[customizations]
+hostname = "web01"
+
+[customizations.services]
+enabled = ["httpd", "sshd"]
+disabled = ["cups"]
+
+[[customizations.user]]
+name = "admin"
+groups = ["wheel"]
+uid = 1000
+
+[[customizations.files]]
+path = "/etc/motd"
+mode = "0644"
+data = "Welcome\n"
+`, string(e.Bytes()))
+
+	assert.ErrorContains(t, e.EnableService("sshd"), "already enabled")
+	assert.ErrorContains(t, e.AddUser(UserCustomization{Name: "admin"}), "already in the blueprint")
+	assert.ErrorContains(t, e.AddFile(FileCustomization{Path: "/etc/motd"}), "already in the blueprint")
+}
+
+func TestEditNewCustomizations(t *testing.T) {
+	e := newEditor(t, "name = \"empty\"\n")
+	require.Nil(t, e.SetHostname("server"))
+	require.Nil(t, e.EnableService("sshd"))
+	assert.Equal(t, `name = "empty"
+
+[customizations]
+hostname = "server"
+
+[customizations.services]
+enabled = ["sshd"]
+`, string(e.Bytes()))
+}
+
+func TestEditVersion(t *testing.T) {
+	e := newEditor(t, commentedBlueprint)
+	require.Nil(t, e.BumpVersion("minor"))
+	assert.Equal(t, "1.3.0", e.Blueprint().Version)
+	assert.Contains(t, string(e.Bytes()), "description = \"Apache web server\"\nversion = \"1.3.0\"\n\n# The web server\n")
+
+	e = newEditor(t, "name = \"unversioned\"\n\n[[packages]]\nname = \"tmux\"\n")
+	require.Nil(t, e.BumpVersion("patch"))
+	assert.Equal(t, "name = \"unversioned\"\nversion = \"0.0.1\"\n\n[[packages]]\nname = \"tmux\"\n", string(e.Bytes()))
+	assert.ErrorContains(t, e.BumpVersion("huge"), "unknown version level")
+}
+
+func TestBumpVersion(t *testing.T) {
+	for _, tc := range []struct {
+		version string
+		level   string
+		result  string
+	}{
+		{"1.2.3", "patch", "1.2.4"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3", "major", "2.0.0"},
+		{"", "patch", "0.0.1"},
+		{"0.9.10", "patch", "0.9.11"},
+	} {
+		v, err := BumpVersion(tc.version, tc.level)
+		require.Nil(t, err)
+		assert.Equal(t, tc.result, v, tc.version+" "+tc.level)
+	}
+	_, err := BumpVersion("1.0", "patch")
+	assert.ErrorContains(t, err, "not a semantic version")
+}
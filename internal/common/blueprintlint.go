@@ -0,0 +1,542 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LintProblem is a problem found in a blueprint by LintBlueprintTOML
+type LintProblem struct {
+	Line     int    `json:"line"`          // Line of the problem, 0 when it is not for a specific line
+	Key      string `json:"key,omitempty"` // Path to the key, eg. customizations.user[0].name
+	Severity string `json:"severity"`      // error or warning
+	Message  string `json:"message"`
+}
+
+// String returns the problem as line: severity: message
+func (p LintProblem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%d: %s: %s", p.Line, p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+}
+
+// LintErrors returns true if any of the problems is an error and not just a warning
+func LintErrors(problems []LintProblem) bool {
+	return slices.ContainsFunc(problems, func(p LintProblem) bool { return p.Severity == "error" })
+}
+
+// linter collects the problems found in a blueprint
+type linter struct {
+	problems []LintProblem
+}
+
+func (l *linter) errorf(key, format string, args ...interface{}) {
+	l.problems = append(l.problems, LintProblem{Key: key, Severity: "error", Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) warnf(key, format string, args ...interface{}) {
+	l.problems = append(l.problems, LintProblem{Key: key, Severity: "warning", Message: fmt.Sprintf(format, args...)})
+}
+
+// LintBlueprintTOML checks a TOML blueprint against the blueprint schema without a server
+// It reports syntax errors, unknown keys, values with the wrong type, and values that the
+// server would reject, eg. a missing package name or a relative mountpoint. The problems
+// are sorted by line.
+func LintBlueprintTOML(data []byte) []LintProblem {
+	var raw map[string]interface{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		var pe toml.ParseError
+		if errors.As(err, &pe) {
+			return []LintProblem{{Line: pe.Position.Line, Severity: "error", Message: pe.Message}}
+		}
+		return []LintProblem{{Severity: "error", Message: err.Error()}}
+	}
+
+	var l linter
+	l.checkSchema("", raw, reflect.TypeOf(Blueprint{}))
+	if !LintErrors(l.problems) {
+		// The types all match so it can be decoded to check the values
+		if bp, err := ParseBlueprintTOML(data); err != nil {
+			l.errorf("", "%s", err)
+		} else {
+			l.checkBlueprint(bp)
+		}
+	}
+
+	lines := tomlLines(data)
+	for i := range l.problems {
+		l.problems[i].Line = findLine(lines, l.problems[i].Key)
+	}
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		return l.problems[i].Line < l.problems[j].Line
+	})
+	return l.problems
+}
+
+// joinKey adds a key to the path
+func joinKey(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+// checkSchema checks that the decoded TOML value matches the type from the blueprint model
+func (l *linter) checkSchema(path string, value interface{}, t reflect.Type) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		table, ok := value.(map[string]interface{})
+		if !ok {
+			l.errorf(path, "%s should be a table, not %s", path, describeTOML(value))
+			return
+		}
+		fields := structFields(t, "toml")
+		keys := make([]string, 0, len(table))
+		for k := range table {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ft, ok := fields[k]
+			if !ok {
+				l.unknownKey(path, k, fields)
+				continue
+			}
+			l.checkSchema(joinKey(path, k), table[k], ft)
+		}
+	case reflect.Slice:
+		var items []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case []map[string]interface{}:
+			for _, m := range v {
+				items = append(items, m)
+			}
+		default:
+			l.errorf(path, "%s should be an array, not %s", path, describeTOML(value))
+			return
+		}
+		for i, item := range items {
+			l.checkSchema(fmt.Sprintf("%s[%d]", path, i), item, t.Elem())
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			l.errorf(path, "%s should be a string, not %s", path, describeTOML(value))
+		}
+	case reflect.Int:
+		if _, ok := value.(int64); !ok {
+			l.errorf(path, "%s should be an integer, not %s", path, describeTOML(value))
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			l.errorf(path, "%s should be true or false, not %s", path, describeTOML(value))
+		}
+	case reflect.Interface:
+		// Sizes, users, and groups can be a string or a number
+		switch value.(type) {
+		case string, int64:
+		default:
+			l.errorf(path, "%s should be a string or an integer, not %s", path, describeTOML(value))
+		}
+	}
+}
+
+// unknownKey reports a key that is not in the schema, suggesting a similar key if there is one
+func (l *linter) unknownKey(path, key string, fields map[string]reflect.Type) {
+	var suggestion string
+	best := max(1, len(key)/4)
+	for name := range fields {
+		if d := editDistance(key, name); d < best || (d == best && (len(suggestion) == 0 || name < suggestion)) {
+			best = d
+			suggestion = name
+		}
+	}
+	if len(suggestion) > 0 {
+		l.errorf(joinKey(path, key), "unknown key %s, did you mean %s?", joinKey(path, key), joinKey(path, suggestion))
+		return
+	}
+	l.errorf(joinKey(path, key), "unknown key %s", joinKey(path, key))
+}
+
+// editDistance returns the number of changes needed to turn a into b
+// A change is inserting, deleting, or replacing a character, or swapping two of them.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// describeTOML returns the kind of TOML value, used in the problem messages
+func describeTOML(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case int64:
+		return "an integer"
+	case float64:
+		return "a float"
+	case bool:
+		return "a boolean"
+	case time.Time:
+		return "a date"
+	case map[string]interface{}:
+		return "a table"
+	case []interface{}, []map[string]interface{}:
+		return "an array"
+	}
+	return fmt.Sprintf("a %T", value)
+}
+
+var (
+	semverRegex = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+	// These are the units that the server accepts in a size
+	sizeRegex = regexp.MustCompile(`^\d+\s*(B|kB|KiB|MB|MiB|GB|GiB|TB|TiB)?$`)
+)
+
+// checkBlueprint checks the values of the blueprint
+func (l *linter) checkBlueprint(bp Blueprint) {
+	if len(bp.Name) == 0 {
+		l.errorf("name", "name is required")
+	}
+	if len(bp.Version) > 0 && !semverRegex.MatchString(bp.Version) {
+		l.errorf("version", "version %q should be a semantic version, eg. 0.0.1", bp.Version)
+	}
+
+	l.checkPackages("packages", "package", bp.Packages)
+	l.checkPackages("modules", "module", bp.Modules)
+	for i, m := range bp.EnabledModules {
+		path := fmt.Sprintf("enabled_modules[%d]", i)
+		if len(m.Name) == 0 || len(m.Stream) == 0 {
+			l.errorf(path, "%s needs a name and a stream", path)
+		}
+	}
+	for i, g := range bp.Groups {
+		if len(g.Name) == 0 {
+			l.errorf(fmt.Sprintf("groups[%d]", i), "groups[%d] is missing the name", i)
+		}
+	}
+	for i, c := range bp.Containers {
+		if len(c.Source) == 0 {
+			l.errorf(fmt.Sprintf("containers[%d]", i), "containers[%d] is missing the source", i)
+		}
+	}
+	if bp.Customizations != nil {
+		l.checkCustomizations(*bp.Customizations)
+	}
+}
+
+// checkPackages checks the names of packages or modules, duplicates are a warning
+func (l *linter) checkPackages(path, kind string, packages []Package) {
+	seen := make(map[string]bool)
+	for i, p := range packages {
+		key := fmt.Sprintf("%s[%d]", path, i)
+		if len(p.Name) == 0 {
+			l.errorf(key, "%s is missing the name", key)
+			continue
+		}
+		if seen[p.Name] {
+			l.warnf(key, "%s %s is listed more than once", kind, p.Name)
+		}
+		seen[p.Name] = true
+	}
+}
+
+// checkSize checks a size that is a number of bytes or a string with a unit
+func (l *linter) checkSize(path string, size interface{}) {
+	if s, ok := size.(string); ok && !sizeRegex.MatchString(s) {
+		l.errorf(path, "%s %q is not a valid size, eg. 2 GiB", path, s)
+	}
+}
+
+// checkAbsPath checks that the path in the table is set and is absolute
+func (l *linter) checkAbsPath(table, name, path string) {
+	key := joinKey(table, name)
+	if len(path) == 0 {
+		l.errorf(table, "%s is missing the %s", table, name)
+	} else if !filepath.IsAbs(path) {
+		l.errorf(key, "%s %q should be an absolute path", key, path)
+	}
+}
+
+// checkOverlap reports the services in the table that are in both lists
+func (l *linter) checkOverlap(table, kind, first string, a []string, second string, b []string) {
+	for _, s := range b {
+		if slices.Contains(a, s) {
+			l.errorf(joinKey(table, second), "%s %s is both %s and %s", kind, s, first, second)
+		}
+	}
+}
+
+// checkCustomizations checks the values of the customizations
+func (l *linter) checkCustomizations(c Customizations) {
+	for i, k := range c.SSHKey {
+		path := fmt.Sprintf("customizations.sshkey[%d]", i)
+		if len(k.User) == 0 || len(k.Key) == 0 {
+			l.errorf(path, "%s needs a user and a key", path)
+		}
+	}
+	for i, u := range c.User {
+		if len(u.Name) == 0 {
+			l.errorf(fmt.Sprintf("customizations.user[%d]", i), "customizations.user[%d] is missing the name", i)
+		}
+	}
+	for i, g := range c.Group {
+		if len(g.Name) == 0 {
+			l.errorf(fmt.Sprintf("customizations.group[%d]", i), "customizations.group[%d] is missing the name", i)
+		}
+	}
+	for i, fs := range c.Filesystem {
+		path := fmt.Sprintf("customizations.filesystem[%d]", i)
+		l.checkAbsPath(path, "mountpoint", fs.Mountpoint)
+		if fs.Size != nil && fs.MinSize != nil {
+			l.errorf(path, "%s cannot have both size and minsize", path)
+		} else if fs.Size != nil {
+			l.warnf(path+".size", "%s.size is deprecated, use minsize", path)
+		}
+		l.checkSize(path+".minsize", fs.MinSize)
+		l.checkSize(path+".size", fs.Size)
+	}
+	if c.Disk != nil {
+		if len(c.Filesystem) > 0 {
+			l.errorf("customizations.disk", "customizations.disk and customizations.filesystem cannot be used together")
+		}
+		l.checkSize("customizations.disk.minsize", c.Disk.MinSize)
+		for i, p := range c.Disk.Partitions {
+			path := fmt.Sprintf("customizations.disk.partitions[%d]", i)
+			l.checkSize(path+".minsize", p.MinSize)
+			for j, lv := range p.LogicalVolumes {
+				l.checkSize(fmt.Sprintf("%s.logical_volumes[%d].minsize", path, j), lv.MinSize)
+			}
+		}
+	}
+	if !slices.Contains([]string{"", "raw", "lvm", "auto-lvm"}, c.PartitioningMode) {
+		l.errorf("customizations.partitioning_mode", "customizations.partitioning_mode %s should be raw, lvm, or auto-lvm", c.PartitioningMode)
+	}
+	if c.Services != nil {
+		s := c.Services
+		l.checkOverlap("customizations.services", "service", "enabled", s.Enabled, "disabled", s.Disabled)
+		l.checkOverlap("customizations.services", "service", "enabled", s.Enabled, "masked", s.Masked)
+	}
+	if c.Firewall != nil && c.Firewall.Services != nil {
+		s := c.Firewall.Services
+		l.checkOverlap("customizations.firewall.services", "firewall service", "enabled", s.Enabled, "disabled", s.Disabled)
+	}
+	if c.OpenSCAP != nil && len(c.OpenSCAP.ProfileID) == 0 {
+		l.errorf("customizations.openscap", "customizations.openscap is missing the profile_id")
+	}
+	for i, d := range c.Directories {
+		l.checkAbsPath(fmt.Sprintf("customizations.directories[%d]", i), "path", d.Path)
+		l.checkMode(fmt.Sprintf("customizations.directories[%d].mode", i), d.Mode)
+	}
+	for i, f := range c.Files {
+		l.checkAbsPath(fmt.Sprintf("customizations.files[%d]", i), "path", f.Path)
+		l.checkMode(fmt.Sprintf("customizations.files[%d].mode", i), f.Mode)
+	}
+	for i, r := range c.Repositories {
+		path := fmt.Sprintf("customizations.repositories[%d]", i)
+		if len(r.ID) == 0 {
+			l.errorf(path, "%s is missing the id", path)
+		}
+		if len(r.BaseURLs) == 0 && len(r.Metalink) == 0 && len(r.Mirrorlist) == 0 {
+			l.errorf(path, "%s needs one of baseurls, metalink, or mirrorlist", path)
+		}
+	}
+	if c.RPM != nil && c.RPM.ImportKeys != nil {
+		for i, f := range c.RPM.ImportKeys.Files {
+			l.checkAbsPath("customizations.rpm.import_keys", fmt.Sprintf("files[%d]", i), f)
+		}
+	}
+}
+
+// checkMode checks that a file mode is an octal number
+func (l *linter) checkMode(path, mode string) {
+	if len(mode) == 0 {
+		return
+	}
+	if _, err := strconv.ParseUint(mode, 8, 32); err != nil {
+		l.errorf(path, "%s %q should be an octal number, eg. 0644", path, mode)
+	}
+}
+
+// findLine returns the line of the key, or of the closest parent key that has a line
+func findLine(lines map[string]int, key string) int {
+	for len(key) > 0 {
+		if n, ok := lines[key]; ok {
+			return n
+		}
+		i := strings.LastIndexAny(key, ".[")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return 0
+}
+
+// tomlLines returns the line of each key in the TOML document
+// The keys are the paths used by LintProblem, eg. customizations.user[1].name. Keys in
+// inline tables are not included, findLine uses the line of the table.
+func tomlLines(data []byte) map[string]int {
+	lines := make(map[string]int)
+	arrays := make(map[string]int) // Number of tables in each array of tables
+	record := func(key string, line int) {
+		if _, ok := lines[key]; !ok {
+			lines[key] = line
+		}
+	}
+	// resolve adds the index of the current table in any arrays of tables in the path
+	resolve := func(parts []string) string {
+		var path string
+		for _, p := range parts {
+			path = joinKey(path, p)
+			if n, ok := arrays[path]; ok {
+				path = fmt.Sprintf("%s[%d]", path, n-1)
+			}
+		}
+		return path
+	}
+
+	var table string
+	var depth int        // Depth of brackets in a value that continues on the next line
+	var multiline string // Quotes of a multiline string that continues on the next line
+	for i, text := range strings.Split(string(data), "\n") {
+		line := i + 1
+		if len(multiline) > 0 {
+			if strings.Contains(text, multiline) {
+				multiline = ""
+			}
+			continue
+		}
+		if depth > 0 {
+			depth += bracketDepth(text)
+			continue
+		}
+		s := strings.TrimSpace(text)
+		switch {
+		case len(s) == 0 || s[0] == '#':
+		case strings.HasPrefix(s, "[["):
+			name, _, _ := strings.Cut(s[2:], "]]")
+			parts := splitKey(name)
+			if len(parts) == 0 {
+				continue
+			}
+			path := joinKey(resolve(parts[:len(parts)-1]), parts[len(parts)-1])
+			arrays[path]++
+			table = fmt.Sprintf("%s[%d]", path, arrays[path]-1)
+			record(path, line)
+			record(table, line)
+		case s[0] == '[':
+			name, _, _ := strings.Cut(s[1:], "]")
+			table = resolve(splitKey(name))
+			record(table, line)
+		default:
+			key, value, found := strings.Cut(s, "=")
+			if !found {
+				continue
+			}
+			record(joinKey(table, strings.Join(splitKey(key), ".")), line)
+			value = strings.TrimSpace(value)
+			for _, q := range []string{`"""`, `'''`} {
+				if strings.HasPrefix(value, q) && !strings.Contains(value[3:], q) {
+					multiline = q
+				}
+			}
+			if len(multiline) == 0 {
+				depth = bracketDepth(value)
+			}
+		}
+	}
+	return lines
+}
+
+// splitKey splits a dotted TOML key into its parts, removing the quotes
+func splitKey(key string) []string {
+	var parts []string
+	var part strings.Builder
+	var quote rune
+	for _, r := range key {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			part.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '.':
+			parts = append(parts, strings.TrimSpace(part.String()))
+			part.Reset()
+		default:
+			part.WriteRune(r)
+		}
+	}
+	if p := strings.TrimSpace(part.String()); len(p) > 0 || len(parts) > 0 {
+		parts = append(parts, p)
+	}
+	return parts
+}
+
+// bracketDepth returns the number of brackets and braces left open by the text
+// Brackets in strings and comments are skipped.
+func bracketDepth(text string) int {
+	var depth int
+	var quote rune
+	var escaped bool
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return depth
+		case r == '[' || r == '{':
+			depth++
+		case r == ']' || r == '}':
+			depth--
+		}
+	}
+	return depth
+}
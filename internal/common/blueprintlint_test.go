@@ -0,0 +1,301 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// problemStrings returns the problems as strings for easier comparison
+func problemStrings(problems []LintProblem) []string {
+	var s []string
+	for _, p := range problems {
+		s = append(s, p.String())
+	}
+	return s
+}
+
+func TestLintBlueprintValid(t *testing.T) {
+	bp := `name = "everything"
+description = """
+All of the sections
+[[not a table]]
+"""
+version = "1.2.3"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[modules]]
+name = "nodejs"
+
+[[enabled_modules]]
+name = "nodejs"
+stream = "20"
+
+[[groups]]
+name = "core"
+
+[[containers]]
+source = "quay.io/fedora/fedora:latest"
+tls-verify = false
+
+[customizations]
+hostname = "server"
+fips = true
+partitioning_mode = "lvm"
+
+[customizations.kernel]
+append = "nosmt=force"
+
+[[customizations.sshkey]]
+user = "root"
+key = "ssh-ed25519 AAAA"
+
+[[customizations.user]]
+name = "admin"
+groups = ["wheel", "users"]
+uid = 1200
+
+[[customizations.group]]
+name = "users"
+gid = 1300
+
+[customizations.timezone]
+timezone = "US/Eastern"
+ntpservers = ["0.north-america.pool.ntp.org"]
+
+[customizations.locale]
+languages = ["en_US.UTF-8"]
+keyboard = "us"
+
+[customizations.firewall]
+ports = ["22:tcp", "80:tcp"]
+
+[customizations.firewall.services]
+enabled = ["ftp"]
+disabled = ["telnet"]
+
+[customizations.services]
+enabled = ["sshd"]
+masked = ["rpcbind"]
+
+[[customizations.filesystem]]
+mountpoint = "/var"
+minsize = "2 GiB"
+
+[[customizations.filesystem]]
+mountpoint = "/home"
+minsize = 1073741824
+
+[customizations.openscap]
+datastream = "/usr/share/xml/scap/ssg/content/ssg-fedora-ds.xml"
+profile_id = "standard"
+
+[customizations.openscap.tailoring]
+selected = ["xccdf_org.ssgproject.content_bind_crypto_policy"]
+
+[customizations.fdo]
+manufacturing_server_url = "http://192.168.122.199:8080"
+diun_pub_key_insecure = "true"
+
+[customizations.ignition.firstboot]
+url = "http://some-server/configuration.ig"
+
+[customizations.installer]
+unattended = true
+sudo-nopasswd = ["user", "%wheel"]
+
+[customizations.rpm.import_keys]
+files = ["/etc/pki/rpm-gpg/RPM-GPG-KEY-fedora-18-primary"]
+
+[[customizations.repositories]]
+id = "example"
+baseurls = [
+  "http://example.com/repo/",
+]
+gpgcheck = true
+
+[[customizations.directories]]
+path = "/etc/example"
+user = 1020
+mode = "0755"
+ensure_parents = true
+
+[[customizations.files]]
+path = "/etc/example/config"
+user = "admin"
+data = "debug = false"
+`
+	assert.Nil(t, LintBlueprintTOML([]byte(bp)))
+}
+
+func TestLintBlueprintSyntax(t *testing.T) {
+	problems := LintBlueprintTOML([]byte("name = \"test\"\n[[packages]\nname = \"tmux\"\n"))
+	require.Equal(t, 1, len(problems))
+	assert.Equal(t, 3, problems[0].Line)
+	assert.Equal(t, "error", problems[0].Severity)
+	assert.True(t, LintErrors(problems))
+}
+
+func TestLintBlueprintSchema(t *testing.T) {
+	bp := `name = "test"
+version = "0.0.1"
+distribution = "fedora-41"
+
+[[packges]]
+name = "tmux"
+
+[[packages]]
+name = "bash"
+version = 5
+
+[customizations]
+hostname = ["server"]
+
+[[customizations.user]]
+name = "admin"
+
+[[customizations.user]]
+name = "bart"
+uid = "1000"
+gruops = ["wheel"]
+
+[customizations.services]
+enable = ["sshd"]
+`
+	assert.Equal(t, []string{
+		"3: error: unknown key distribution",
+		"5: error: unknown key packges, did you mean packages?",
+		"10: error: packages[0].version should be a string, not an integer",
+		"13: error: customizations.hostname should be a string, not an array",
+		"20: error: customizations.user[1].uid should be an integer, not a string",
+		"21: error: unknown key customizations.user[1].gruops, did you mean customizations.user[1].groups?",
+		"24: error: unknown key customizations.services.enable, did you mean customizations.services.enabled?",
+	}, problemStrings(LintBlueprintTOML([]byte(bp))))
+}
+
+func TestLintBlueprintValues(t *testing.T) {
+	bp := `name = ""
+version = "1.0"
+
+[[packages]]
+name = "tmux"
+
+[[packages]]
+version = "*"
+
+[[packages]]
+name = "tmux"
+
+[[customizations.filesystem]]
+mountpoint = "var"
+size = "2 GiB"
+
+[[customizations.filesystem]]
+mountpoint = "/home"
+minsize = "lots"
+
+[customizations.services]
+enabled = ["sshd", "cups"]
+disabled = ["cups"]
+
+[[customizations.files]]
+path = "/etc/motd"
+mode = "rw-r--r--"
+
+[[customizations.repositories]]
+id = "local"
+`
+	problems := LintBlueprintTOML([]byte(bp))
+	assert.Equal(t, []string{
+		"1: error: name is required",
+		"2: error: version \"1.0\" should be a semantic version, eg. 0.0.1",
+		"7: error: packages[1] is missing the name",
+		"10: warning: package tmux is listed more than once",
+		"14: error: customizations.filesystem[0].mountpoint \"var\" should be an absolute path",
+		"15: warning: customizations.filesystem[0].size is deprecated, use minsize",
+		"19: error: customizations.filesystem[1].minsize \"lots\" is not a valid size, eg. 2 GiB",
+		"23: error: service cups is both enabled and disabled",
+		"27: error: customizations.files[0].mode \"rw-r--r--\" should be an octal number, eg. 0644",
+		"29: error: customizations.repositories[0] needs one of baseurls, metalink, or mirrorlist",
+	}, problemStrings(problems))
+	assert.True(t, LintErrors(problems))
+}
+
+func TestLintBlueprintSizes(t *testing.T) {
+	for _, size := range []string{"1024", "512 B", "512 kB", "512 KiB", "2 MB", "2 MiB", "2 GB", "2GiB", "1 TB", "1 TiB"} {
+		bp := fmt.Sprintf("name = \"test\"\n[[customizations.filesystem]]\nmountpoint = \"/var\"\nminsize = %q\n", size)
+		assert.Nil(t, problemStrings(LintBlueprintTOML([]byte(bp))), size)
+	}
+	for _, size := range []string{"512 KB", "2 gb", "2 GIB", "1.5 GiB", "2 PiB"} {
+		bp := fmt.Sprintf("name = \"test\"\n[[customizations.filesystem]]\nmountpoint = \"/var\"\nminsize = %q\n", size)
+		assert.True(t, LintErrors(LintBlueprintTOML([]byte(bp))), size)
+	}
+}
+
+func TestLintBlueprintWarnings(t *testing.T) {
+	problems := LintBlueprintTOML([]byte("name = \"test\"\npackages = [{name = \"tmux\"}, {name = \"tmux\"}]\n"))
+	assert.Equal(t, []string{"2: warning: package tmux is listed more than once"}, problemStrings(problems))
+	assert.False(t, LintErrors(problems))
+}
+
+func TestLintBlueprintMissingName(t *testing.T) {
+	problems := LintBlueprintTOML([]byte("description = \"no name\"\n"))
+	assert.Equal(t, []string{"error: name is required"}, problemStrings(problems))
+}
+
+func TestTOMLLines(t *testing.T) {
+	lines := tomlLines([]byte(`name = "test" # [[not.a.table]]
+packages = [
+  { name = "tmux" },
+]
+notes = '''
+[customizations]
+'''
+
+[[customizations.disk.partitions]]
+type = "lvm"
+
+[[customizations.disk.partitions.logical_volumes]]
+name = "root"
+
+[[customizations.disk.partitions]]
+type = "plain"
+
+[[customizations.disk.partitions.logical_volumes]]
+"quoted.key" = "value"
+`))
+	assert.Equal(t, map[string]int{
+		"name":                                   1,
+		"packages":                               2,
+		"notes":                                  5,
+		"customizations.disk.partitions":         9,
+		"customizations.disk.partitions[0]":      9,
+		"customizations.disk.partitions[0].type": 10,
+		"customizations.disk.partitions[0].logical_volumes":               12,
+		"customizations.disk.partitions[0].logical_volumes[0]":            12,
+		"customizations.disk.partitions[0].logical_volumes[0].name":       13,
+		"customizations.disk.partitions[1]":                               15,
+		"customizations.disk.partitions[1].type":                          16,
+		"customizations.disk.partitions[1].logical_volumes":               18,
+		"customizations.disk.partitions[1].logical_volumes[0]":            18,
+		"customizations.disk.partitions[1].logical_volumes[0].quoted.key": 19,
+	}, lines)
+	assert.Equal(t, 2, findLine(lines, "packages[0].name"))
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("packages", "packages"))
+	assert.Equal(t, 1, editDistance("packges", "packages"))
+	assert.Equal(t, 1, editDistance("gruops", "groups"))
+	assert.Equal(t, 4, editDistance("distribution", "description"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}
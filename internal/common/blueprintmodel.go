@@ -0,0 +1,373 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+// Blueprint is the complete blueprint
+// It follows the blueprint reference at https://osbuild.org/docs/user-guide/blueprint-reference
+// Each of the structs has an Extra map with the fields that are not part of this schema, so
+// that a blueprint for a newer server can be read and written without losing them.
+type Blueprint struct {
+	Name           string          `json:"name" toml:"name"`
+	Description    string          `json:"description,omitempty" toml:"description,omitempty"`
+	Version        string          `json:"version,omitempty" toml:"version,omitempty"`
+	Distro         string          `json:"distro,omitempty" toml:"distro,omitempty"`
+	Arch           string          `json:"arch,omitempty" toml:"arch,omitempty"`
+	Minimal        bool            `json:"minimal,omitempty" toml:"minimal,omitempty"`
+	Packages       []Package       `json:"packages,omitempty" toml:"packages,omitempty"`
+	Modules        []Package       `json:"modules,omitempty" toml:"modules,omitempty"`
+	EnabledModules []EnabledModule `json:"enabled_modules,omitempty" toml:"enabled_modules,omitempty"`
+	Groups         []Group         `json:"groups,omitempty" toml:"groups,omitempty"`
+	Containers     []Container     `json:"containers,omitempty" toml:"containers,omitempty"`
+	Customizations *Customizations `json:"customizations,omitempty" toml:"customizations,omitempty"`
+
+	// Extra holds the top level fields that are not part of this schema
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// EnabledModule is a module stream to enable without installing any of its packages
+type EnabledModule struct {
+	Name   string `json:"name" toml:"name"`
+	Stream string `json:"stream" toml:"stream"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// Container is a container image to embed in the image
+type Container struct {
+	Source       string `json:"source" toml:"source"`
+	Name         string `json:"name,omitempty" toml:"name,omitempty"`
+	TLSVerify    *bool  `json:"tls-verify,omitempty" toml:"tls-verify,omitempty"`
+	LocalStorage bool   `json:"local-storage,omitempty" toml:"local-storage,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// Customizations are the changes made to the image after the packages are installed
+type Customizations struct {
+	Hostname           *string                   `json:"hostname,omitempty" toml:"hostname,omitempty"`
+	Kernel             *KernelCustomization      `json:"kernel,omitempty" toml:"kernel,omitempty"`
+	SSHKey             []SSHKeyCustomization     `json:"sshkey,omitempty" toml:"sshkey,omitempty"`
+	User               []UserCustomization       `json:"user,omitempty" toml:"user,omitempty"`
+	Group              []GroupCustomization      `json:"group,omitempty" toml:"group,omitempty"`
+	Timezone           *TimezoneCustomization    `json:"timezone,omitempty" toml:"timezone,omitempty"`
+	Locale             *LocaleCustomization      `json:"locale,omitempty" toml:"locale,omitempty"`
+	Firewall           *FirewallCustomization    `json:"firewall,omitempty" toml:"firewall,omitempty"`
+	Services           *ServicesCustomization    `json:"services,omitempty" toml:"services,omitempty"`
+	Filesystem         []FilesystemCustomization `json:"filesystem,omitempty" toml:"filesystem,omitempty"`
+	Disk               *DiskCustomization        `json:"disk,omitempty" toml:"disk,omitempty"`
+	InstallationDevice string                    `json:"installation_device,omitempty" toml:"installation_device,omitempty"`
+	PartitioningMode   string                    `json:"partitioning_mode,omitempty" toml:"partitioning_mode,omitempty"`
+	FDO                *FDOCustomization         `json:"fdo,omitempty" toml:"fdo,omitempty"`
+	OpenSCAP           *OpenSCAPCustomization    `json:"openscap,omitempty" toml:"openscap,omitempty"`
+	Ignition           *IgnitionCustomization    `json:"ignition,omitempty" toml:"ignition,omitempty"`
+	Directories        []DirectoryCustomization  `json:"directories,omitempty" toml:"directories,omitempty"`
+	Files              []FileCustomization       `json:"files,omitempty" toml:"files,omitempty"`
+	Repositories       []RepositoryCustomization `json:"repositories,omitempty" toml:"repositories,omitempty"`
+	FIPS               *bool                     `json:"fips,omitempty" toml:"fips,omitempty"`
+	Installer          *InstallerCustomization   `json:"installer,omitempty" toml:"installer,omitempty"`
+	RPM                *RPMCustomization         `json:"rpm,omitempty" toml:"rpm,omitempty"`
+	CACerts            *CACertsCustomization     `json:"cacerts,omitempty" toml:"cacerts,omitempty"`
+
+	// Extra holds the customizations that are not part of this schema
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// KernelCustomization selects the kernel package and its commandline
+type KernelCustomization struct {
+	Name   string `json:"name,omitempty" toml:"name,omitempty"`
+	Append string `json:"append,omitempty" toml:"append,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// SSHKeyCustomization adds an ssh key to an existing user
+type SSHKeyCustomization struct {
+	User string `json:"user" toml:"user"`
+	Key  string `json:"key" toml:"key"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// UserCustomization creates a user account
+type UserCustomization struct {
+	Name               string   `json:"name" toml:"name"`
+	Description        *string  `json:"description,omitempty" toml:"description,omitempty"`
+	Password           *string  `json:"password,omitempty" toml:"password,omitempty"`
+	Key                *string  `json:"key,omitempty" toml:"key,omitempty"`
+	Home               *string  `json:"home,omitempty" toml:"home,omitempty"`
+	Shell              *string  `json:"shell,omitempty" toml:"shell,omitempty"`
+	Groups             []string `json:"groups,omitempty" toml:"groups,omitempty"`
+	UID                *int     `json:"uid,omitempty" toml:"uid,omitempty"`
+	GID                *int     `json:"gid,omitempty" toml:"gid,omitempty"`
+	ExpireDate         *int     `json:"expiredate,omitempty" toml:"expiredate,omitempty"`
+	ForcePasswordReset *bool    `json:"force_password_reset,omitempty" toml:"force_password_reset,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// GroupCustomization creates a group
+type GroupCustomization struct {
+	Name string `json:"name" toml:"name"`
+	GID  *int   `json:"gid,omitempty" toml:"gid,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// TimezoneCustomization sets the timezone and the NTP servers
+type TimezoneCustomization struct {
+	Timezone   *string  `json:"timezone,omitempty" toml:"timezone,omitempty"`
+	NTPServers []string `json:"ntpservers,omitempty" toml:"ntpservers,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// LocaleCustomization sets the languages and the keyboard layout
+type LocaleCustomization struct {
+	Languages []string `json:"languages,omitempty" toml:"languages,omitempty"`
+	Keyboard  *string  `json:"keyboard,omitempty" toml:"keyboard,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// FirewallCustomization opens ports and services in the firewall
+type FirewallCustomization struct {
+	Ports    []string                       `json:"ports,omitempty" toml:"ports,omitempty"`
+	Services *FirewallServicesCustomization `json:"services,omitempty" toml:"services,omitempty"`
+	Zones    []FirewallZoneCustomization    `json:"zones,omitempty" toml:"zones,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// FirewallServicesCustomization enables and disables firewalld services
+type FirewallServicesCustomization struct {
+	Enabled  []string `json:"enabled,omitempty" toml:"enabled,omitempty"`
+	Disabled []string `json:"disabled,omitempty" toml:"disabled,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// FirewallZoneCustomization adds sources to a firewalld zone
+type FirewallZoneCustomization struct {
+	Name    *string  `json:"name,omitempty" toml:"name,omitempty"`
+	Sources []string `json:"sources,omitempty" toml:"sources,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// ServicesCustomization enables, disables, and masks systemd services
+type ServicesCustomization struct {
+	Enabled  []string `json:"enabled,omitempty" toml:"enabled,omitempty"`
+	Disabled []string `json:"disabled,omitempty" toml:"disabled,omitempty"`
+	Masked   []string `json:"masked,omitempty" toml:"masked,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// FilesystemCustomization sets the minimum size of a mountpoint
+// The size is a number of bytes or a string with a unit, eg. "2 GiB". size is the
+// deprecated name for minsize.
+type FilesystemCustomization struct {
+	Mountpoint string      `json:"mountpoint" toml:"mountpoint"`
+	MinSize    interface{} `json:"minsize,omitempty" toml:"minsize,omitempty"`
+	Size       interface{} `json:"size,omitempty" toml:"size,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// DiskCustomization is the complete partition table of the image
+type DiskCustomization struct {
+	Type       string                   `json:"type,omitempty" toml:"type,omitempty"`
+	MinSize    interface{}              `json:"minsize,omitempty" toml:"minsize,omitempty"`
+	Partitions []PartitionCustomization `json:"partitions,omitempty" toml:"partitions,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// PartitionCustomization is a plain, lvm, or btrfs partition
+type PartitionCustomization struct {
+	Type           string                `json:"type,omitempty" toml:"type,omitempty"`
+	MinSize        interface{}           `json:"minsize,omitempty" toml:"minsize,omitempty"`
+	PartType       string                `json:"part_type,omitempty" toml:"part_type,omitempty"`
+	PartLabel      string                `json:"part_label,omitempty" toml:"part_label,omitempty"`
+	PartUUID       string                `json:"part_uuid,omitempty" toml:"part_uuid,omitempty"`
+	Mountpoint     string                `json:"mountpoint,omitempty" toml:"mountpoint,omitempty"`
+	Label          string                `json:"label,omitempty" toml:"label,omitempty"`
+	FSType         string                `json:"fs_type,omitempty" toml:"fs_type,omitempty"`
+	Name           string                `json:"name,omitempty" toml:"name,omitempty"`
+	LogicalVolumes []LVCustomization     `json:"logical_volumes,omitempty" toml:"logical_volumes,omitempty"`
+	Subvolumes     []SubvolCustomization `json:"subvolumes,omitempty" toml:"subvolumes,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// LVCustomization is a logical volume in an lvm partition
+type LVCustomization struct {
+	Name       string      `json:"name,omitempty" toml:"name,omitempty"`
+	MinSize    interface{} `json:"minsize,omitempty" toml:"minsize,omitempty"`
+	Mountpoint string      `json:"mountpoint,omitempty" toml:"mountpoint,omitempty"`
+	Label      string      `json:"label,omitempty" toml:"label,omitempty"`
+	FSType     string      `json:"fs_type,omitempty" toml:"fs_type,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// SubvolCustomization is a subvolume in a btrfs partition
+type SubvolCustomization struct {
+	Name       string `json:"name" toml:"name"`
+	Mountpoint string `json:"mountpoint" toml:"mountpoint"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// FDOCustomization configures FIDO device onboarding
+type FDOCustomization struct {
+	ManufacturingServerURL  string `json:"manufacturing_server_url,omitempty" toml:"manufacturing_server_url,omitempty"`
+	DiunPubKeyInsecure      string `json:"diun_pub_key_insecure,omitempty" toml:"diun_pub_key_insecure,omitempty"`
+	DiunPubKeyHash          string `json:"diun_pub_key_hash,omitempty" toml:"diun_pub_key_hash,omitempty"`
+	DiunPubKeyRootCerts     string `json:"diun_pub_key_root_certs,omitempty" toml:"diun_pub_key_root_certs,omitempty"`
+	DiMfgStringTypeMacIface string `json:"di_mfg_string_type_mac_iface,omitempty" toml:"di_mfg_string_type_mac_iface,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// OpenSCAPCustomization remediates the image with an OpenSCAP profile
+type OpenSCAPCustomization struct {
+	DataStream    string                              `json:"datastream,omitempty" toml:"datastream,omitempty"`
+	ProfileID     string                              `json:"profile_id" toml:"profile_id"`
+	PolicyID      string                              `json:"policy_id,omitempty" toml:"policy_id,omitempty"`
+	Tailoring     *OpenSCAPTailoringCustomization     `json:"tailoring,omitempty" toml:"tailoring,omitempty"`
+	JSONTailoring *OpenSCAPJSONTailoringCustomization `json:"json_tailoring,omitempty" toml:"json_tailoring,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// OpenSCAPTailoringCustomization selects and unselects rules of the profile
+type OpenSCAPTailoringCustomization struct {
+	Selected   []string `json:"selected,omitempty" toml:"selected,omitempty"`
+	Unselected []string `json:"unselected,omitempty" toml:"unselected,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// OpenSCAPJSONTailoringCustomization uses a JSON tailoring file
+type OpenSCAPJSONTailoringCustomization struct {
+	ProfileID string `json:"profile_id" toml:"profile_id"`
+	Filepath  string `json:"filepath" toml:"filepath"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// IgnitionCustomization configures ignition for the first boot
+type IgnitionCustomization struct {
+	Embedded  *EmbeddedIgnitionCustomization  `json:"embedded,omitempty" toml:"embedded,omitempty"`
+	FirstBoot *FirstBootIgnitionCustomization `json:"firstboot,omitempty" toml:"firstboot,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// EmbeddedIgnitionCustomization is an ignition config included in the image
+type EmbeddedIgnitionCustomization struct {
+	Config string `json:"config" toml:"config"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// FirstBootIgnitionCustomization is the url of an ignition config fetched on the first boot
+type FirstBootIgnitionCustomization struct {
+	ProvisioningURL string `json:"url" toml:"url"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// DirectoryCustomization creates a directory in the image
+// User and Group are a name or a numeric id
+type DirectoryCustomization struct {
+	Path          string      `json:"path" toml:"path"`
+	User          interface{} `json:"user,omitempty" toml:"user,omitempty"`
+	Group         interface{} `json:"group,omitempty" toml:"group,omitempty"`
+	Mode          string      `json:"mode,omitempty" toml:"mode,omitempty"`
+	EnsureParents bool        `json:"ensure_parents,omitempty" toml:"ensure_parents,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// FileCustomization creates a file in the image
+// User and Group are a name or a numeric id
+type FileCustomization struct {
+	Path  string      `json:"path" toml:"path"`
+	User  interface{} `json:"user,omitempty" toml:"user,omitempty"`
+	Group interface{} `json:"group,omitempty" toml:"group,omitempty"`
+	Mode  string      `json:"mode,omitempty" toml:"mode,omitempty"`
+	Data  string      `json:"data,omitempty" toml:"data,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// RepositoryCustomization adds a dnf repository to the image
+// It is only used by the installed system, not to build the image.
+type RepositoryCustomization struct {
+	ID             string   `json:"id" toml:"id"`
+	BaseURLs       []string `json:"baseurls,omitempty" toml:"baseurls,omitempty"`
+	GPGKeys        []string `json:"gpgkeys,omitempty" toml:"gpgkeys,omitempty"`
+	Metalink       string   `json:"metalink,omitempty" toml:"metalink,omitempty"`
+	Mirrorlist     string   `json:"mirrorlist,omitempty" toml:"mirrorlist,omitempty"`
+	Name           string   `json:"name,omitempty" toml:"name,omitempty"`
+	Priority       *int     `json:"priority,omitempty" toml:"priority,omitempty"`
+	Enabled        *bool    `json:"enabled,omitempty" toml:"enabled,omitempty"`
+	GPGCheck       *bool    `json:"gpgcheck,omitempty" toml:"gpgcheck,omitempty"`
+	RepoGPGCheck   *bool    `json:"repo_gpgcheck,omitempty" toml:"repo_gpgcheck,omitempty"`
+	SSLVerify      *bool    `json:"sslverify,omitempty" toml:"sslverify,omitempty"`
+	ModuleHotfixes *bool    `json:"module_hotfixes,omitempty" toml:"module_hotfixes,omitempty"`
+	Filename       string   `json:"filename,omitempty" toml:"filename,omitempty"`
+	InstallFrom    bool     `json:"install_from,omitempty" toml:"install_from,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// InstallerCustomization configures the Anaconda installer of installer images
+type InstallerCustomization struct {
+	Unattended   bool                         `json:"unattended,omitempty" toml:"unattended,omitempty"`
+	SudoNopasswd []string                     `json:"sudo-nopasswd,omitempty" toml:"sudo-nopasswd,omitempty"`
+	Kickstart    *KickstartCustomization      `json:"kickstart,omitempty" toml:"kickstart,omitempty"`
+	Modules      *AnacondaModuleCustomization `json:"modules,omitempty" toml:"modules,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// KickstartCustomization is a kickstart file included in the installer
+type KickstartCustomization struct {
+	Contents string `json:"contents" toml:"contents"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// AnacondaModuleCustomization enables and disables Anaconda modules
+type AnacondaModuleCustomization struct {
+	Enable  []string `json:"enable,omitempty" toml:"enable,omitempty"`
+	Disable []string `json:"disable,omitempty" toml:"disable,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// RPMCustomization configures rpm in the image
+type RPMCustomization struct {
+	ImportKeys *RPMImportKeys `json:"import_keys,omitempty" toml:"import_keys,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// RPMImportKeys are the gpg key files imported into the rpm database
+type RPMImportKeys struct {
+	Files []string `json:"files,omitempty" toml:"files,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
+
+// CACertsCustomization adds certificate authorities to the system trust store
+type CACertsCustomization struct {
+	PEMCerts []string `json:"pem_certs,omitempty" toml:"pem_certs,omitempty"`
+
+	Extra map[string]interface{} `json:"-" toml:"-"`
+}
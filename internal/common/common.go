@@ -134,61 +134,43 @@ func SortedMapKeys(m map[string]any) []string {
 //     provided filename.
 //
 // It will not save to a nonexistant directory, and it will not overwrite an
-// existing file.
+// existing file. The body is written to a .part file which is renamed when it is
+// complete, see DownloadResponseBody for resuming interrupted downloads.
 func SaveResponseBodyToFile(resp *http.Response, path string) (string, error) {
-	var fileName string
-	var err error
+	return DownloadResponseBody(resp, path, nil, nil)
+}
 
+// ResponseFileName returns the filename to use when saving the response body
+// The path is handled the same way as SaveResponseBodyToFile, it returns an error if
+// the path is a directory that does not exist.
+func ResponseFileName(resp *http.Response, path string) (string, error) {
 	// Save to server provided filename under current directory
 	if len(path) == 0 {
 		// The fileName returned is safe to write to
-		fileName, err = GetContentFilename(resp.Header.Get("content-disposition"))
+		return GetContentFilename(resp.Header.Get("content-disposition"))
+	}
+
+	// Is the path a directory that exists, or a file to save to?
+
+	// If it is an existing directory? Save under that.
+	fi, err := os.Stat(path)
+	if err == nil {
+		if !fi.IsDir() {
+			return path, nil
+		}
+		fileName, err := GetContentFilename(resp.Header.Get("content-disposition"))
 		if err != nil {
 			return fileName, err
 		}
-	} else {
-		// Is the path a directory that exists, or a file to save to?
-
-		// If it is an existing directory? Save under that.
-		var fi fs.FileInfo
-		fi, err = os.Stat(path)
-		if err == nil {
-			if fi.IsDir() {
-				fileName, err = GetContentFilename(resp.Header.Get("content-disposition"))
-				if err != nil {
-					return fileName, err
-				}
-				fileName = filepath.Join(path, fileName)
-			} else {
-				fileName = path
-			}
-		} else {
-			if errors.Is(err, fs.ErrNotExist) {
-				// Does it look like a directory? A directory needs to exist.
-				if path[len(path)-1] == '/' {
-					err = fmt.Errorf("%s does not exist", path)
-					return fileName, err
-				}
-				// Assume it is a file
-				fileName = path
-			} else {
-				// Some other error
-				return fileName, err
-			}
+		return filepath.Join(path, fileName), nil
+	} else if errors.Is(err, fs.ErrNotExist) {
+		// Does it look like a directory? A directory needs to exist.
+		if path[len(path)-1] == '/' {
+			return "", fmt.Errorf("%s does not exist", path)
 		}
+		// Assume it is a file
+		return path, nil
 	}
-
-	_, err = os.Stat(fileName)
-	if err == nil {
-		return fileName, fmt.Errorf("%s exists, skipping download", fileName)
-	}
-	f, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE, 0600)
-	if err != nil {
-		return fileName, err
-	}
-	if _, err = io.Copy(f, resp.Body); err != nil {
-		return fileName, err
-	}
-	err = f.Close()
-	return fileName, err
+	// Some other error
+	return "", err
 }
@@ -0,0 +1,147 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrUnknownCompose is returned by a ComposeBackend when the API does not have the compose
+// Use errors.Is to check for it, the error includes the message from the API.
+var ErrUnknownCompose = errors.New("unknown compose")
+
+// unknownComposeError is an API's error message for an unknown compose
+type unknownComposeError struct {
+	msg string
+}
+
+func (e unknownComposeError) Error() string {
+	return e.msg
+}
+
+func (e unknownComposeError) Is(target error) bool {
+	return target == ErrUnknownCompose
+}
+
+// UnknownComposeError returns an error with the API's message that matches ErrUnknownCompose
+func UnknownComposeError(msg string) error {
+	return unknownComposeError{msg}
+}
+
+// Compose is the status of a compose, it is the same for the weldr and cloud APIs
+type Compose struct {
+	ID        string
+	API       string // The API that owns the compose, weldr or cloud
+	Status    string // Status reported by the API, eg. RUNNING or pending
+	State     string // Status as WAITING, RUNNING, FINISHED, or FAILED for both APIs
+	Blueprint string
+	Version   string
+	Types     []string  // Image types, a cloud API compose can have several
+	Sizes     []uint64  // Image sizes in bytes, 0 when it uses the default size
+	Created   time.Time // The times are zero when the API does not report them
+	Started   time.Time
+	Finished  time.Time
+	Uploads   []ComposeUpload // Status of the image uploads, when the API reports them
+	Errors    []string        // Reasons the compose failed, when the API reports them
+}
+
+// ComposeUpload is the status of one of the compose's uploads
+type ComposeUpload struct {
+	Type   string // Upload type or provider, eg. aws or local
+	Status string // Status reported by the API
+}
+
+// Done returns true when the compose has finished or failed
+func (c Compose) Done() bool {
+	return c.State == "FINISHED" || c.State == "FAILED"
+}
+
+// ComposeImage is one of the images built by a compose
+type ComposeImage struct {
+	Type    string
+	Arch    string // Empty when the API does not report it
+	Size    uint64
+	Uploads []string // Upload types, eg. aws or local
+}
+
+// ComposeMetadata is the blueprint, images, and packages used by a compose
+type ComposeMetadata struct {
+	Blueprint InfoBlueprint
+	Images    []ComposeImage
+	Packages  []PackageNEVRA // The depsolved packages
+}
+
+// ComposeBackend is the set of compose operations supported by both APIs
+// The weldr and cloud clients return one from their Composes method, so that composes can
+// be handled the same way whichever API was used to start them. API errors are returned
+// as an error, an unknown compose UUID is an ErrUnknownCompose.
+type ComposeBackend interface {
+	// API returns the name of the API, weldr or cloud
+	API() string
+	// List returns the status of all of the composes
+	List() ([]Compose, error)
+	// Info returns the status of a compose
+	Info(id string) (Compose, error)
+	// Wait checks the status of a compose until it is done or the timeout is exceeded
+	// aborted is true when the timeout was exceeded, compose is the last status.
+	Wait(id string, timeout, interval time.Duration) (aborted bool, compose Compose, err error)
+	// Delete removes a finished or failed compose and its images
+	Delete(id string) error
+	// Cancel stops a waiting or running compose
+	Cancel(id string) error
+	// Image downloads the compose's image to path, returning the filename used
+	Image(id, path string) (string, error)
+	// Logs returns the compose's log
+	Logs(id string) (string, error)
+	// Metadata returns the blueprint, images, and packages used by the compose
+	Metadata(id string) (ComposeMetadata, error)
+}
+
+// FindCompose returns the backend that has the compose, and its status
+// The backends are tried in order. If none of them have it the error from the last one
+// is returned, any other error is returned without trying the rest of the backends.
+func FindCompose(id string, backends ...ComposeBackend) (ComposeBackend, Compose, error) {
+	err := ErrUnknownCompose
+	for _, b := range backends {
+		var compose Compose
+		compose, err = b.Info(id)
+		if err == nil {
+			return b, compose, nil
+		}
+		if !errors.Is(err, ErrUnknownCompose) {
+			return nil, Compose{}, err
+		}
+	}
+	return nil, Compose{}, err
+}
+
+// SortComposes sorts the composes by status, blueprint name, version, and image types
+// The status order is RUNNING, WAITING, FINISHED, FAILED, and then any unknown status.
+func SortComposes(composes []Compose) []Compose {
+	stateOrder := map[string]int{"RUNNING": 0, "WAITING": 1, "FINISHED": 2, "FAILED": 3}
+	order := func(state string) int {
+		if o, ok := stateOrder[state]; ok {
+			return o
+		}
+		return 4
+	}
+	sort.SliceStable(composes,
+		func(i, j int) bool {
+			ci := composes[i]
+			cj := composes[j]
+			if ci.State != cj.State {
+				return order(ci.State) < order(cj.State)
+			} else if ci.Blueprint != cj.Blueprint {
+				return ci.Blueprint < cj.Blueprint
+			} else if ci.Version != cj.Version {
+				return ci.Version < cj.Version
+			}
+			return strings.Join(ci.Types, ",") < strings.Join(cj.Types, ",")
+		})
+	return composes
+}
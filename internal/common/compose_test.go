@@ -0,0 +1,123 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBackend is a ComposeBackend that only knows about its list of composes
+// If err is set Info returns it instead.
+type testBackend struct {
+	name     string
+	composes []Compose
+	err      error
+}
+
+func (b testBackend) API() string { return b.name }
+
+func (b testBackend) List() ([]Compose, error) { return b.composes, nil }
+
+func (b testBackend) Info(id string) (Compose, error) {
+	if b.err != nil {
+		return Compose{}, b.err
+	}
+	for _, c := range b.composes {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return Compose{}, UnknownComposeError(fmt.Sprintf("%s: compose %s doesn't exist", b.name, id))
+}
+
+func (b testBackend) Wait(id string, timeout, interval time.Duration) (bool, Compose, error) {
+	c, err := b.Info(id)
+	return false, c, err
+}
+
+func (b testBackend) Delete(id string) error { return nil }
+
+func (b testBackend) Cancel(id string) error { return nil }
+
+func (b testBackend) Image(id, path string) (string, error) { return "", nil }
+
+func (b testBackend) Logs(id string) (string, error) { return "", nil }
+
+func (b testBackend) Metadata(id string) (ComposeMetadata, error) { return ComposeMetadata{}, nil }
+
+func TestComposeDone(t *testing.T) {
+	assert.False(t, Compose{State: "WAITING"}.Done())
+	assert.False(t, Compose{State: "RUNNING"}.Done())
+	assert.True(t, Compose{State: "FINISHED"}.Done())
+	assert.True(t, Compose{State: "FAILED"}.Done())
+}
+
+func TestUnknownComposeError(t *testing.T) {
+	err := UnknownComposeError("compose 1234 doesn't exist")
+	assert.True(t, errors.Is(err, ErrUnknownCompose))
+	assert.Equal(t, "compose 1234 doesn't exist", err.Error())
+	assert.False(t, errors.Is(fmt.Errorf("compose 1234 doesn't exist"), ErrUnknownCompose))
+}
+
+func TestFindCompose(t *testing.T) {
+	cloud := testBackend{name: "cloud", composes: []Compose{{ID: "1111", API: "cloud", State: "RUNNING"}}}
+	weldr := testBackend{name: "weldr", composes: []Compose{{ID: "2222", API: "weldr", State: "FINISHED"}}}
+
+	b, c, err := FindCompose("2222", cloud, weldr)
+	require.Nil(t, err)
+	assert.Equal(t, "weldr", b.API())
+	assert.Equal(t, "FINISHED", c.State)
+
+	b, c, err = FindCompose("1111", cloud, weldr)
+	require.Nil(t, err)
+	assert.Equal(t, "cloud", b.API())
+	assert.Equal(t, "RUNNING", c.State)
+
+	// The error is from the last backend
+	b, _, err = FindCompose("3333", cloud, weldr)
+	assert.Nil(t, b)
+	assert.True(t, errors.Is(err, ErrUnknownCompose))
+	assert.Equal(t, "weldr: compose 3333 doesn't exist", err.Error())
+
+	_, _, err = FindCompose("3333")
+	assert.True(t, errors.Is(err, ErrUnknownCompose))
+}
+
+func TestFindComposeError(t *testing.T) {
+	// Errors other than an unknown compose are returned without trying the next backend
+	cloud := testBackend{name: "cloud", err: fmt.Errorf("GET /composes/2222 failed with status 500: server error")}
+	weldr := testBackend{name: "weldr", composes: []Compose{{ID: "2222", API: "weldr", State: "FINISHED"}}}
+
+	b, _, err := FindCompose("2222", cloud, weldr)
+	assert.Nil(t, b)
+	assert.False(t, errors.Is(err, ErrUnknownCompose))
+	assert.Equal(t, "GET /composes/2222 failed with status 500: server error", err.Error())
+
+	// An unknown compose moves on to the next backend
+	cloud.err = UnknownComposeError("cloud: compose 2222 doesn't exist")
+	b, c, err := FindCompose("2222", cloud, weldr)
+	require.Nil(t, err)
+	assert.Equal(t, "weldr", b.API())
+	assert.Equal(t, "FINISHED", c.State)
+}
+
+func TestSortComposes(t *testing.T) {
+	composes := []Compose{
+		{ID: "1", State: "FAILED", Blueprint: "http-server", Version: "0.0.1", Types: []string{"qcow2"}},
+		{ID: "2", State: "FINISHED", Blueprint: "http-server", Version: "0.0.1", Types: []string{"qcow2"}},
+		{ID: "3", State: "BROKEN", Blueprint: "http-server", Version: "0.0.1", Types: []string{"qcow2"}},
+		{ID: "4", State: "RUNNING", Blueprint: "tmux", Version: "0.0.2", Types: []string{"qcow2"}},
+		{ID: "5", State: "RUNNING", Blueprint: "tmux", Version: "0.0.1", Types: []string{"qcow2", "ami"}},
+		{ID: "6", State: "RUNNING", Blueprint: "tmux", Version: "0.0.1", Types: []string{"ami"}},
+		{ID: "7", State: "WAITING", Blueprint: "http-server", Version: "0.0.1", Types: []string{"qcow2"}},
+	}
+	var ids []string
+	for _, c := range SortComposes(composes) {
+		ids = append(ids, c.ID)
+	}
+	assert.Equal(t, []string{"6", "5", "4", "7", "2", "1", "3"}, ids)
+}
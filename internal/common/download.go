@@ -0,0 +1,403 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MaxResumeAttempts is the number of times an interrupted download is resumed before giving up
+const MaxResumeAttempts = 5
+
+// RangeRequestFunc repeats the download request with extra headers
+// It is used to resume a download with a Range request. The response is returned for
+// 200, 206, and 416 status codes, other responses should be returned as errors.
+// The caller closes the response body.
+type RangeRequestFunc func(headers map[string]string) (*http.Response, error)
+
+// Digest is a checksum of the file provided by the server
+type Digest struct {
+	Algorithm string // sha-256 or sha-512
+	Sum       []byte
+}
+
+// String returns the algorithm and hex encoded checksum
+func (d Digest) String() string {
+	return d.Algorithm + ":" + hex.EncodeToString(d.Sum)
+}
+
+// newHash returns a hash for the digest's algorithm
+func (d Digest) newHash() hash.Hash {
+	if d.Algorithm == "sha-512" {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
+// ParseDigest returns the checksum of the complete file from the response headers
+// It supports the Repr-Digest header (RFC 9530) and the older Digest header (RFC 3230)
+// with sha-256 or sha-512. It returns nil if the server did not provide a checksum.
+func ParseDigest(header http.Header) *Digest {
+	// Repr-Digest: sha-256=:BASE64:, sha-512=:BASE64:
+	for _, value := range header.Values("Repr-Digest") {
+		for _, field := range strings.Split(value, ",") {
+			algorithm, sum, ok := strings.Cut(strings.TrimSpace(field), "=")
+			if !ok || len(sum) < 2 || sum[0] != ':' || sum[len(sum)-1] != ':' {
+				continue
+			}
+			if d := newDigest(algorithm, sum[1:len(sum)-1]); d != nil {
+				return d
+			}
+		}
+	}
+
+	// Digest: SHA-256=BASE64
+	for _, value := range header.Values("Digest") {
+		for _, field := range strings.Split(value, ",") {
+			algorithm, sum, ok := strings.Cut(strings.TrimSpace(field), "=")
+			if !ok {
+				continue
+			}
+			if d := newDigest(algorithm, sum); d != nil {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
+// newDigest returns a Digest for a supported algorithm and base64 encoded checksum
+func newDigest(algorithm, sum string) *Digest {
+	algorithm = strings.ToLower(algorithm)
+	if algorithm != "sha-256" && algorithm != "sha-512" {
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(sum)
+	if err != nil {
+		return nil
+	}
+	d := &Digest{Algorithm: algorithm, Sum: decoded}
+	if len(decoded) != d.newHash().Size() {
+		return nil
+	}
+	return d
+}
+
+// DownloadResponseBody saves the body of the response to a file, resuming it if it is interrupted
+// The path is handled the same way as SaveResponseBodyToFile. The body is written to
+// FILENAME.part and renamed to FILENAME when it is complete.
+//
+// If getRange is not nil it is used to resume the download with a Range request:
+//   - When a .part file was left behind by an earlier download it continues from the end of it.
+//   - When the connection drops it continues from the last byte written, up to MaxResumeAttempts times.
+//
+// The server's ETag, or its Last-Modified time, is saved in FILENAME.part.etag and sent
+// in the If-Range header so that the parts of two different files are never joined.
+// A .part file without a saved validator, or with one that does not match the server's,
+// is discarded. Without a validator an interrupted download starts over.
+//
+// If it still fails the .part file is kept so that running the download again resumes it.
+// Without getRange the .part file is removed when the download fails.
+//
+// When the server provides a checksum of the file it is verified before renaming the file,
+// if it does not match the .part file is removed and an error is returned.
+//
+// If progress is not nil it is called as the data is written to the file.
+func DownloadResponseBody(resp *http.Response, path string, getRange RangeRequestFunc, progress ProgressFunc) (string, error) {
+	fileName, err := ResponseFileName(resp, path)
+	if err != nil {
+		return fileName, err
+	}
+	if _, err = os.Stat(fileName); err == nil {
+		return fileName, fmt.Errorf("%s exists, skipping download", fileName)
+	}
+	partName := fileName + ".part"
+
+	d := download{
+		getRange:      getRange,
+		validator:     responseValidator(resp.Header),
+		validatorName: partName + ".etag",
+		digest:        ParseDigest(resp.Header),
+		total:         resp.ContentLength,
+		progress:      progress,
+		name:          filepath.Base(fileName),
+	}
+
+	// Only continue a .part file that is from the same version of the file
+	flags := os.O_RDWR | os.O_CREATE
+	saved, err := os.ReadFile(d.validatorName)
+	if err != nil || len(d.validator) == 0 || string(saved) != d.validator {
+		flags |= os.O_TRUNC
+	}
+	d.f, err = os.OpenFile(partName, flags, 0600)
+	if err != nil {
+		return fileName, err
+	}
+	err = d.saveValidator()
+	if err == nil {
+		err = d.run(resp.Body)
+	}
+	if closeErr := d.f.Close(); err == nil {
+		err = closeErr
+	}
+	if progress != nil {
+		progress(d.name, d.offset, d.total, true)
+	}
+	if err != nil {
+		if getRange == nil || d.corrupt {
+			os.Remove(partName)        //nolint:errcheck
+			os.Remove(d.validatorName) //nolint:errcheck
+		}
+		return fileName, err
+	}
+
+	if _, err = os.Stat(fileName); err == nil {
+		return fileName, fmt.Errorf("%s exists, skipping download", fileName)
+	}
+	if err = os.Rename(partName, fileName); err != nil {
+		return fileName, err
+	}
+	os.Remove(d.validatorName) //nolint:errcheck
+	return fileName, nil
+}
+
+// responseValidator returns the value to use in an If-Range header for the response
+// This is the ETag, or the Last-Modified time if there is no strong ETag. It is empty
+// if the server provides neither of them.
+func responseValidator(header http.Header) string {
+	validator := header.Get("ETag")
+	if len(validator) == 0 || strings.HasPrefix(validator, "W/") {
+		// Weak ETags cannot be used with If-Range
+		validator = header.Get("Last-Modified")
+	}
+	return validator
+}
+
+// download holds the state of a file being downloaded to a .part file
+type download struct {
+	f             *os.File
+	offset        int64
+	getRange      RangeRequestFunc
+	validator     string
+	validatorName string // File the validator is saved in, next to the .part file
+	digest        *Digest
+	corrupt       bool
+	total         int64 // Size of the complete file, -1 if it is unknown
+	progress      ProgressFunc
+	name          string // Name of the file passed to progress
+}
+
+// saveValidator writes the validator next to the .part file so that a later download can resume it
+// The file is removed when there is no validator.
+func (d *download) saveValidator() error {
+	if len(d.validator) == 0 {
+		if err := os.Remove(d.validatorName); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(d.validatorName, []byte(d.validator), 0600)
+}
+
+// Write writes the data to the .part file and reports the progress
+func (d *download) Write(p []byte) (int, error) {
+	n, err := d.f.Write(p)
+	d.offset += int64(n)
+	if d.progress != nil {
+		d.progress(d.name, d.offset, d.total, false)
+	}
+	return n, err
+}
+
+// run writes the body to the file, resuming it when it is interrupted
+// The caller closes body, the bodies of the resume requests are closed by run.
+func (d *download) run(body io.ReadCloser) error {
+	var err error
+	d.offset, err = d.f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	// Continue an earlier download, or start over if it cannot be resumed
+	if d.offset > 0 && d.getRange != nil {
+		// Don't leave the first request streaming the whole file while resuming
+		body.Close() //nolint:errcheck
+		body, err = d.resume()
+		if err != nil {
+			return err
+		}
+		defer body.Close() //nolint:errcheck
+	} else if d.offset > 0 {
+		if err = d.restart(); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		// Write through d so that the offset and progress are updated
+		_, err = io.Copy(d, body)
+		if err == nil {
+			break
+		}
+		if d.getRange == nil || errors.Is(err, context.Canceled) {
+			return err
+		} else if attempt >= MaxResumeAttempts {
+			return fmt.Errorf("download interrupted after %d bytes, run it again to resume: %s", d.offset, err)
+		}
+
+		body, err = d.resume()
+		if err != nil {
+			return fmt.Errorf("download interrupted after %d bytes, run it again to resume: %s", d.offset, err)
+		}
+		defer body.Close() //nolint:errcheck
+	}
+
+	return d.verify()
+}
+
+// resume requests the rest of the file, starting at the current offset
+// If the server sends the whole file instead the .part file is truncated. Without a
+// validator the whole file is requested, a Range could join parts of different files.
+func (d *download) resume() (io.ReadCloser, error) {
+	headers := map[string]string{}
+	if len(d.validator) > 0 {
+		headers["Range"] = fmt.Sprintf("bytes=%d-", d.offset)
+		headers["If-Range"] = d.validator
+	}
+	resp, err := d.getRange(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || start != d.offset {
+			resp.Body.Close() //nolint:errcheck
+			return nil, fmt.Errorf("unexpected Content-Range in response: %q", resp.Header.Get("Content-Range"))
+		}
+		if size >= 0 {
+			d.total = size
+		}
+		return resp.Body, nil
+	case http.StatusOK:
+		// The server does not support ranges, or the file changed
+		if err := d.restartWith(resp); err != nil {
+			resp.Body.Close() //nolint:errcheck
+			return nil, err
+		}
+		return resp.Body, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close() //nolint:errcheck
+		// The .part file may already be complete
+		if _, size, err := parseContentRange(resp.Header.Get("Content-Range")); err == nil && size == d.offset {
+			return http.NoBody, nil
+		}
+		// Otherwise it is larger than the file on the server, start over
+		resp, err = d.getRange(map[string]string{})
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close() //nolint:errcheck
+			return nil, fmt.Errorf("unexpected response status: %d", resp.StatusCode)
+		}
+		if err := d.restartWith(resp); err != nil {
+			resp.Body.Close() //nolint:errcheck
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	resp.Body.Close() //nolint:errcheck
+	return nil, fmt.Errorf("unexpected response status: %d", resp.StatusCode)
+}
+
+// restart truncates the .part file so that the download starts at the beginning
+func (d *download) restart() error {
+	if err := d.f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := d.f.Seek(0, io.SeekStart)
+	d.offset = 0
+	return err
+}
+
+// restartWith starts over using a response with the whole file
+// The file may have changed, so its size, validator, and checksum replace the old ones.
+func (d *download) restartWith(resp *http.Response) error {
+	if err := d.restart(); err != nil {
+		return err
+	}
+	d.total = resp.ContentLength
+	d.validator = responseValidator(resp.Header)
+	d.digest = ParseDigest(resp.Header)
+	return d.saveValidator()
+}
+
+// verify checks the file against the server's checksum, if there is one
+func (d *download) verify() error {
+	if d.digest == nil {
+		return nil
+	}
+	if _, err := d.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	h := d.digest.newHash()
+	if _, err := io.Copy(h, d.f); err != nil {
+		return err
+	}
+	if sum := h.Sum(nil); !bytes.Equal(sum, d.digest.Sum) {
+		d.corrupt = true
+		return fmt.Errorf("checksum mismatch: expected %s got %s:%s", d.digest, d.digest.Algorithm, hex.EncodeToString(sum))
+	}
+	return nil
+}
+
+// parseContentRange returns the start and total size from a Content-Range header
+// eg. 'bytes 100-199/200' or 'bytes */200'. The size is -1 if it is unknown.
+func parseContentRange(header string) (int64, int64, error) {
+	value, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", header)
+	}
+	byteRange, sizeStr, ok := strings.Cut(value, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", header)
+	}
+	size := int64(-1)
+	if sizeStr != "*" {
+		var err error
+		size, err = strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range: %q", header)
+		}
+	}
+	if byteRange == "*" {
+		return 0, size, nil
+	}
+	startStr, _, ok := strings.Cut(byteRange, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", header)
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", header)
+	}
+	return start, size, nil
+}
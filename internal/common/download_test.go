@@ -0,0 +1,323 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage is the content of the file being downloaded
+var testImage = []byte(strings.Repeat("A Very Short File. ", 100))
+
+// brokenReader returns the data and then fails, like a dropped connection
+type brokenReader struct {
+	r io.Reader
+}
+
+func (b *brokenReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset by peer")
+	}
+	return n, err
+}
+
+// newImageResponse returns a response with part of the image
+// If broken is true the body fails after returning the data.
+func newImageResponse(status int, data []byte, broken bool) *http.Response {
+	var body io.Reader = bytes.NewReader(data)
+	if broken {
+		body = &brokenReader{body}
+	}
+	resp := &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(body),
+		Header:     http.Header{},
+	}
+	resp.Header.Set("Content-Disposition", "attachment; filename=disk.qcow2")
+	resp.Header.Set("ETag", `"image-etag"`)
+	sum := sha256.Sum256(testImage)
+	resp.Header.Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum[:]))
+	return resp
+}
+
+// rangeServer returns a RangeRequestFunc that serves the image
+// The first `breaks` responses fail after returning half of the requested range.
+func rangeServer(t *testing.T, breaks int, requests *[]map[string]string) RangeRequestFunc {
+	return func(headers map[string]string) (*http.Response, error) {
+		*requests = append(*requests, headers)
+		var start int
+		if r, ok := headers["Range"]; ok {
+			_, err := fmt.Sscanf(r, "bytes=%d-", &start)
+			require.Nil(t, err)
+			assert.Equal(t, `"image-etag"`, headers["If-Range"])
+		}
+		if start >= len(testImage) {
+			resp := newImageResponse(http.StatusRequestedRangeNotSatisfiable, nil, false)
+			resp.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", len(testImage)))
+			return resp, nil
+		}
+
+		end := len(testImage)
+		broken := breaks > 0
+		if broken {
+			end = start + (end-start)/2
+			breaks--
+		}
+		status := http.StatusOK
+		if start > 0 {
+			status = http.StatusPartialContent
+		}
+		resp := newImageResponse(status, testImage[start:end], broken)
+		if start > 0 {
+			resp.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(testImage)-1, len(testImage)))
+		}
+		return resp, nil
+	}
+}
+
+func TestParseDigest(t *testing.T) {
+	sum256 := sha256.Sum256(testImage)
+	sum512 := sha512.Sum512(testImage)
+	b64_256 := base64.StdEncoding.EncodeToString(sum256[:])
+	b64_512 := base64.StdEncoding.EncodeToString(sum512[:])
+
+	header := http.Header{}
+	assert.Nil(t, ParseDigest(header))
+
+	header.Set("Digest", "SHA-256="+b64_256)
+	assert.Equal(t, &Digest{"sha-256", sum256[:]}, ParseDigest(header))
+
+	header.Set("Digest", "md5=abcdef, sha-512="+b64_512)
+	assert.Equal(t, &Digest{"sha-512", sum512[:]}, ParseDigest(header))
+
+	// Repr-Digest is preferred
+	header.Set("Repr-Digest", "sha-256=:"+b64_256+":")
+	assert.Equal(t, &Digest{"sha-256", sum256[:]}, ParseDigest(header))
+
+	// Bad checksums are ignored
+	header = http.Header{}
+	header.Set("Digest", "sha-256=not-base64")
+	assert.Nil(t, ParseDigest(header))
+	header.Set("Digest", "sha-256="+b64_512)
+	assert.Nil(t, ParseDigest(header))
+	header.Set("Repr-Digest", "sha-256="+b64_256)
+	assert.Nil(t, ParseDigest(header))
+}
+
+func TestParseContentRange(t *testing.T) {
+	start, size, err := parseContentRange("bytes 100-199/200")
+	require.Nil(t, err)
+	assert.Equal(t, int64(100), start)
+	assert.Equal(t, int64(200), size)
+
+	start, size, err = parseContentRange("bytes 100-199/*")
+	require.Nil(t, err)
+	assert.Equal(t, int64(100), start)
+	assert.Equal(t, int64(-1), size)
+
+	_, size, err = parseContentRange("bytes */200")
+	require.Nil(t, err)
+	assert.Equal(t, int64(200), size)
+
+	for _, h := range []string{"", "bytes", "bytes 100", "items 1-2/3", "bytes a-b/3", "bytes 1-2/c"} {
+		_, _, err = parseContentRange(h)
+		assert.Error(t, err, h)
+	}
+}
+
+func TestDownloadResponseBody(t *testing.T) {
+	tdir := t.TempDir()
+	var requests []map[string]string
+
+	fn, err := DownloadResponseBody(newImageResponse(200, testImage, false), tdir, rangeServer(t, 0, &requests), nil)
+	require.Nil(t, err)
+	assert.Equal(t, filepath.Join(tdir, "disk.qcow2"), fn)
+	data, err := os.ReadFile(fn)
+	require.Nil(t, err)
+	assert.Equal(t, testImage, data)
+	assert.Equal(t, 0, len(requests))
+	_, err = os.Stat(fn + ".part")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(fn + ".part.etag")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// It will not overwrite an existing file
+	_, err = DownloadResponseBody(newImageResponse(200, testImage, false), tdir, rangeServer(t, 0, &requests), nil)
+	assert.ErrorContains(t, err, "exists, skipping download")
+}
+
+func TestDownloadResponseBodyResume(t *testing.T) {
+	tdir := t.TempDir()
+	var requests []map[string]string
+
+	// The connection drops twice, it should resume from where it stopped
+	resp := newImageResponse(200, testImage[:100], true)
+	fn, err := DownloadResponseBody(resp, tdir, rangeServer(t, 1, &requests), nil)
+	require.Nil(t, err)
+	data, err := os.ReadFile(fn)
+	require.Nil(t, err)
+	assert.Equal(t, testImage, data)
+	require.Equal(t, 2, len(requests))
+	assert.Equal(t, "bytes=100-", requests[0]["Range"])
+	assert.Equal(t, fmt.Sprintf("bytes=%d-", 100+(len(testImage)-100)/2), requests[1]["Range"])
+}
+
+func TestDownloadResponseBodyPartFile(t *testing.T) {
+	tdir := t.TempDir()
+	fn := filepath.Join(tdir, "disk.qcow2")
+	var requests []map[string]string
+
+	// A .part file from an earlier download of the same file is continued
+	err := os.WriteFile(fn+".part", testImage[:500], 0600)
+	require.Nil(t, err)
+	err = os.WriteFile(fn+".part.etag", []byte(`"image-etag"`), 0600)
+	require.Nil(t, err)
+	_, err = DownloadResponseBody(newImageResponse(200, testImage, false), fn, rangeServer(t, 0, &requests), nil)
+	require.Nil(t, err)
+	data, err := os.ReadFile(fn)
+	require.Nil(t, err)
+	assert.Equal(t, testImage, data)
+	require.Equal(t, 1, len(requests))
+	assert.Equal(t, "bytes=500-", requests[0]["Range"])
+	require.Nil(t, os.Remove(fn))
+
+	// A complete .part file is only renamed
+	requests = nil
+	err = os.WriteFile(fn+".part", testImage, 0600)
+	require.Nil(t, err)
+	err = os.WriteFile(fn+".part.etag", []byte(`"image-etag"`), 0600)
+	require.Nil(t, err)
+	_, err = DownloadResponseBody(newImageResponse(200, testImage, false), fn, rangeServer(t, 0, &requests), nil)
+	require.Nil(t, err)
+	data, err = os.ReadFile(fn)
+	require.Nil(t, err)
+	assert.Equal(t, testImage, data)
+	require.Equal(t, 1, len(requests))
+	require.Nil(t, os.Remove(fn))
+
+	// A .part file without a saved validator is replaced
+	requests = nil
+	err = os.WriteFile(fn+".part", []byte("Something else entirely"), 0600)
+	require.Nil(t, err)
+	_, err = DownloadResponseBody(newImageResponse(200, testImage, false), fn, rangeServer(t, 0, &requests), nil)
+	require.Nil(t, err)
+	data, err = os.ReadFile(fn)
+	require.Nil(t, err)
+	assert.Equal(t, testImage, data)
+	assert.Equal(t, 0, len(requests))
+	require.Nil(t, os.Remove(fn))
+
+	// As is one from a different version of the file
+	err = os.WriteFile(fn+".part", []byte("Something else entirely"), 0600)
+	require.Nil(t, err)
+	err = os.WriteFile(fn+".part.etag", []byte(`"old-etag"`), 0600)
+	require.Nil(t, err)
+	_, err = DownloadResponseBody(newImageResponse(200, testImage, false), fn, rangeServer(t, 0, &requests), nil)
+	require.Nil(t, err)
+	data, err = os.ReadFile(fn)
+	require.Nil(t, err)
+	assert.Equal(t, testImage, data)
+	assert.Equal(t, 0, len(requests))
+	_, err = os.Stat(fn + ".part.etag")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	require.Nil(t, os.Remove(fn))
+
+	// Without range support the .part file is replaced
+	err = os.WriteFile(fn+".part", []byte("Something else entirely"), 0600)
+	require.Nil(t, err)
+	_, err = DownloadResponseBody(newImageResponse(200, testImage, false), fn, nil, nil)
+	require.Nil(t, err)
+	data, err = os.ReadFile(fn)
+	require.Nil(t, err)
+	assert.Equal(t, testImage, data)
+}
+
+func TestDownloadResponseBodyRestart(t *testing.T) {
+	tdir := t.TempDir()
+	fn := filepath.Join(tdir, "disk.qcow2")
+
+	// The server ignores the Range and sends the whole file
+	err := os.WriteFile(fn+".part", []byte("Something else entirely"), 0600)
+	require.Nil(t, err)
+	err = os.WriteFile(fn+".part.etag", []byte(`"image-etag"`), 0600)
+	require.Nil(t, err)
+	getRange := func(headers map[string]string) (*http.Response, error) {
+		return newImageResponse(200, testImage, false), nil
+	}
+	_, err = DownloadResponseBody(newImageResponse(200, testImage, false), fn, getRange, nil)
+	require.Nil(t, err)
+	data, err := os.ReadFile(fn)
+	require.Nil(t, err)
+	assert.Equal(t, testImage, data)
+}
+
+func TestDownloadResponseBodyNoValidator(t *testing.T) {
+	tdir := t.TempDir()
+	fn := filepath.Join(tdir, "disk.qcow2")
+	var requests []map[string]string
+
+	// Without an ETag or Last-Modified the interrupted download starts over
+	resp := newImageResponse(200, testImage[:100], true)
+	resp.Header.Del("ETag")
+	getRange := func(headers map[string]string) (*http.Response, error) {
+		requests = append(requests, headers)
+		return newImageResponse(200, testImage, false), nil
+	}
+	_, err := DownloadResponseBody(resp, fn, getRange, nil)
+	require.Nil(t, err)
+	data, err := os.ReadFile(fn)
+	require.Nil(t, err)
+	assert.Equal(t, testImage, data)
+	assert.Equal(t, []map[string]string{{}}, requests)
+}
+
+func TestDownloadResponseBodyFailed(t *testing.T) {
+	tdir := t.TempDir()
+	fn := filepath.Join(tdir, "disk.qcow2")
+	var requests []map[string]string
+
+	// It gives up after MaxResumeAttempts, leaving the .part file to resume later
+	_, err := DownloadResponseBody(newImageResponse(200, testImage[:100], true), fn, rangeServer(t, 100, &requests), nil)
+	assert.ErrorContains(t, err, "run it again to resume: connection reset by peer")
+	assert.Equal(t, MaxResumeAttempts, len(requests))
+	_, err = os.Stat(fn + ".part")
+	assert.Nil(t, err)
+	etag, err := os.ReadFile(fn + ".part.etag")
+	assert.Nil(t, err)
+	assert.Equal(t, `"image-etag"`, string(etag))
+	_, err = os.Stat(fn)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Without range support the .part file is removed
+	require.Nil(t, os.Remove(fn+".part"))
+	_, err = DownloadResponseBody(newImageResponse(200, testImage[:100], true), fn, nil, nil)
+	assert.ErrorContains(t, err, "connection reset by peer")
+	_, err = os.Stat(fn + ".part")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDownloadResponseBodyChecksum(t *testing.T) {
+	tdir := t.TempDir()
+	fn := filepath.Join(tdir, "disk.qcow2")
+
+	resp := newImageResponse(200, []byte("Not the image"), false)
+	_, err := DownloadResponseBody(resp, fn, nil, nil)
+	assert.ErrorContains(t, err, "checksum mismatch: expected sha-256:")
+	_, err = os.Stat(fn + ".part")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(fn)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
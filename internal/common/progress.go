@@ -0,0 +1,183 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgressFunc is called as a file is downloaded
+// It is passed the name of the file being written, the number of bytes received so far,
+// and the total size of the file, or -1 if the server did not send it. received includes
+// the bytes from an earlier, resumed, download. It is called one last time with done set
+// to true when the download is finished or has failed.
+type ProgressFunc func(fileName string, received, total int64, done bool)
+
+const (
+	// InteractiveProgressInterval is how often the progress line is redrawn on a terminal
+	InteractiveProgressInterval = 250 * time.Millisecond
+
+	// ProgressInterval is how often a progress line is written when not on a terminal
+	ProgressInterval = 10 * time.Second
+)
+
+// progressWriter writes the progress of downloads to an io.Writer
+type progressWriter struct {
+	mu          sync.Mutex
+	w           io.Writer
+	interactive bool
+	interval    time.Duration
+	now         func() time.Time
+
+	fileName string    // The file currently being downloaded
+	start    time.Time // When the first progress was received
+	first    int64     // The bytes already downloaded when it started, not counted in the rate
+	last     time.Time // When the progress was last written
+	width    int       // Length of the last interactive line, used to clear it
+	reported bool      // Progress has been written for the current file
+}
+
+// NewProgressWriter returns a ProgressFunc that writes the progress of downloads to w
+// When interactive is true a single line with the bytes received, the rate, and the
+// estimated time remaining is redrawn in place. Otherwise a line is written every
+// ProgressInterval, and when the download is done. Downloads that finish before the first
+// line is written are not reported.
+func NewProgressWriter(w io.Writer, interactive bool) ProgressFunc {
+	return newProgressWriter(w, interactive).progress
+}
+
+// newProgressWriter returns a progressWriter using the interval for the type of output
+func newProgressWriter(w io.Writer, interactive bool) *progressWriter {
+	p := &progressWriter{
+		w:           w,
+		interactive: interactive,
+		interval:    ProgressInterval,
+		now:         time.Now,
+	}
+	if interactive {
+		p.interval = InteractiveProgressInterval
+	}
+	return p
+}
+
+// IsTerminal returns true if w is a terminal
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || f == nil {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// progress is the ProgressFunc for the writer
+func (p *progressWriter) progress(fileName string, received, total int64, done bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if fileName != p.fileName || p.start.IsZero() {
+		p.fileName = fileName
+		p.start = now
+		p.first = received
+		p.last = now
+		p.reported = false
+		if !done {
+			// Wait for some data before estimating the rate
+			return
+		}
+	}
+	if !done && now.Sub(p.last) < p.interval {
+		return
+	}
+	if done && !p.reported {
+		// Too quick to bother reporting it
+		p.reset()
+		return
+	}
+	p.last = now
+	p.reported = true
+
+	line := p.format(received, total, now, done)
+	if !p.interactive {
+		fmt.Fprintln(p.w, line) //nolint:errcheck
+	} else {
+		// Pad the line to overwrite the end of a longer one
+		pad := ""
+		if len(line) < p.width {
+			pad = strings.Repeat(" ", p.width-len(line))
+		}
+		p.width = len(line)
+		fmt.Fprintf(p.w, "\r%s%s", line, pad) //nolint:errcheck
+		if done {
+			fmt.Fprintln(p.w) //nolint:errcheck
+		}
+	}
+
+	if done {
+		p.reset()
+	}
+}
+
+// reset clears the state so that the next download starts over
+func (p *progressWriter) reset() {
+	p.fileName = ""
+	p.start = time.Time{}
+	p.width = 0
+	p.reported = false
+}
+
+// format returns a line describing the download progress
+// eg. 'disk.qcow2: 1.2 GiB of 10.0 GiB (12%), 45.3 MiB/s, 3m12s remaining'
+func (p *progressWriter) format(received, total int64, now time.Time, done bool) string {
+	elapsed := now.Sub(p.start)
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(received-p.first) / elapsed.Seconds()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", p.fileName, FormatBytes(received))
+	if done {
+		fmt.Fprintf(&b, " in %s", elapsed.Round(time.Second))
+		if rate > 0 {
+			fmt.Fprintf(&b, ", %s/s", FormatBytes(int64(rate)))
+		}
+		return b.String()
+	}
+	if total > 0 {
+		fmt.Fprintf(&b, " of %s (%d%%)", FormatBytes(total), received*100/total)
+	}
+	if rate > 0 {
+		fmt.Fprintf(&b, ", %s/s", FormatBytes(int64(rate)))
+		if total > received {
+			eta := time.Duration(float64(total-received) / rate * float64(time.Second))
+			fmt.Fprintf(&b, ", %s remaining", eta.Round(time.Second))
+		}
+	}
+	return b.String()
+}
+
+// FormatBytes returns the size using binary units, eg. 1.5 GiB
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit && exp < 5; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,101 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock returns a time that is advanced by the test
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func newTestProgressWriter(interactive bool) (*progressWriter, *bytes.Buffer, *fakeClock) {
+	var buf bytes.Buffer
+	clock := &fakeClock{t: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)}
+	p := newProgressWriter(&buf, interactive)
+	p.now = clock.now
+	return p, &buf, clock
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "0 B", FormatBytes(0))
+	assert.Equal(t, "1023 B", FormatBytes(1023))
+	assert.Equal(t, "1.0 KiB", FormatBytes(1024))
+	assert.Equal(t, "1.5 MiB", FormatBytes(1536*1024))
+	assert.Equal(t, "10.0 GiB", FormatBytes(10*1024*1024*1024))
+}
+
+func TestProgressWriter(t *testing.T) {
+	p, buf, clock := newTestProgressWriter(false)
+	const total = 1000 * 1024 * 1024
+
+	p.progress("disk.raw", 0, total, false)
+	clock.t = clock.t.Add(5 * time.Second)
+	p.progress("disk.raw", 50*1024*1024, total, false)
+	assert.Equal(t, "", buf.String())
+
+	// A line is written every ProgressInterval
+	clock.t = clock.t.Add(5 * time.Second)
+	p.progress("disk.raw", 100*1024*1024, total, false)
+	assert.Equal(t, "disk.raw: 100.0 MiB of 1000.0 MiB (10%), 10.0 MiB/s, 1m30s remaining\n", buf.String())
+
+	buf.Reset()
+	clock.t = clock.t.Add(90 * time.Second)
+	p.progress("disk.raw", total, total, true)
+	assert.Equal(t, "disk.raw: 1000.0 MiB in 1m40s, 10.0 MiB/s\n", buf.String())
+}
+
+func TestProgressWriterQuick(t *testing.T) {
+	// Downloads that finish before the first line are not reported
+	p, buf, clock := newTestProgressWriter(false)
+	p.progress("logs.tar", 0, 2048, false)
+	clock.t = clock.t.Add(time.Second)
+	p.progress("logs.tar", 2048, 2048, false)
+	p.progress("logs.tar", 2048, 2048, true)
+	assert.Equal(t, "", buf.String())
+}
+
+func TestProgressWriterInteractive(t *testing.T) {
+	p, buf, clock := newTestProgressWriter(true)
+
+	// Resumed downloads do not count the earlier bytes in the rate
+	p.progress("disk.qcow2", 4096, -1, false)
+	clock.t = clock.t.Add(time.Second)
+	p.progress("disk.qcow2", 4096+2048, -1, false)
+	assert.Equal(t, "\rdisk.qcow2: 6.0 KiB, 2.0 KiB/s", buf.String())
+
+	buf.Reset()
+	clock.t = clock.t.Add(time.Second)
+	p.progress("disk.qcow2", 8192, -1, true)
+	assert.Equal(t, "\rdisk.qcow2: 8.0 KiB in 2s, 2.0 KiB/s\n", buf.String())
+}
+
+func TestDownloadResponseBodyProgress(t *testing.T) {
+	tdir := t.TempDir()
+	var requests []map[string]string
+	var received []int64
+	var finished bool
+	progress := func(fileName string, r, total int64, done bool) {
+		assert.Equal(t, "disk.qcow2", fileName)
+		assert.Equal(t, int64(len(testImage)), total)
+		received = append(received, r)
+		finished = done
+	}
+
+	// The progress continues from where the interrupted download stopped
+	resp := newImageResponse(200, testImage[:100], true)
+	resp.ContentLength = int64(len(testImage))
+	_, err := DownloadResponseBody(resp, tdir, rangeServer(t, 0, &requests), progress)
+	assert.Nil(t, err)
+	assert.True(t, finished)
+	assert.Equal(t, int64(100), received[0])
+	assert.Equal(t, int64(len(testImage)), received[len(received)-1])
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// NewTLSConfig returns a tls.Config using the CA bundle and client certificate files
+// caFile is a PEM bundle of CA certificates used to verify the server, if it is empty
+// the system's CA certificates are used.
+// certFile and keyFile are the PEM client certificate and key, they are optional but
+// must be passed together.
+func NewTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if len(caFile) > 0 {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificates: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no CA certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if len(certFile) > 0 || len(keyFile) > 0 {
+		if len(certFile) == 0 || len(keyFile) == 0 {
+			return nil, fmt.Errorf("both the client certificate and key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %s", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// NewRemoteHTTPClient returns a client for connecting to a server over tcp
+// serverURL is the protocol, host, and optional port of the server, eg. https://builder:443
+// tlsConfig is used for https connections, if it is nil the default settings are used.
+// It returns the http client, protocol, and host:port to use when building request urls.
+func NewRemoteHTTPClient(serverURL string, tlsConfig *tls.Config) (HTTPClient, string, string, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid server url: %s", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, "", "", fmt.Errorf("invalid server url %s: protocol must be http or https", serverURL)
+	}
+	if len(u.Host) == 0 {
+		return nil, "", "", fmt.Errorf("invalid server url %s: missing host", serverURL)
+	}
+	if len(u.Path) > 0 && u.Path != "/" {
+		return nil, "", "", fmt.Errorf("invalid server url %s: paths are not supported", serverURL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if u.Scheme == "https" && tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{Transport: transport}, u.Scheme, u.Host, nil
+}
+
+// AddBearerToken returns a copy of the headers with the Authorization header set
+// If token is empty the original headers are returned.
+func AddBearerToken(headers map[string]string, token string) map[string]string {
+	if len(token) == 0 {
+		return headers
+	}
+	h := map[string]string{"Authorization": "Bearer " + token}
+	for k, v := range headers {
+		h[k] = v
+	}
+	return h
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRemoteHTTPClient(t *testing.T) {
+	client, protocol, host, err := NewRemoteHTTPClient("https://builder.example.com:443", nil)
+	require.Nil(t, err)
+	assert.NotNil(t, client)
+	assert.Equal(t, "https", protocol)
+	assert.Equal(t, "builder.example.com:443", host)
+
+	_, protocol, host, err = NewRemoteHTTPClient("http://localhost:8080/", nil)
+	require.Nil(t, err)
+	assert.Equal(t, "http", protocol)
+	assert.Equal(t, "localhost:8080", host)
+}
+
+func TestNewRemoteHTTPClientErrors(t *testing.T) {
+	_, _, _, err := NewRemoteHTTPClient("unix:///run/weldr/api.socket", nil)
+	assert.ErrorContains(t, err, "protocol must be http or https")
+	_, _, _, err = NewRemoteHTTPClient("https://", nil)
+	assert.ErrorContains(t, err, "missing host")
+	_, _, _, err = NewRemoteHTTPClient("https://builder/api/v1", nil)
+	assert.ErrorContains(t, err, "paths are not supported")
+	_, _, _, err = NewRemoteHTTPClient("https://builder:port", nil)
+	assert.ErrorContains(t, err, "invalid server url")
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	config, err := NewTLSConfig("", "", "")
+	require.Nil(t, err)
+	assert.Nil(t, config.RootCAs)
+	assert.Equal(t, 0, len(config.Certificates))
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	_, err := NewTLSConfig("/no/such/ca.pem", "", "")
+	assert.ErrorContains(t, err, "reading CA certificates")
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	require.Nil(t, os.WriteFile(notPEM, []byte("not a certificate"), 0600))
+	_, err = NewTLSConfig(notPEM, "", "")
+	assert.ErrorContains(t, err, "no CA certificates found")
+
+	_, err = NewTLSConfig("", "client.pem", "")
+	assert.ErrorContains(t, err, "both the client certificate and key are required")
+	_, err = NewTLSConfig("", "/no/such/client.pem", "/no/such/client.key")
+	assert.ErrorContains(t, err, "loading client certificate")
+}
+
+func TestAddBearerToken(t *testing.T) {
+	headers := map[string]string{"Content-Type": "application/json"}
+	assert.Equal(t, headers, AddBearerToken(headers, ""))
+
+	h := AddBearerToken(headers, "secret")
+	assert.Equal(t, "Bearer secret", h["Authorization"])
+	assert.Equal(t, "application/json", h["Content-Type"])
+	// The original map is not modified
+	assert.Equal(t, 1, len(headers))
+
+	h = AddBearerToken(nil, "secret")
+	assert.Equal(t, map[string]string{"Authorization": "Bearer secret"}, h)
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// errRequestTimeout is used as the context cause when the server does not respond in time
+var errRequestTimeout = errors.New("request timeout")
+
+// cancelOnClose cancels the request's context when the response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelCauseFunc
+}
+
+// Close closes the body and then releases the request's context
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel(nil)
+	return err
+}
+
+// DoRequest sends a request using the client's context
+// The request is cancelled when ctx is cancelled, eg. by ctrl-c, at any point while it
+// is running, including while the caller is reading the response body.
+// If timeout is > 0 the server must send the response headers within that time, it does
+// not limit how long it takes to read the body so that large downloads are not interrupted.
+func DoRequest(ctx context.Context, client HTTPClient, timeout time.Duration, method, url string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+
+	for h, v := range headers {
+		req.Header.Set(h, v)
+	}
+
+	var timer *time.Timer
+	if timeout > 0 {
+		timer = time.AfterFunc(timeout, func() { cancel(errRequestTimeout) })
+	}
+	resp, err := client.Do(req)
+	if timer != nil {
+		timer.Stop()
+	}
+	if err != nil {
+		cause := context.Cause(ctx)
+		cancel(nil)
+		if errors.Is(cause, errRequestTimeout) {
+			return nil, fmt.Errorf("%s %s: no response from the server after %v", method, req.URL.Path, timeout)
+		}
+		return nil, err
+	}
+
+	// The context needs to stay valid until the caller is done reading the body
+	if resp.Body != nil {
+		resp.Body = cancelOnClose{resp.Body, cancel}
+	} else {
+		cancel(nil)
+	}
+	return resp, nil
+}
+
+// RequestURI returns the path and query of a url, used for logging
+// If it cannot be parsed the full url is returned.
+func RequestURI(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.RequestURI()
+}
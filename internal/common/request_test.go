@@ -0,0 +1,82 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockClient implements HTTPClient for testing
+type mockClient struct {
+	doFunc func(req *http.Request) (*http.Response, error)
+}
+
+func (m *mockClient) Do(req *http.Request) (*http.Response, error) {
+	return m.doFunc(req)
+}
+
+// waitForCancel blocks until the request is cancelled, like a server that never answers
+func waitForCancel(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestDoRequest(t *testing.T) {
+	var reqCtx context.Context
+	mc := &mockClient{doFunc: func(req *http.Request) (*http.Response, error) {
+		reqCtx = req.Context()
+		assert.Equal(t, "text/x-toml", req.Header.Get("Content-Type"))
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte("response body"))),
+		}, nil
+	}}
+
+	resp, err := DoRequest(context.Background(), mc, time.Second, "POST", "http://localhost/api/v1/route",
+		bytes.NewReader([]byte("data")), map[string]string{"Content-Type": "text/x-toml"})
+	require.Nil(t, err)
+	require.NotNil(t, resp)
+
+	// The context is still valid while the body is being read
+	assert.Nil(t, reqCtx.Err())
+	body, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	assert.Equal(t, []byte("response body"), body)
+
+	// And released when the body is closed
+	assert.Nil(t, resp.Body.Close())
+	assert.NotNil(t, reqCtx.Err())
+}
+
+func TestDoRequestTimeout(t *testing.T) {
+	mc := &mockClient{doFunc: waitForCancel}
+
+	_, err := DoRequest(context.Background(), mc, 10*time.Millisecond, "GET", "http://localhost/api/v1/status", nil, nil)
+	require.NotNil(t, err)
+	assert.ErrorContains(t, err, "no response from the server after 10ms")
+}
+
+func TestDoRequestCancel(t *testing.T) {
+	mc := &mockClient{doFunc: waitForCancel}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := DoRequest(ctx, mc, 0, "GET", "http://localhost/api/v1/status", nil, nil)
+	require.NotNil(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRequestURI(t *testing.T) {
+	assert.Equal(t, "/api/v1/compose/log/UUID?size=1024", RequestURI("http://localhost/api/v1/compose/log/UUID?size=1024"))
+	assert.Equal(t, "/api/status", RequestURI("http://localhost/api/status"))
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxLogBodySize is the maximum number of bytes of a response body written to the request log
+const MaxLogBodySize = 4096
+
+// RequestLogEntry is a single record written to the request log as one line of JSON
+type RequestLogEntry struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route"`
+	Status    int       `json:"status"`
+	LatencyMS float64   `json:"latency_ms"`
+	Body      string    `json:"body,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
+	Binary    bool      `json:"binary,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// logMutex serializes writes to the request logs so that the weldr and cloud clients
+// can share the same log file without interleaving records.
+var logMutex sync.Mutex
+
+// RequestLogger writes a JSON-lines trace of the requests made by the API clients
+type RequestLogger struct {
+	w io.Writer
+}
+
+// NewRequestLogger returns a RequestLogger that writes to w
+// If w is nil it returns nil, and logging is skipped.
+func NewRequestLogger(w io.Writer) *RequestLogger {
+	if w == nil {
+		return nil
+	}
+	return &RequestLogger{w: w}
+}
+
+// Log writes a record for a request to the log
+// If there is a response its body is replaced with a reader that returns the complete,
+// unmodified body to the caller, and the record is written when the body has been read
+// or is closed. The first MaxLogBodySize bytes of the body are included in the record,
+// and the latency is the time until then. The body is never read by the logger, so it
+// does not block streaming responses. Errors writing the log are ignored, it should
+// never break a request.
+func (l *RequestLogger) Log(method, route string, start time.Time, resp *http.Response, reqErr error) {
+	if l == nil {
+		return
+	}
+
+	entry := RequestLogEntry{
+		Time:   start.UTC(),
+		Method: method,
+		Route:  route,
+	}
+	if reqErr != nil {
+		entry.Error = reqErr.Error()
+	}
+	if resp == nil || resp.Body == nil {
+		if resp != nil {
+			entry.Status = resp.StatusCode
+		}
+		l.write(entry, start)
+		return
+	}
+	entry.Status = resp.StatusCode
+	resp.Body = &logBody{ReadCloser: resp.Body, logger: l, entry: entry, start: start}
+}
+
+// write sets the latency and writes the record to the log
+func (l *RequestLogger) write(entry RequestLogEntry, start time.Time) {
+	entry.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	l.w.Write(append(data, '\n')) //nolint:errcheck
+}
+
+// logBody keeps a copy of the start of a response body as the caller reads it
+// The record is written once, at the end of the body or when it is closed.
+type logBody struct {
+	io.ReadCloser
+	logger *RequestLogger
+	entry  RequestLogEntry
+	start  time.Time
+	buf    bytes.Buffer
+	eof    bool
+	once   sync.Once
+}
+
+func (b *logBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := MaxLogBodySize + 1 - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	if err == io.EOF {
+		b.eof = true
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *logBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+// finish writes the record with the part of the body that was read
+func (b *logBody) finish() {
+	b.once.Do(func() {
+		b.entry.Body, b.entry.Truncated, b.entry.Binary = logBodyString(b.buf.Bytes(), b.eof)
+		b.logger.write(b.entry, b.start)
+	})
+}
+
+// logBodyString returns the body to write to the log, whether it was truncated, and
+// whether it looks like binary data. It is truncated if it is larger than MaxLogBodySize
+// or if the caller closed it without reading all of it.
+func logBodyString(buf []byte, eof bool) (string, bool, bool) {
+	body := buf
+	truncated := !eof || len(buf) > MaxLogBodySize
+	if len(buf) > MaxLogBodySize {
+		body = trimPartialRune(buf[:MaxLogBodySize])
+	}
+	// Don't write image data to the log, just note that it was skipped
+	if !utf8.Valid(body) {
+		return "", truncated, true
+	}
+	return string(body), truncated, false
+}
+
+// trimPartialRune removes an incomplete utf8 sequence from the end of buf
+func trimPartialRune(buf []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(buf); i++ {
+		if utf8.RuneStart(buf[len(buf)-i]) {
+			if !utf8.FullRune(buf[len(buf)-i:]) {
+				return buf[:len(buf)-i]
+			}
+			break
+		}
+	}
+	return buf
+}
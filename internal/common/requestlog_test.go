@@ -0,0 +1,135 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLoggerNil(t *testing.T) {
+	// A nil logger must be safe to call
+	var l *RequestLogger
+	l.Log("GET", "/route", time.Now(), nil, nil)
+	assert.Nil(t, NewRequestLogger(nil))
+}
+
+func TestRequestLoggerLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewRequestLogger(&buf)
+
+	resp := &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader([]byte(`{"status": true}`))),
+	}
+	l.Log("GET", "/api/v1/status", time.Now(), resp, nil)
+
+	// The caller must still be able to read the whole body, the record is written at the end of it
+	body, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	assert.Equal(t, `{"status": true}`, string(body))
+	l.Log("POST", "/api/v1/compose", time.Now(), nil, errors.New("connection refused"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, 2, len(lines))
+
+	var entry RequestLogEntry
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "GET", entry.Method)
+	assert.Equal(t, "/api/v1/status", entry.Route)
+	assert.Equal(t, 200, entry.Status)
+	assert.Equal(t, `{"status": true}`, entry.Body)
+	assert.False(t, entry.Truncated)
+	assert.False(t, entry.Binary)
+	assert.False(t, entry.Time.IsZero())
+
+	entry = RequestLogEntry{}
+	require.Nil(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "POST", entry.Method)
+	assert.Equal(t, 0, entry.Status)
+	assert.Equal(t, "connection refused", entry.Error)
+}
+
+func TestRequestLoggerTruncate(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewRequestLogger(&buf)
+
+	data := strings.Repeat("A", MaxLogBodySize*2)
+	resp := &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(data)),
+	}
+	l.Log("GET", "/api/v1/compose/logs/UUID", time.Now(), resp, nil)
+	body, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	assert.Equal(t, data, string(body))
+
+	var entry RequestLogEntry
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.True(t, entry.Truncated)
+	assert.Equal(t, MaxLogBodySize, len(entry.Body))
+}
+
+func TestRequestLoggerBinary(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewRequestLogger(&buf)
+
+	data := []byte{0x1f, 0x8b, 0x08, 0x00, 0xff, 0xfe}
+	resp := &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader(data)),
+	}
+	l.Log("GET", "/api/v1/compose/image/UUID", time.Now(), resp, nil)
+	body, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	assert.Equal(t, data, body)
+
+	var entry RequestLogEntry
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.True(t, entry.Binary)
+	assert.Equal(t, "", entry.Body)
+}
+
+func TestRequestLoggerStream(t *testing.T) {
+	// A streaming body is not read by the logger, the record is written when it is closed
+	var buf bytes.Buffer
+	l := NewRequestLogger(&buf)
+
+	r, w := io.Pipe()
+	resp := &http.Response{
+		StatusCode: 200,
+		Body:       r,
+	}
+	l.Log("GET", "/api/v1/compose/log/UUID?follow=true", time.Now(), resp, nil)
+	assert.Equal(t, 0, buf.Len())
+
+	go func() {
+		w.Write([]byte("Started build\n")) //nolint:errcheck
+	}()
+	line := make([]byte, 14)
+	_, err := io.ReadFull(resp.Body, line)
+	require.Nil(t, err)
+	assert.Equal(t, "Started build\n", string(line))
+	assert.Equal(t, 0, buf.Len())
+
+	require.Nil(t, resp.Body.Close())
+	var entry RequestLogEntry
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "Started build\n", entry.Body)
+	assert.True(t, entry.Truncated)
+
+	// Closing it again does not write another record
+	resp.Body.Close() //nolint:errcheck
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+}
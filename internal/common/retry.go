@@ -0,0 +1,141 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how requests are retried when the connection to the server fails
+// or the server is temporarily unavailable. The zero value disables retries.
+type RetryPolicy struct {
+	Attempts   int           // Total number of attempts, including the first one. 0 or 1 disables retries
+	Backoff    time.Duration // Delay before the first retry, it is doubled for each retry after it
+	MaxBackoff time.Duration // Upper limit for the delay, 0 for no limit
+	Jitter     float64       // Fraction of the delay that is randomized, from 0 to 1
+}
+
+// DefaultRetryPolicy retries 3 times, waiting about 0.5, 1, and 2 seconds
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   4,
+	Backoff:    500 * time.Millisecond,
+	MaxBackoff: 10 * time.Second,
+	Jitter:     0.2,
+}
+
+// RetryFunc is called before a request is retried
+// It is passed the request's method and route, the number of the retry starting at 1,
+// the time it will wait before sending it, and the reason the last attempt failed.
+type RetryFunc func(method, route string, retry int, delay time.Duration, err error)
+
+// Validate returns an error if the policy's values are out of range
+func (p RetryPolicy) Validate() error {
+	if p.Attempts < 0 {
+		return fmt.Errorf("retry attempts must be 0 or more")
+	}
+	if p.Backoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("retry backoff must be 0 or more")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("retry jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// Delay returns the time to wait before the retry, starting at 1 for the first retry
+// The Backoff is doubled for each retry, limited to MaxBackoff, and then up to Jitter
+// of it is randomly added or removed so that clients do not all retry at the same time.
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d += time.Duration(float64(d) * p.Jitter * (2*rand.Float64() - 1))
+	}
+	return d
+}
+
+// IdempotentMethod returns true if repeating the request has the same result as sending it once
+func IdempotentMethod(method string) bool {
+	return slices.Contains([]string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE"}, method)
+}
+
+// retryableStatus returns true for responses from a proxy or a server that is restarting
+func retryableStatus(status int) bool {
+	return slices.Contains([]int{502, 503, 504}, status)
+}
+
+// retryableError returns true if the request failed because of the connection
+// A request that could not connect never reached the server so it can be retried with any
+// method, a dropped connection is only retried if the request is idempotent.
+func retryableError(method string, err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	if !IdempotentMethod(method) {
+		return false
+	}
+	for _, e := range []error{syscall.ECONNRESET, syscall.ECONNREFUSED, syscall.EPIPE, io.EOF, io.ErrUnexpectedEOF} {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Do calls send until it succeeds, the failure cannot be retried, or the attempts are used up
+// Connection errors, and 502, 503, and 504 responses to idempotent requests, are retried
+// after waiting for the policy's Delay. notify, if it is not nil, is called before each
+// retry. If ctx is cancelled while waiting it returns the context's error.
+// The last response or error is returned when it is out of attempts.
+func (p RetryPolicy) Do(ctx context.Context, method, route string, notify RetryFunc, send func() (*http.Response, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := send()
+		if attempt >= p.Attempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		var reason error
+		if err != nil && retryableError(method, err) {
+			reason = err
+		} else if err == nil && IdempotentMethod(method) && retryableStatus(resp.StatusCode) {
+			reason = fmt.Errorf("server returned status %d", resp.StatusCode)
+		} else {
+			return resp, err
+		}
+
+		delay := p.Delay(attempt)
+		if notify != nil {
+			notify(method, route, attempt, delay, reason)
+		}
+		if resp != nil && resp.Body != nil {
+			// Drain the body so that the connection can be reused
+			io.Copy(io.Discard, resp.Body) //nolint:errcheck
+			resp.Body.Close()              //nolint:errcheck
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
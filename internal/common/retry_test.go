@@ -0,0 +1,165 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPolicy retries quickly so that the tests don't wait
+var testPolicy = RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+
+// statusResponse returns a response with the status and an empty body
+func statusResponse(status int) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+	}
+}
+
+// dialError is the error returned by http.Client when the socket cannot be opened
+var dialError = &url.Error{Op: "Get", URL: "http://localhost/api/status",
+	Err: &net.OpError{Op: "dial", Net: "unix", Err: syscall.ENOENT}}
+
+// resetError is the error returned by http.Client when the server drops the connection
+var resetError = &url.Error{Op: "Get", URL: "http://localhost/api/status",
+	Err: &net.OpError{Op: "read", Net: "unix", Err: syscall.ECONNRESET}}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{Attempts: 6, Backoff: 500 * time.Millisecond, MaxBackoff: 3 * time.Second}
+	var delays []time.Duration
+	for retry := 1; retry <= 5; retry++ {
+		delays = append(delays, p.Delay(retry))
+	}
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}, delays)
+
+	// No limit
+	p.MaxBackoff = 0
+	assert.Equal(t, 8*time.Second, p.Delay(5))
+
+	// The jitter stays within the fraction of the delay
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.Delay(2)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, 1500*time.Millisecond)
+	}
+}
+
+func TestRetryValidate(t *testing.T) {
+	assert.Nil(t, DefaultRetryPolicy.Validate())
+	assert.Nil(t, RetryPolicy{}.Validate())
+	assert.ErrorContains(t, RetryPolicy{Attempts: -1}.Validate(), "attempts")
+	assert.ErrorContains(t, RetryPolicy{Backoff: -time.Second}.Validate(), "backoff")
+	assert.ErrorContains(t, RetryPolicy{Jitter: 1.5}.Validate(), "jitter")
+}
+
+func TestRetryableError(t *testing.T) {
+	assert.True(t, retryableError("GET", dialError))
+	assert.True(t, retryableError("POST", dialError))
+	assert.True(t, retryableError("GET", resetError))
+	assert.True(t, retryableError("DELETE", fmt.Errorf("reading body: %w", io.ErrUnexpectedEOF)))
+	assert.False(t, retryableError("POST", resetError))
+	assert.False(t, retryableError("GET", errors.New("unsupported protocol scheme")))
+	assert.False(t, retryableError("GET", context.Canceled))
+}
+
+func TestRetryDo(t *testing.T) {
+	var attempts int
+	var retries []string
+	notify := func(method, route string, retry int, delay time.Duration, err error) {
+		retries = append(retries, fmt.Sprintf("%s %s %d %s", method, route, retry, err))
+	}
+	resp, err := testPolicy.Do(context.Background(), "GET", "/api/status", notify, func() (*http.Response, error) {
+		attempts++
+		switch attempts {
+		case 1:
+			return nil, dialError
+		case 2:
+			return statusResponse(503), nil
+		}
+		return statusResponse(200), nil
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 3, attempts)
+	require.Equal(t, 2, len(retries))
+	assert.Contains(t, retries[0], "GET /api/status 1 ")
+	assert.Equal(t, "GET /api/status 2 server returned status 503", retries[1])
+}
+
+func TestRetryDoAttempts(t *testing.T) {
+	// The last response is returned when it runs out of attempts
+	var attempts int
+	resp, err := testPolicy.Do(context.Background(), "GET", "/api/status", nil, func() (*http.Response, error) {
+		attempts++
+		return statusResponse(502), nil
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 502, resp.StatusCode)
+	assert.Equal(t, 3, attempts)
+
+	// The zero policy sends it once
+	attempts = 0
+	_, err = RetryPolicy{}.Do(context.Background(), "GET", "/api/status", nil, func() (*http.Response, error) {
+		attempts++
+		return nil, resetError
+	})
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryDoNotIdempotent(t *testing.T) {
+	// POST is only retried if it never reached the server
+	var attempts int
+	resp, err := testPolicy.Do(context.Background(), "POST", "/api/v1/compose", nil, func() (*http.Response, error) {
+		attempts++
+		return statusResponse(503), nil
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 503, resp.StatusCode)
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	_, err = testPolicy.Do(context.Background(), "POST", "/api/v1/compose", nil, func() (*http.Response, error) {
+		attempts++
+		return nil, resetError
+	})
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	_, err = testPolicy.Do(context.Background(), "POST", "/api/v1/compose", nil, func() (*http.Response, error) {
+		attempts++
+		return nil, dialError
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryDoCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := RetryPolicy{Attempts: 3, Backoff: time.Hour}
+	notify := func(string, string, int, time.Duration, error) { cancel() }
+	start := time.Now()
+	_, err := p.Do(ctx, "GET", "/api/status", notify, func() (*http.Response, error) {
+		return nil, resetError
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Minute)
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"time"
+)
+
+// ComposeEvent is sent by a compose watcher when the status of the compose changes
+type ComposeEvent struct {
+	ID       string    // UUID of the compose
+	Status   string    // Status reported by the API, eg. RUNNING or pending
+	Previous string    // Status before the change, empty for the first event
+	State    string    // Status as WAITING, RUNNING, FINISHED, or FAILED for both APIs
+	Time     time.Time // When the change was seen
+	Done     bool      // The compose has finished or failed, this is the last event
+	Err      error     // The watch stopped because of an error, this is the last event
+}
+
+// ComposeStatusFunc returns the current status of a compose
+// The status is the one reported by the API, state is the common WAITING, RUNNING,
+// FINISHED, or FAILED status and done is true when the compose has finished or failed.
+type ComposeStatusFunc func() (status, state string, done bool, err error)
+
+// WatchCompose checks the status of a compose every interval and sends an event for each change
+// The first event has the status when the watch starts. The channel is closed after the
+// compose is done, when getting the status fails, or when ctx is cancelled. When getting
+// the status fails an event with Err set is sent before closing it. The caller must read
+// all of the events or cancel ctx, otherwise the watch is blocked.
+func WatchCompose(ctx context.Context, id string, interval time.Duration, getStatus ComposeStatusFunc) <-chan ComposeEvent {
+	events := make(chan ComposeEvent)
+	go func() {
+		defer close(events)
+
+		send := func(ev ComposeEvent) bool {
+			select {
+			case events <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var previous string
+		check := time.NewTimer(0)
+		defer check.Stop()
+		for {
+			select {
+			case <-check.C:
+				status, state, done, err := getStatus()
+				if err != nil {
+					send(ComposeEvent{ID: id, Previous: previous, Time: time.Now(), Err: err})
+					return
+				}
+				if status != previous || len(previous) == 0 {
+					ev := ComposeEvent{
+						ID:       id,
+						Status:   status,
+						Previous: previous,
+						State:    state,
+						Time:     time.Now(),
+						Done:     done,
+					}
+					if !send(ev) {
+						return
+					}
+					previous = status
+				}
+				if done {
+					return
+				}
+				check.Reset(interval)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
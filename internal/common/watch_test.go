@@ -0,0 +1,66 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusSequence returns a ComposeStatusFunc that returns each of the statuses in turn
+func statusSequence(statuses ...string) ComposeStatusFunc {
+	var i int
+	return func() (string, string, bool, error) {
+		status := statuses[min(i, len(statuses)-1)]
+		i++
+		if status == "error" {
+			return "", "", false, errors.New("status failed")
+		}
+		done := status == "FINISHED" || status == "FAILED"
+		return status, status, done, nil
+	}
+}
+
+func TestWatchCompose(t *testing.T) {
+	events := WatchCompose(context.Background(), "test-id", time.Millisecond,
+		statusSequence("WAITING", "WAITING", "RUNNING", "RUNNING", "RUNNING", "FINISHED"))
+
+	var transitions [][2]string
+	var last ComposeEvent
+	for ev := range events {
+		assert.Equal(t, "test-id", ev.ID)
+		assert.Nil(t, ev.Err)
+		transitions = append(transitions, [2]string{ev.Previous, ev.Status})
+		last = ev
+	}
+	assert.Equal(t, [][2]string{{"", "WAITING"}, {"WAITING", "RUNNING"}, {"RUNNING", "FINISHED"}}, transitions)
+	assert.True(t, last.Done)
+}
+
+func TestWatchComposeError(t *testing.T) {
+	events := WatchCompose(context.Background(), "test-id", time.Millisecond, statusSequence("RUNNING", "error"))
+
+	ev := <-events
+	assert.Equal(t, "RUNNING", ev.Status)
+	ev = <-events
+	require.NotNil(t, ev.Err)
+	assert.Equal(t, "status failed", ev.Err.Error())
+	assert.Equal(t, "RUNNING", ev.Previous)
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestWatchComposeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := WatchCompose(ctx, "test-id", time.Millisecond, statusSequence("RUNNING", "WAITING", "RUNNING"))
+
+	ev := <-events
+	assert.Equal(t, "RUNNING", ev.Status)
+	cancel()
+	for ev = range events {
+		assert.False(t, ev.Done)
+	}
+}
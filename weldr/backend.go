@@ -0,0 +1,224 @@
+// Copyright 2026 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package weldr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/osbuild/weldr-client/v2/internal/common"
+)
+
+// Compose is the status of a compose from either API, see Client.Composes
+type Compose = common.Compose
+
+// ComposeImage is one of the images built by a compose
+type ComposeImage = common.ComposeImage
+
+// ComposeUpload is the status of one of the compose's uploads
+type ComposeUpload = common.ComposeUpload
+
+// ComposeMetadata is the blueprint, images, and packages used by a compose
+type ComposeMetadata = common.ComposeMetadata
+
+// ComposeBackend is the set of compose operations supported by the weldr and cloud API clients
+type ComposeBackend = common.ComposeBackend
+
+// ErrUnknownCompose is returned by a ComposeBackend when the API does not have the compose
+var ErrUnknownCompose = common.ErrUnknownCompose
+
+// Composes returns the client's composes as a ComposeBackend
+// This handles composes the same way as the cloud API client's Composes, eg.
+//
+//	backend, compose, err := weldr.FindCompose(id, cloudClient.Composes(), weldrClient.Composes())
+func (c Client) Composes() ComposeBackend {
+	return composeBackend{c}
+}
+
+// FindCompose returns the backend that has the compose, and its status
+// The backends are tried in order. If none of them have it the error from the last one
+// is returned.
+func FindCompose(id string, backends ...ComposeBackend) (ComposeBackend, Compose, error) {
+	return common.FindCompose(id, backends...)
+}
+
+// SortComposes sorts the composes by status, blueprint name, version, and image types
+// It is the same order as SortComposeStatusV0.
+func SortComposes(composes []Compose) []Compose {
+	return common.SortComposes(composes)
+}
+
+// composeBackend implements ComposeBackend using the weldr API
+type composeBackend struct {
+	c Client
+}
+
+// weldrTime converts the API's float64 time to Time
+func weldrTime(t float64) time.Time {
+	if t <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(t*float64(time.Second)))
+}
+
+// responseError converts the API's error response to an error
+// An unknown UUID is returned as ErrUnknownCompose.
+func responseError(resp *APIResponse) error {
+	if resp.HasErrorID("UnknownUUID") {
+		return common.UnknownComposeError(strings.Join(resp.AllErrors(), ", "))
+	}
+	return fmt.Errorf("%s", strings.Join(resp.AllErrors(), ", "))
+}
+
+// errorsError converts a list of API errors to an error
+func errorsError(errors []APIErrorMsg) error {
+	return responseError(&APIResponse{Errors: errors})
+}
+
+// infoCompose converts the compose/info response to a Compose
+func infoCompose(info ComposeInfoV0) Compose {
+	compose := Compose{
+		ID:        info.ID,
+		API:       "weldr",
+		Status:    info.QueueStatus,
+		State:     info.QueueStatus,
+		Blueprint: info.Blueprint.Name,
+		Version:   info.Blueprint.Version,
+		Types:     []string{info.ComposeType},
+		Sizes:     []uint64{info.ImageSize},
+	}
+	for _, u := range info.Uploads {
+		compose.Uploads = append(compose.Uploads, ComposeUpload{Type: u.Provider, Status: u.Status})
+	}
+	return compose
+}
+
+func (b composeBackend) API() string {
+	return "weldr"
+}
+
+func (b composeBackend) List() ([]Compose, error) {
+	composes, errors, err := b.c.ListComposes()
+	if err != nil {
+		return nil, err
+	}
+	if len(errors) > 0 {
+		return nil, errorsError(errors)
+	}
+
+	var list []Compose
+	for _, c := range composes {
+		list = append(list, Compose{
+			ID:        c.ID,
+			API:       "weldr",
+			Status:    c.Status,
+			State:     c.Status,
+			Blueprint: c.Blueprint,
+			Version:   c.Version,
+			Types:     []string{c.Type},
+			Sizes:     []uint64{uint64(c.Size)},
+			Created:   weldrTime(c.JobCreated),
+			Started:   weldrTime(c.JobStarted),
+			Finished:  weldrTime(c.JobFinished),
+		})
+	}
+	return list, nil
+}
+
+func (b composeBackend) Info(id string) (Compose, error) {
+	info, resp, err := b.c.ComposeInfo(id)
+	if err != nil {
+		return Compose{}, err
+	}
+	if resp != nil {
+		return Compose{}, responseError(resp)
+	}
+	return infoCompose(info), nil
+}
+
+func (b composeBackend) Wait(id string, timeout, interval time.Duration) (bool, Compose, error) {
+	aborted, info, resp, err := b.c.ComposeWait(id, timeout, interval)
+	if err != nil {
+		return aborted, Compose{}, err
+	}
+	if resp != nil {
+		return aborted, Compose{}, responseError(resp)
+	}
+	return aborted, infoCompose(info), nil
+}
+
+func (b composeBackend) Delete(id string) error {
+	deleted, errors, err := b.c.DeleteComposes([]string{id})
+	if err != nil {
+		return err
+	}
+	if len(errors) > 0 {
+		return errorsError(errors)
+	}
+	if len(deleted) == 0 || !deleted[0].Status {
+		return fmt.Errorf("compose %s was not deleted", id)
+	}
+	return nil
+}
+
+func (b composeBackend) Cancel(id string) error {
+	status, errors, err := b.c.CancelCompose(id)
+	if err != nil {
+		return err
+	}
+	if len(errors) > 0 {
+		return errorsError(errors)
+	}
+	if !status.Status {
+		return fmt.Errorf("compose %s was not cancelled", id)
+	}
+	return nil
+}
+
+func (b composeBackend) Image(id, path string) (string, error) {
+	fn, resp, err := b.c.ComposeImagePath(id, path)
+	if err != nil {
+		return "", err
+	}
+	if resp != nil {
+		return "", responseError(resp)
+	}
+	return fn, nil
+}
+
+// Logs returns the last 1MB of the compose's log
+func (b composeBackend) Logs(id string) (string, error) {
+	log, resp, err := b.c.ComposeLog(id, 1024)
+	if err != nil {
+		return "", err
+	}
+	if resp != nil {
+		return "", responseError(resp)
+	}
+	return log, nil
+}
+
+// Metadata returns the details from compose/info, the weldr API builds one image
+// It does not report the image's architecture.
+func (b composeBackend) Metadata(id string) (ComposeMetadata, error) {
+	info, resp, err := b.c.ComposeInfo(id)
+	if err != nil {
+		return ComposeMetadata{}, err
+	}
+	if resp != nil {
+		return ComposeMetadata{}, responseError(resp)
+	}
+
+	image := ComposeImage{Type: info.ComposeType, Size: info.ImageSize, Uploads: []string{}}
+	for _, u := range info.Uploads {
+		image.Uploads = append(image.Uploads, u.Provider)
+	}
+	return ComposeMetadata{
+		Blueprint: info.Blueprint,
+		Images:    []ComposeImage{image},
+		Packages:  info.Deps.Packages,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package weldr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backendServer returns the responses for one finished compose
+// Any other compose is an unknown UUID.
+func backendServer(request *http.Request) (*http.Response, error) {
+	var sc int
+	var body string
+	switch request.URL.Path {
+	case "/api/v1/compose/queue":
+		sc = 200
+		body = `{"new": [], "run": []}`
+	case "/api/v1/compose/finished":
+		sc = 200
+		body = `{"finished": [{"id": "ddcf50e5-1ffa-4de6-95ed-42749ac1f389", "blueprint": "tmux-bcl",
+"version": "1.0.0", "compose_type": "qcow2", "image_size": 0, "queue_status": "FINISHED",
+"job_created": 1608149057.869176, "job_started": 1608149057.8762598, "job_finished": 1608149299.7789}]}`
+	case "/api/v1/compose/failed":
+		sc = 200
+		body = `{"failed": []}`
+	case "/api/v1/compose/info/ddcf50e5-1ffa-4de6-95ed-42749ac1f389":
+		sc = 200
+		body = `{"id": "ddcf50e5-1ffa-4de6-95ed-42749ac1f389", "compose_type": "qcow2",
+"queue_status": "FINISHED", "image_size": 2147483648,
+"blueprint": {"name": "tmux-bcl", "version": "1.0.0", "packages": [{"name": "tmux", "version": "*"}]},
+"deps": {"packages": [{"name": "tmux", "epoch": 0, "version": "3.1", "release": "1.fc33", "arch": "x86_64"}]},
+"uploads": [{"image_name": "tmux-image", "provider_name": "aws", "status": "FINISHED", "uuid": "0c7d8f6e"}]}`
+	case "/api/v1/compose/cancel/ddcf50e5-1ffa-4de6-95ed-42749ac1f389":
+		sc = 400
+		body = `{"status": false, "errors": [{"id": "BuildInWrongState", "msg": "Build ddcf50e5-1ffa-4de6-95ed-42749ac1f389 is not in WAITING or RUNNING."}]}`
+	default:
+		sc = 400
+		body = `{"status": false, "errors": [{"id": "UnknownUUID", "msg": "Compose 4b668b1a-e6b8-4dce-8828-4a8e3bef2345 doesn't exist"}]}`
+	}
+	return &http.Response{
+		Request:    request,
+		StatusCode: sc,
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+	}, nil
+}
+
+func TestComposesList(t *testing.T) {
+	mc := MockClient{DoFunc: backendServer}
+	tc := NewClient(context.Background(), &mc, 1, "")
+
+	composes, err := tc.Composes().List()
+	require.Nil(t, err)
+	require.Equal(t, 1, len(composes))
+	c := composes[0]
+	assert.Equal(t, "ddcf50e5-1ffa-4de6-95ed-42749ac1f389", c.ID)
+	assert.Equal(t, "weldr", c.API)
+	assert.Equal(t, "FINISHED", c.Status)
+	assert.Equal(t, "FINISHED", c.State)
+	assert.Equal(t, "tmux-bcl", c.Blueprint)
+	assert.Equal(t, []string{"qcow2"}, c.Types)
+	assert.Equal(t, time.Date(2020, 12, 16, 20, 4, 17, 0, time.UTC), c.Created.UTC().Truncate(time.Second))
+	assert.Equal(t, time.Date(2020, 12, 16, 20, 8, 19, 0, time.UTC), c.Finished.UTC().Truncate(time.Second))
+	assert.Equal(t, "weldr", tc.Composes().API())
+}
+
+func TestComposesInfo(t *testing.T) {
+	mc := MockClient{DoFunc: backendServer}
+	tc := NewClient(context.Background(), &mc, 1, "")
+
+	c, err := tc.Composes().Info("ddcf50e5-1ffa-4de6-95ed-42749ac1f389")
+	require.Nil(t, err)
+	assert.Equal(t, Compose{
+		ID:        "ddcf50e5-1ffa-4de6-95ed-42749ac1f389",
+		API:       "weldr",
+		Status:    "FINISHED",
+		State:     "FINISHED",
+		Blueprint: "tmux-bcl",
+		Version:   "1.0.0",
+		Types:     []string{"qcow2"},
+		Sizes:     []uint64{2147483648},
+		Uploads:   []ComposeUpload{{Type: "aws", Status: "FINISHED"}},
+	}, c)
+
+	_, err = tc.Composes().Info("4b668b1a-e6b8-4dce-8828-4a8e3bef2345")
+	require.NotNil(t, err)
+	assert.True(t, errors.Is(err, ErrUnknownCompose))
+	assert.Equal(t, "UnknownUUID: Compose 4b668b1a-e6b8-4dce-8828-4a8e3bef2345 doesn't exist", err.Error())
+}
+
+func TestComposesMetadata(t *testing.T) {
+	mc := MockClient{DoFunc: backendServer}
+	tc := NewClient(context.Background(), &mc, 1, "")
+
+	metadata, err := tc.Composes().Metadata("ddcf50e5-1ffa-4de6-95ed-42749ac1f389")
+	require.Nil(t, err)
+	assert.Equal(t, "tmux-bcl", metadata.Blueprint.Name)
+	assert.Equal(t, []ComposeImage{{Type: "qcow2", Size: 2147483648, Uploads: []string{"aws"}}}, metadata.Images)
+	require.Equal(t, 1, len(metadata.Packages))
+	assert.Equal(t, "tmux-3.1-1.fc33.x86_64", metadata.Packages[0].String())
+}
+
+func TestComposesCancel(t *testing.T) {
+	mc := MockClient{DoFunc: backendServer}
+	tc := NewClient(context.Background(), &mc, 1, "")
+
+	err := tc.Composes().Cancel("ddcf50e5-1ffa-4de6-95ed-42749ac1f389")
+	require.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrUnknownCompose))
+	assert.Contains(t, err.Error(), "BuildInWrongState")
+}
+
+func TestFindCompose(t *testing.T) {
+	mc := MockClient{DoFunc: backendServer}
+	tc := NewClient(context.Background(), &mc, 1, "")
+
+	b, c, err := FindCompose("ddcf50e5-1ffa-4de6-95ed-42749ac1f389", tc.Composes())
+	require.Nil(t, err)
+	assert.Equal(t, "weldr", b.API())
+	assert.True(t, c.Done())
+
+	_, _, err = FindCompose("4b668b1a-e6b8-4dce-8828-4a8e3bef2345", tc.Composes())
+	assert.True(t, errors.Is(err, ErrUnknownCompose))
+}
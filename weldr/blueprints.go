@@ -8,8 +8,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/osbuild/weldr-client/v2/internal/common"
 )
 
+// Blueprint is the complete blueprint, see common.Blueprint
+type Blueprint = common.Blueprint
+
+// Customizations are the blueprint's customizations, see common.Customizations
+type Customizations = common.Customizations
+
+// ParseBlueprintTOML parses a TOML blueprint, keeping any unknown fields
+var ParseBlueprintTOML = common.ParseBlueprintTOML
+
+// BlueprintTOML returns the blueprint as TOML, including any unknown fields
+var BlueprintTOML = common.BlueprintTOML
+
+// BlueprintFromJSON converts a blueprint returned by GetBlueprintsJSON into a Blueprint
+var BlueprintFromJSON = common.BlueprintFromJSON
+
 // ListBlueprints returns a list of all of the blueprints available
 func (c Client) ListBlueprints() ([]string, *APIResponse, error) {
 	body, resp, err := c.GetJSONAll("/blueprints/list")
@@ -41,6 +58,35 @@ func (c Client) GetBlueprintsTOML(names []string) ([]string, *APIResponse, error
 	return result, nil, nil
 }
 
+// GetBlueprints returns the listed blueprints as Blueprints
+// Fields that are not part of the Blueprint schema are kept in its Extra maps so that
+// the blueprint can be edited and pushed back to the server without losing them.
+func (c Client) GetBlueprints(names []string) ([]Blueprint, *APIResponse, error) {
+	blueprints, resp, err := c.GetBlueprintsTOML(names)
+	if resp != nil || err != nil {
+		return nil, resp, err
+	}
+	var result []Blueprint
+	for _, data := range blueprints {
+		bp, err := ParseBlueprintTOML([]byte(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, bp)
+	}
+	return result, nil, nil
+}
+
+// PushBlueprint pushes a Blueprint as a new commit
+// When successful the response will have Status = true
+func (c Client) PushBlueprint(bp Blueprint) (*APIResponse, error) {
+	data, err := BlueprintTOML(bp)
+	if err != nil {
+		return nil, err
+	}
+	return c.PushBlueprintTOML(string(data))
+}
+
 // GetFrozenBlueprintsTOML returns the listed blueprints as TOML strings
 // These blueprints are 'frozen', their package versions have been depsolved and are set to
 // the exact EVRA value.
@@ -15,6 +15,8 @@ import (
 
 	"github.com/BurntSushi/toml"
 
+	"github.com/osbuild/weldr-client/v2/internal/common"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -37,6 +39,15 @@ func TestGetBlueprintsTOML(t *testing.T) {
 	assert.GreaterOrEqual(t, len(blueprints), 2)
 }
 
+func TestGetBlueprints(t *testing.T) {
+	blueprints, r, err := testState.client.GetBlueprints([]string{"cli-test-bp-1"})
+	require.Nil(t, err)
+	require.Nil(t, r)
+	require.Equal(t, 1, len(blueprints))
+	assert.Equal(t, "cli-test-bp-1", blueprints[0].Name)
+	assert.Equal(t, "0.1.0", blueprints[0].Version)
+}
+
 func TestGetBlueprintsJSON(t *testing.T) {
 	blueprints, errors, err := testState.client.GetBlueprintsJSON([]string{"cli-test-bp-1", "cli-test-bp-2", "unknown-cli-bp"})
 	require.Nil(t, err)
@@ -95,6 +106,25 @@ func TestPushBlueprintTOML(t *testing.T) {
 	assert.True(t, r.Status)
 }
 
+func TestPushBlueprint(t *testing.T) {
+	bp := Blueprint{
+		Name:        "test-typed-blueprint-v0",
+		Description: "postBlueprintV0",
+		Version:     "0.0.1",
+		Packages:    []common.Package{{Name: "bash", Version: "*"}},
+	}
+	r, err := testState.client.PushBlueprint(bp)
+	require.Nil(t, err)
+	require.NotNil(t, r)
+	assert.True(t, r.Status)
+
+	blueprints, r, err := testState.client.GetBlueprints([]string{"test-typed-blueprint-v0"})
+	require.Nil(t, err)
+	require.Nil(t, r)
+	require.Equal(t, 1, len(blueprints))
+	assert.Equal(t, bp.Packages, blueprints[0].Packages)
+}
+
 func TestPushBlueprintTOMLError(t *testing.T) {
 	// Use a blueprint that's missing a trailing ']' on package
 	bp := `
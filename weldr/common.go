@@ -7,6 +7,7 @@ package weldr
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"net/http"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/osbuild/weldr-client/v2/internal/common"
 )
@@ -50,6 +52,36 @@ func InitClientUnixSocket(ctx context.Context, apiVersion int, socketPath string
 	return NewClient(ctx, socket, apiVersion, socketPath)
 }
 
+// InitClientURL configures the client to connect to a remote server using http or https
+// serverURL is the protocol, host, and optional port, eg. https://builder.example.com:443
+// tlsConfig is used for https, it can be created with the CA bundle and client certificate
+// using NewTLSConfig. If it is nil the system defaults are used.
+// It must be called before using any of the weldr.Client functions.
+func InitClientURL(ctx context.Context, apiVersion int, serverURL string, tlsConfig *tls.Config) (Client, error) {
+	socket, protocol, host, err := common.NewRemoteHTTPClient(serverURL, tlsConfig)
+	if err != nil {
+		return Client{}, err
+	}
+	client := NewClient(ctx, socket, apiVersion, "")
+	client.protocol = protocol
+	client.host = host
+	client.remote = true
+	return client, nil
+}
+
+// NewTLSConfig returns a tls.Config for use with InitClientURL
+// caFile is a PEM bundle used to verify the server, the system CAs are used if it is empty.
+// certFile and keyFile are the optional PEM client certificate and key.
+func NewTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	return common.NewTLSConfig(caFile, certFile, keyFile)
+}
+
+// RetryPolicy controls how requests are retried, see SetRetryPolicy
+type RetryPolicy = common.RetryPolicy
+
+// DefaultRetryPolicy retries 3 times, waiting about 0.5, 1, and 2 seconds
+var DefaultRetryPolicy = common.DefaultRetryPolicy
+
 // Client contains details about the API server connection as well as functions to interact with the server
 type Client struct {
 	ctx        context.Context
@@ -59,6 +91,13 @@ type Client struct {
 	socketPath string
 	version    int
 	rawFunc    func(string, string, int, []byte) // Pass the raw json data to a user function
+	logger     *common.RequestLogger             // Optional trace log of all requests
+	timeout    time.Duration                     // Maximum time to wait for a response, 0 for no limit
+	remote     bool                              // Connected to a remote server instead of a socket
+	token      string                            // Optional bearer token sent with every request
+	progress   common.ProgressFunc               // Optional progress reporting for file downloads
+	retry      RetryPolicy                       // Retry failed connections, disabled by default
+	retryFunc  common.RetryFunc                  // Optional function called before each retry
 }
 
 // SetRawCallback sets a function that will be called with from the server response
@@ -67,6 +106,72 @@ func (c *Client) SetRawCallback(f func(string, string, int, []byte)) {
 	c.rawFunc = f
 }
 
+// SetTimeout sets the maximum time to wait for the server to respond to a request
+// It does not limit the time it takes to read the response body. 0 disables the timeout.
+// Cancelling the client's context aborts requests no matter what the timeout is.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// SetToken sets a bearer token that is sent with every request
+// This is used to authenticate with a remote server. Pass "" to disable it.
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// SetLogWriter enables a JSON-lines trace log of every request made by the client
+// Each line records the time, method, route, status, latency, and the start of the
+// response body. Pass nil to disable logging.
+func (c *Client) SetLogWriter(w io.Writer) {
+	c.logger = common.NewRequestLogger(w)
+}
+
+// SetProgressCallback sets a function that is called as files are downloaded
+// It is passed the name of the file, the bytes received, the total size or -1 if it is
+// unknown, and true when the download has finished or failed. Pass nil to disable it.
+func (c *Client) SetProgressCallback(f func(fileName string, received, total int64, done bool)) {
+	c.progress = f
+}
+
+// SetProgressWriter writes the progress of file downloads to w
+// If w is a terminal a single line with the size, rate, and time remaining is updated
+// while downloading, otherwise a line is written every 10 seconds. Pass nil to disable it.
+func (c *Client) SetProgressWriter(w io.Writer) {
+	if w == nil {
+		c.progress = nil
+		return
+	}
+	c.progress = common.NewProgressWriter(w, common.IsTerminal(w))
+}
+
+// SetRetryPolicy sets how requests are retried when the connection fails
+// Requests that could not connect to the server are retried, and so are idempotent requests
+// that lost the connection or got a 502, 503, or 504 response. The zero RetryPolicy
+// disables retries, which is the default.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// SetRetryCallback sets a function that is called before a request is retried
+// It is passed the method, route, the number of the retry, the time it will wait before
+// sending it, and the reason the last attempt failed. Pass nil to disable it.
+func (c *Client) SetRetryCallback(f func(method, route string, retry int, delay time.Duration, err error)) {
+	c.retryFunc = f
+}
+
+// send makes the request, retrying it according to the client's RetryPolicy
+// Each attempt is written to the request log.
+func (c Client) send(method, url, body string, headers map[string]string) (*http.Response, error) {
+	route := common.RequestURI(url)
+	headers = common.AddBearerToken(headers, c.token)
+	return c.retry.Do(c.ctx, method, route, c.retryFunc, func() (*http.Response, error) {
+		start := time.Now()
+		resp, err := common.DoRequest(c.ctx, c.socket, c.timeout, method, url, bytes.NewReader([]byte(body)), headers)
+		c.logger.Log(method, route, start, resp, err)
+		return resp, err
+	})
+}
+
 // APIURL returns the full url for a given route, including protocol, host, and api version
 func (c Client) APIURL(route string) string {
 	if route[0] == '/' {
@@ -91,17 +196,14 @@ func (c Client) RawURL(route string) string {
 // If it is successful a http.Response will be returned. If there is an error, the response will be
 // nil and error will be returned.
 func (c Client) Request(method, route, body string, headers map[string]string) (*http.Response, error) {
-	req, err := http.NewRequest(method, c.APIURL(route), bytes.NewReader([]byte(body)))
-	if err != nil {
-		return nil, common.CheckSocketError(c.socketPath, err)
-	}
-
-	for h, v := range headers {
-		req.Header.Set(h, v)
-	}
-
-	resp, err := c.socket.Do(req)
-	if err != nil {
+	url := c.APIURL(route)
+	resp, err := c.send(method, url, body, headers)
+	if err != nil && c.ctx.Err() != nil {
+		// Cancelled by the caller, not a problem with the socket
+		return nil, err
+	} else if err != nil && c.remote {
+		return nil, err
+	} else if err != nil {
 		return nil, common.CheckSocketError(c.socketPath, err)
 	}
 
@@ -118,17 +220,14 @@ func (c Client) Request(method, route, body string, headers map[string]string) (
 //
 // This request method does not add the API path and version to the request.
 func (c Client) RequestRawURL(method, route, body string, headers map[string]string) (*http.Response, error) {
-	req, err := http.NewRequest(method, c.RawURL(route), bytes.NewReader([]byte(body)))
-	if err != nil {
-		return nil, common.CheckSocketError(c.socketPath, err)
-	}
-
-	for h, v := range headers {
-		req.Header.Set(h, v)
-	}
-
-	resp, err := c.socket.Do(req)
-	if err != nil {
+	url := c.RawURL(route)
+	resp, err := c.send(method, url, body, headers)
+	if err != nil && c.ctx.Err() != nil {
+		// Cancelled by the caller, not a problem with the socket
+		return nil, err
+	} else if err != nil && c.remote {
+		return nil, err
+	} else if err != nil {
 		return nil, common.CheckSocketError(c.socketPath, err)
 	}
 
@@ -257,6 +356,9 @@ func (c Client) GetFile(path string) (fileName, cDisposition, cType string, apiR
 // If the path doesn't end in a / it is assumed to be a full path + filename and the file is
 // saved to it, or skipped if it already exists.
 // If the path ends with a / and doesn't exist it returns an error
+// The file is downloaded to a .part file which is resumed if the download is interrupted,
+// and it is checked against the server's checksum if one is included in the response.
+// The progress is reported to the SetProgressCallback or SetProgressWriter hook.
 func (c Client) GetFilePath(route, path string) (string, *APIResponse, error) {
 	resp, err := c.Request("GET", route, "", map[string]string{})
 	if err != nil {
@@ -271,7 +373,22 @@ func (c Client) GetFilePath(route, path string) (string, *APIResponse, error) {
 		return "", apiResponse, err
 	}
 
-	fileName, err := common.SaveResponseBodyToFile(resp, path)
+	// Resume the download with a Range request if it is interrupted
+	getRange := func(headers map[string]string) (*http.Response, error) {
+		resp, err := c.Request("GET", route, "", headers)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == 400 || resp.StatusCode == 404 || resp.StatusCode == 500 {
+			apiResponse, err := c.apiError(resp)
+			if err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%s", apiResponse)
+		}
+		return resp, nil
+	}
+	fileName, err := common.DownloadResponseBody(resp, path, getRange, c.progress)
 	return fileName, nil, err
 }
 
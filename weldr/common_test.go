@@ -4,12 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strconv"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -87,6 +93,41 @@ func TestRequestPostBody(t *testing.T) {
 	assert.Equal(t, "/api/v1/testroute", mc.Req.URL.Path)
 }
 
+func TestRequestContext(t *testing.T) {
+	// Test that requests use the client's context
+	mc := MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	tc := NewClient(ctx, &mc, 1, "")
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := tc.Request("GET", "/testroute", "", map[string]string{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	// Test that the timeout aborts a request the server doesn't respond to
+	mc := MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		},
+	}
+	// The socket needs to exist so that the timeout error is returned
+	socket, err := os.CreateTemp(t.TempDir(), "api.socket")
+	require.Nil(t, err)
+	require.Nil(t, socket.Close())
+	tc := NewClient(context.Background(), &mc, 1, socket.Name())
+	tc.SetTimeout(10 * time.Millisecond)
+
+	_, err = tc.Request("GET", "/testroute", "", map[string]string{})
+	assert.ErrorContains(t, err, "no response from the server")
+}
+
 func TestRequestMethods404(t *testing.T) {
 	// Test the GET, POST, DELETE methods
 	mc := MockClient{
@@ -769,6 +810,80 @@ func TestRawCallbackResponse(t *testing.T) {
 	assert.Equal(t, []byte(json), rawData)
 }
 
+func TestLogWriter(t *testing.T) {
+	// Test writing a trace log of the requests
+	mc := MockClient{
+		DoFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewReader([]byte(`{"blueprints": []}`))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, 1, "")
+	var log bytes.Buffer
+	tc.SetLogWriter(&log)
+
+	body, r, err := tc.GetRaw("GET", "/blueprints/list")
+	require.Nil(t, err)
+	require.Nil(t, r)
+	assert.Equal(t, []byte(`{"blueprints": []}`), body)
+
+	var entry struct {
+		Method string  `json:"method"`
+		Route  string  `json:"route"`
+		Status int     `json:"status"`
+		Body   string  `json:"body"`
+		Time   string  `json:"time"`
+		Lat    float64 `json:"latency_ms"`
+	}
+	err = json.Unmarshal(log.Bytes(), &entry)
+	require.Nil(t, err)
+	assert.Equal(t, "GET", entry.Method)
+	assert.Equal(t, "/api/v1/blueprints/list", entry.Route)
+	assert.Equal(t, 200, entry.Status)
+	assert.Equal(t, `{"blueprints": []}`, entry.Body)
+	assert.NotEmpty(t, entry.Time)
+}
+
+func TestRetry(t *testing.T) {
+	// Test retrying a GET when the connection to the server is dropped
+	var attempts int
+	mc := MockClient{
+		DoFunc: func(request *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, &net.OpError{Op: "read", Net: "unix", Err: syscall.ECONNRESET}
+			}
+			return &http.Response{
+				Request:    request,
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewReader([]byte(`{"blueprints": []}`))),
+			}, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, 1, "")
+	tc.SetRetryPolicy(RetryPolicy{Attempts: 3, Backoff: time.Millisecond})
+	var retries []string
+	tc.SetRetryCallback(func(method, route string, retry int, delay time.Duration, err error) {
+		retries = append(retries, fmt.Sprintf("%s %s %d", method, route, retry))
+	})
+
+	body, r, err := tc.GetRaw("GET", "/blueprints/list")
+	require.Nil(t, err)
+	require.Nil(t, r)
+	assert.Equal(t, []byte(`{"blueprints": []}`), body)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []string{"GET /api/v1/blueprints/list 1"}, retries)
+
+	// A POST that lost the connection may have reached the server, it is not retried
+	attempts = 0
+	_, _, err = tc.PostTOML("/blueprints/new", `name = "test"`)
+	require.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, 1, len(retries))
+}
+
 func TestGetFile(t *testing.T) {
 	// Test retrieving a file
 	mc := MockClient{
@@ -920,6 +1035,88 @@ func TestGetFileMissingDir(t *testing.T) {
 	assert.ErrorContains(t, err, "no such file or directory")
 }
 
+// failingReader returns the data and then an error, like a dropped connection
+type failingReader struct {
+	r io.Reader
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, fmt.Errorf("connection reset by peer")
+	}
+	return n, err
+}
+
+func TestGetFilePathResume(t *testing.T) {
+	// Test resuming an interrupted download with a Range request
+	var ranges []string
+	mc := MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			resp := http.Response{
+				Request: req,
+				Header:  http.Header{},
+			}
+			resp.Header.Set("Content-Disposition", "attachment; filename=a-very-short-file.txt")
+			resp.Header.Set("Content-Type", "text/plain")
+			resp.Header.Set("ETag", `"short-file"`)
+
+			if r := req.Header.Get("Range"); len(r) > 0 {
+				ranges = append(ranges, r)
+				resp.StatusCode = 206
+				resp.Header.Set("Content-Range", "bytes 7-17/18")
+				resp.Body = io.NopCloser(bytes.NewReader([]byte("Short File.")))
+			} else {
+				resp.StatusCode = 200
+				resp.Body = io.NopCloser(&failingReader{bytes.NewReader([]byte("A Very "))})
+			}
+			return &resp, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, 1, "")
+
+	tf := filepath.Join(t.TempDir(), "a-new-file.txt")
+	filename, r, err := tc.GetFilePath("/file/a-very-short-file", tf)
+	require.Nil(t, err)
+	require.Nil(t, r)
+	assert.Equal(t, tf, filename)
+	assert.Equal(t, []string{"bytes=7-"}, ranges)
+	data, err := os.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, []byte("A Very Short File."), data)
+	_, err = os.Stat(filename + ".part")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestGetFilePathProgress(t *testing.T) {
+	mc := MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			resp := http.Response{
+				Request:       req,
+				StatusCode:    200,
+				Header:        http.Header{},
+				ContentLength: 18,
+				Body:          io.NopCloser(bytes.NewReader([]byte("A Very Short File."))),
+			}
+			resp.Header.Set("Content-Disposition", "attachment; filename=a-very-short-file.txt")
+			return &resp, nil
+		},
+	}
+	tc := NewClient(context.Background(), &mc, 1, "")
+	var received, total int64
+	var finished bool
+	tc.SetProgressCallback(func(fileName string, r, size int64, done bool) {
+		assert.Equal(t, "a-very-short-file.txt", fileName)
+		received, total, finished = r, size, done
+	})
+
+	_, _, err := tc.GetFilePath("/file/a-very-short-file", t.TempDir())
+	require.Nil(t, err)
+	assert.Equal(t, int64(18), received)
+	assert.Equal(t, int64(18), total)
+	assert.True(t, finished)
+}
+
 func TestGetFilePathError400(t *testing.T) {
 	mc := MockClient{
 		DoFunc: func(req *http.Request) (*http.Response, error) {
@@ -1034,3 +1231,69 @@ func TestSortComposeStatus(t *testing.T) {
 	}
 	assert.Equal(t, sorted, SortComposeStatusV0(unsorted))
 }
+
+func TestInitClientURL(t *testing.T) {
+	// Test connecting to a remote server over https with a bearer token
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"status": false, "errors": [{"id": "Unauthorized", "msg": "missing token"}]}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path": "%s"}`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	// Write the server's certificate to a CA bundle
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.Nil(t, os.WriteFile(caFile, ca, 0600))
+	tlsConfig, err := NewTLSConfig(caFile, "", "")
+	require.Nil(t, err)
+
+	tc, err := InitClientURL(context.Background(), 1, server.URL, tlsConfig)
+	require.Nil(t, err)
+	tc.SetToken("secret-token")
+
+	body, r, err := tc.GetRaw("GET", "/status")
+	require.Nil(t, r)
+	require.Nil(t, err)
+	assert.Equal(t, `{"path": "/api/v1/status"}`, string(body))
+
+	// Without the token the server refuses the request
+	tc.SetToken("")
+	_, r, err = tc.GetRaw("GET", "/status")
+	assert.Nil(t, err)
+	assert.NotNil(t, r)
+}
+
+func TestInitClientURLBadCA(t *testing.T) {
+	// Test that the server certificate is verified
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "{}")
+	}))
+	defer server.Close()
+
+	tc, err := InitClientURL(context.Background(), 1, server.URL, nil)
+	require.Nil(t, err)
+	_, _, err = tc.GetRaw("GET", "/status")
+	assert.ErrorContains(t, err, "certificate")
+}
+
+func TestInitClientURLError(t *testing.T) {
+	_, err := InitClientURL(context.Background(), 1, "ftp://builder", nil)
+	assert.ErrorContains(t, err, "protocol must be http or https")
+}
+
+func TestNewLogOutput(t *testing.T) {
+	assert.Equal(t, "line 1\n", newLogOutput("", "line 1\n"))
+	assert.Equal(t, "", newLogOutput("line 1\n", "line 1\n"))
+	assert.Equal(t, "line 2\n", newLogOutput("line 1\n", "line 1\nline 2\n"))
+
+	// The start of the tail moves as the log grows
+	assert.Equal(t, "line 3\n", newLogOutput("line 1\nline 2\n", "line 2\nline 3\n"))
+
+	// The log grew by more than the size of the tail
+	assert.Equal(t, "...\nline 5\nline 6\n", newLogOutput("line 1\nline 2\n", "line 5\nline 6\n"))
+}
@@ -7,10 +7,13 @@ package weldr
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/osbuild/weldr-client/v2/internal/common"
 )
 
 // ListComposes returns details about the composes on the server
@@ -337,6 +340,102 @@ func (c Client) ComposeLog(id string, size int) (string, *APIResponse, error) {
 	return string(body), nil, nil
 }
 
+// ComposeEvent is a change in the status of a compose
+// It is the same type for the weldr and cloud API clients, see ComposeWatch.
+type ComposeEvent = common.ComposeEvent
+
+// ComposeWatch sends an event on the channel each time the status of the compose changes
+// The status is checked every interval, the first event has the status when the watch
+// starts, eg. WAITING -> RUNNING -> FINISHED. The channel is closed after the compose is
+// FINISHED or FAILED, or when there is an error getting the status. Errors, including API
+// errors like an unknown UUID, are sent as a last event with Err set. The caller must read
+// the events until the channel is closed, or cancel the client's context to stop it.
+func (c Client) ComposeWatch(id string, interval time.Duration) <-chan ComposeEvent {
+	return common.WatchCompose(c.ctx, id, interval, func() (string, string, bool, error) {
+		info, resp, err := c.ComposeInfo(id)
+		if err != nil {
+			return "", "", false, err
+		}
+		if resp != nil {
+			return "", "", false, fmt.Errorf("%s", resp)
+		}
+		done := info.QueueStatus == "FINISHED" || info.QueueStatus == "FAILED"
+		return info.QueueStatus, info.QueueStatus, done, nil
+	})
+}
+
+// ComposeLogFollow writes the log of a compose to w as it is built
+// It checks the status and the last size kB of the log every interval, writing any new
+// lines, until the compose is FINISHED or FAILED. It returns the final status of the compose.
+// If the log grows by more than size kB between checks the missing part is skipped, and
+// a line with '...' is written in its place.
+func (c Client) ComposeLogFollow(id string, size int, interval time.Duration, w io.Writer) (info ComposeInfoV0, resp *APIResponse, err error) {
+	var last string
+	check := time.NewTimer(0)
+	defer check.Stop()
+	for {
+		select {
+		case <-check.C:
+			info, resp, err = c.ComposeInfo(id)
+			if err != nil || resp != nil {
+				return info, resp, err
+			}
+			done := info.QueueStatus == "FINISHED" || info.QueueStatus == "FAILED"
+
+			// There is no log until the compose starts running
+			if info.QueueStatus != "WAITING" {
+				log, logResp, err := c.ComposeLog(id, size)
+				if err != nil {
+					return info, nil, err
+				}
+				if logResp != nil && (!done || len(last) == 0) {
+					return info, logResp, nil
+				}
+				if logResp == nil {
+					if _, err := io.WriteString(w, newLogOutput(last, log)); err != nil {
+						return info, nil, err
+					}
+					if len(log) > 0 {
+						last = log
+					}
+				}
+			}
+			if done {
+				return info, nil, nil
+			}
+			check.Reset(interval)
+		case <-c.ctx.Done():
+			// Cancelled by the caller, eg. ctrl-c
+			return info, nil, c.ctx.Err()
+		}
+	}
+}
+
+// newLogOutput returns the part of the log tail that has not been written yet
+// last is the previous tail of the log, current is the new one. The tail only moves forward,
+// so the new output starts after the longest end of last that current starts with. If they
+// do not overlap the log grew by more than the size of the tail, it is all returned after
+// a '...' line.
+func newLogOutput(last, current string) string {
+	if len(last) == 0 || len(current) == 0 {
+		return current
+	}
+
+	// Only check the places in last that start with the first byte of current
+	for i := 0; i < len(last); {
+		j := strings.IndexByte(last[i:], current[0])
+		if j < 0 {
+			break
+		}
+		i += j
+		if strings.HasPrefix(current, last[i:]) {
+			return current[len(last)-i:]
+		}
+		i++
+	}
+	return "...\n" + current
+}
+
 // ComposeLogs saves the compose's logs to a file in the current directory
 // It returns the filename, the server response, and the error.
 func (c Client) ComposeLogs(id string) (fileName string, apiResponse *APIResponse, err error) {
@@ -432,6 +531,9 @@ func (c Client) ComposeWait(id string, timeout, interval time.Duration) (aborted
 				return false, info, resp, err
 			}
 			check.Reset(interval)
+		case <-c.ctx.Done():
+			// Cancelled by the caller, eg. ctrl-c
+			return false, info, nil, c.ctx.Err()
 		case <-abort.C:
 			// Timed out, but no errors to report, info will have last status
 			return true, info, nil, nil
@@ -9,6 +9,19 @@ For normal usage InitClientUnixSocket() should be called with the api version
 and full path of the server's Unix Domain Socket. It will return a weldr.Client
 struct that you can then use to interact with the server.
 
+To connect to a remote server use InitClientURL() with the server's url, and
+optionally a tls.Config created by NewTLSConfig() with the CA bundle and client
+certificate. A bearer token can be set with Client.SetToken().
+
+Client.Composes() returns a ComposeBackend, the cloud.Client has the same method.
+It lists, waits for, and downloads composes the same way for both APIs, use
+FindCompose() to find the API that has a compose.
+
+Blueprint is the complete blueprint schema. Use ParseBlueprintTOML() and
+BlueprintTOML() to read and write it, or Client.GetBlueprints() and
+Client.PushBlueprint() to use it with the server. Fields that are not part of
+the schema are kept in the Extra maps so that they are not lost when editing.
+
 For testing you can initialize a temporary weldr.Client using weldr.NewClient(),
 this is used in the weldr test functions.
 */